@@ -0,0 +1,107 @@
+// Command import loads time entries exported from Toggl, Harvest or Clockify into a team.
+// With `-source ics` it reads a calendar file instead and proposes timers to the attendees of its meetings.
+// Run it from the project root so it picks up config.yml:
+//
+//	SLACK_TIME_ENV=production go run cmd/import/main.go -team T0123 -source toggl -file export.csv -dry-run
+//	SLACK_TIME_ENV=production go run cmd/import/main.go -team T0123 -source ics -file calendar.ics -dry-run
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+)
+
+const (
+	version   = "0.1.0"
+	icsSource = "ics"
+)
+
+func main() {
+	time.Local = time.UTC
+
+	teamID := flag.String("team", "", "Slack team ID to import the entries into")
+	source := flag.String("source", "", "the export format: toggl, harvest, clockify or ics")
+	fileName := flag.String("file", "", "path to the CSV export or ICS calendar")
+	dryRun := flag.Bool("dry-run", false, "only report what would be imported or proposed")
+	flag.Parse()
+
+	if *teamID == "" || *source == "" || *fileName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	env := os.Getenv("SLACK_TIME_ENV")
+	if env == "" {
+		env = utils.DevelopmentEnv
+	}
+	environment := utils.NewEnvironment(env, version)
+
+	session, err := utils.ConnectToDatabase(environment.Config)
+	if err != nil {
+		log.Fatalf("Failed to connect to Database: %s", err)
+	}
+	defer session.Close()
+
+	team, err := data.NewTeamRepository(session).FindByExternalID(*teamID)
+	if err != nil {
+		log.Fatalf("Failed to find team %s: %s", *teamID, err)
+	}
+
+	file, err := os.Open(*fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	if *source == icsSource {
+		report, err := data.NewMeetingService(session).ProposeFromCalendar(nil, team, file, time.Now(), *dryRun)
+		if err != nil {
+			log.Fatalf("Import failed: %s", err)
+		}
+		printMeetingsReport(report)
+		return
+	}
+
+	report, err := data.NewImportService(session).Import(team, *source, file, *dryRun)
+	if err != nil {
+		log.Fatalf("Import failed: %s", err)
+	}
+	printReport(report)
+}
+
+func printReport(report *models.ImportReport) {
+	if report.DryRun {
+		fmt.Println("Dry run, nothing is saved")
+	}
+	fmt.Printf("Entries: %d, imported: %d\n", report.Entries, report.Imported)
+	printList("Unmapped users", report.UnmappedUsers)
+	printList("New projects", report.NewProjects)
+	printList("Duplicates", report.Duplicates)
+	printList("Locked by approved timesheets", report.Locked)
+	printList("Errors", report.Errors)
+}
+
+func printMeetingsReport(report *models.MeetingProposalsReport) {
+	if report.DryRun {
+		fmt.Println("Dry run, nothing is saved")
+	}
+	fmt.Printf("Events: %d, proposed: %d, proposed before: %d\n", report.Events, report.Proposed, report.AlreadyProposed)
+	printList("Unmatched attendees", report.UnmatchedAttendees)
+}
+
+func printList(title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", title)
+	for _, item := range items {
+		fmt.Printf("  %s\n", item)
+	}
+}
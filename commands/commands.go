@@ -10,8 +10,15 @@ const (
 	CommandNameStart  = "start"
 	CommandNameStop   = "stop"
 	CommandNameStatus = "status"
+	CommandNamePomodoro = "pomodoro"
+	CommandNameReport = "report"
+	CommandNameOff = "off"
+	CommandNameIdle = "idle"
+	CommandNameFind = "find"
 )
 
+const forbiddenMessage = "Your role in this team does not allow this command. Please ask the team owner for a different role."
+
 type ResponseToSlack struct {
 	Body []byte
 }
@@ -32,6 +39,21 @@ func LookupHandler(ctx context.Context, slackCommand models.SlackCustomCommand)
 	} else if subCommand == CommandNameStatus {
 		cmd := NewStatus(ctx)
 		return cmd, nil
+	} else if subCommand == CommandNamePomodoro {
+		cmd := NewPomodoro(ctx)
+		return cmd, nil
+	} else if subCommand == CommandNameReport {
+		cmd := NewReport(ctx)
+		return cmd, nil
+	} else if subCommand == CommandNameOff {
+		cmd := NewOff(ctx)
+		return cmd, nil
+	} else if subCommand == CommandNameIdle {
+		cmd := NewIdle(ctx)
+		return cmd, nil
+	} else if subCommand == CommandNameFind {
+		cmd := NewFind(ctx)
+		return cmd, nil
 	}
 	return nil, fmt.Errorf("Unknown command `%s`!", subCommand)
 }
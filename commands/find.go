@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/themes"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+)
+
+// findResultsLimit - a Slack reply lists the most relevant tasks only, the application shows more
+const findResultsLimit = 10
+
+//Find - handles the '/timer find` command received from Slack
+type Find struct {
+	session      *mgo.Session
+	teamService  *data.TeamService
+	timerService *data.TimerService
+	userService  *data.UserService
+	passService  *data.PassService
+	report       *models.FindCommandReport
+	ctx          context.Context
+	theme        themes.SlackMessageTheme
+}
+
+func NewFind(ctx context.Context) *Find {
+	session := utils.GetMongoSessionFromContext(ctx)
+
+	find := &Find{
+		session:      session,
+		teamService:  data.NewTeamService(session),
+		timerService: data.NewTimerService(session),
+		userService:  data.NewUserService(session),
+		passService:  data.NewPassService(session),
+		report:       &models.FindCommandReport{},
+		ctx:          ctx,
+		theme:        utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
+	}
+
+	return find
+}
+
+// Handle - SlackCustomCommandHandler interface
+// Searches user's tasks the same way the application does, like `login bug` or `"release notes"`
+func (c *Find) Handle(ctx context.Context, slackCommand models.SlackCustomCommand) *ResponseToSlack {
+	if slackCommand.Text == "" {
+		return c.errorResponse(
+			fmt.Sprintf("Search text not provided! The correct command would look like: \n>`%s find login bug`", slackCommand.Command),
+		)
+	}
+
+	team, project, err := c.teamService.EnsureTeamSetUp(&slackCommand)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	teamUser, err := c.userService.EnsureUser(team, slackCommand.UserID)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	if err := data.Authorize(teamUser, data.PermissionViewOwnData); err != nil {
+		return c.errorResponse(forbiddenMessage)
+	}
+
+	pass, err := c.passService.EnsurePass(team, teamUser, project)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	c.report.Team = team
+	c.report.Project = project
+	c.report.TeamUser = teamUser
+	c.report.Pass = pass
+	c.report.Text = slackCommand.Text
+
+	c.report.Results, err = c.timerService.SearchTasks(teamUser, &data.SearchFilter{
+		Text:  slackCommand.Text,
+		Limit: findResultsLimit,
+	})
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf("Failed to search: %s", err))
+	}
+
+	return c.response()
+}
+
+func (c *Find) response() *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatFindCommand(c.report)),
+	}
+}
+
+func (c *Find) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/themes"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+)
+
+//Idle - handles the '/timer idle` command received from Slack
+type Idle struct {
+	session     *mgo.Session
+	teamService *data.TeamService
+	userService *data.UserService
+	passService *data.PassService
+	idleService *data.IdleService
+	report      *models.IdleCommandReport
+	ctx         context.Context
+	theme       themes.SlackMessageTheme
+}
+
+func NewIdle(ctx context.Context) *Idle {
+	session := utils.GetMongoSessionFromContext(ctx)
+
+	idle := &Idle{
+		session:     session,
+		teamService: data.NewTeamService(session),
+		userService: data.NewUserService(session),
+		passService: data.NewPassService(session),
+		idleService: data.NewIdleService(session),
+		report:      &models.IdleCommandReport{},
+		ctx:         ctx,
+		theme:       utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
+	}
+
+	return idle
+}
+
+// Handle - SlackCustomCommandHandler interface
+// Lists the pending idle intervals or answers them like `discard` for all of them or `split 2` for the second one
+func (c *Idle) Handle(ctx context.Context, slackCommand models.SlackCustomCommand) *ResponseToSlack {
+	action, number, err := parseIdleCommand(slackCommand.Text)
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf(
+			"%s! The correct command would look like: \n>`%s idle discard` or `%s idle split 2`",
+			err, slackCommand.Command, slackCommand.Command))
+	}
+
+	team, project, err := c.teamService.EnsureTeamSetUp(&slackCommand)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	teamUser, err := c.userService.EnsureUser(team, slackCommand.UserID)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	if err := data.Authorize(teamUser, data.PermissionTrackTime); err != nil {
+		return c.errorResponse(forbiddenMessage)
+	}
+
+	pass, err := c.passService.EnsurePass(team, teamUser, project)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	c.report.Team = team
+	c.report.Project = project
+	c.report.TeamUser = teamUser
+	c.report.Pass = pass
+	c.report.Action = action
+
+	pending, err := c.idleService.Pending(teamUser)
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf("Failed to load the idle time: %s", err))
+	}
+
+	if action != "" {
+		targets := pending
+		if number > 0 {
+			if number > len(pending) {
+				return c.errorResponse(fmt.Sprintf("There is no idle interval #%d", number))
+			}
+			targets = pending[number-1 : number]
+		}
+
+		now := time.Now()
+		for _, interval := range targets {
+			resolved, err := c.idleService.Resolve(teamUser, interval.ID.Hex(), action, now)
+			if err != nil {
+				return c.errorResponse(fmt.Sprintf("Failed to %s the idle time: %s", action, err))
+			}
+			c.report.Resolved = append(c.report.Resolved, resolved)
+		}
+	}
+
+	c.report.IdlePrompt = idlePrompt(c.idleService, teamUser, slackCommand)
+	return c.response()
+}
+
+// parseIdleCommand reads `[keep|discard|split [number]]`, zero number means all the pending intervals
+func parseIdleCommand(text string) (string, int, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", 0, nil
+	}
+
+	action := fields[0]
+	if action != data.IdleActionKeep && action != data.IdleActionDiscard && action != data.IdleActionSplit {
+		return "", 0, fmt.Errorf("Unknown answer `%s`", action)
+	}
+
+	if len(fields) == 1 {
+		return action, 0, nil
+	}
+
+	number, err := strconv.Atoi(fields[1])
+	if len(fields) > 2 || err != nil || number < 1 {
+		return "", 0, fmt.Errorf("`%s` is not a number of an idle interval", strings.Join(fields[1:], " "))
+	}
+	return action, number, nil
+}
+
+// idlePrompt returns the idle intervals to ask the user about in reply to a command, nil if there are none
+func idlePrompt(idleService *data.IdleService, teamUser *models.TeamUser, slackCommand models.SlackCustomCommand) *models.IdlePrompt {
+	intervals, err := idleService.Pending(teamUser)
+	if err != nil || len(intervals) == 0 {
+		return nil
+	}
+
+	return &models.IdlePrompt{
+		Command:   slackCommand.Command,
+		Intervals: intervals,
+	}
+}
+
+func (c *Idle) response() *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatIdleCommand(c.report)),
+	}
+}
+
+func (c *Idle) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
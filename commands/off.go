@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+
+	"fmt"
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/themes"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+)
+
+//Off - handles the '/timer off` command received from Slack
+type Off struct {
+	session        *mgo.Session
+	teamService    *data.TeamService
+	userService    *data.UserService
+	passService    *data.PassService
+	timeOffService *data.TimeOffService
+	report         *models.OffCommandReport
+	ctx            context.Context
+	theme          themes.SlackMessageTheme
+}
+
+func NewOff(ctx context.Context) *Off {
+	session := utils.GetMongoSessionFromContext(ctx)
+
+	off := &Off{
+		session:        session,
+		teamService:    data.NewTeamService(session),
+		userService:    data.NewUserService(session),
+		passService:    data.NewPassService(session),
+		timeOffService: data.NewTimeOffService(session),
+		report:         &models.OffCommandReport{},
+		ctx:            ctx,
+		theme:          utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
+	}
+
+	return off
+}
+
+// Handle - SlackCustomCommandHandler interface
+// Books time off like `2026-10-20..2026-10-24 vacation` or `2026-10-20 sick half`
+func (c *Off) Handle(ctx context.Context, slackCommand models.SlackCustomCommand) *ResponseToSlack {
+	request, err := data.ParseTimeOffRequest(slackCommand.Text)
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf(
+			"%s! The correct command would look like: \n>`%s off 2026-10-20..2026-10-24 vacation` or `%s off 2026-10-20 sick half`",
+			err, slackCommand.Command, slackCommand.Command))
+	}
+
+	team, project, err := c.teamService.EnsureTeamSetUp(&slackCommand)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	teamUser, err := c.userService.EnsureUser(team, slackCommand.UserID)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	if err := data.Authorize(teamUser, data.PermissionTrackTime); err != nil {
+		return c.errorResponse(forbiddenMessage)
+	}
+
+	pass, err := c.passService.EnsurePass(team, teamUser, project)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	c.report.Team = team
+	c.report.Project = project
+	c.report.TeamUser = teamUser
+	c.report.Pass = pass
+
+	c.report.TimeOff, err = c.timeOffService.CreateTimeOff(teamUser, teamUser, request)
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf("Failed to book the time off: %s", err))
+	}
+
+	return c.response()
+}
+
+func (c *Off) response() *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatOffCommand(c.report)),
+	}
+}
+
+func (c *Off) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+
+	"fmt"
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/themes"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"time"
+)
+
+//Pomodoro - handles the '/timer pomodoro` command received from Slack
+type Pomodoro struct {
+	start           *Start
+	pomodoroService *data.PomodoroService
+	report          *models.PomodoroCommandReport
+	ctx             context.Context
+	theme           themes.SlackMessageTheme
+}
+
+func NewPomodoro(ctx context.Context) *Pomodoro {
+	session := utils.GetMongoSessionFromContext(ctx)
+	start := NewStart(ctx)
+
+	pomodoro := &Pomodoro{
+		start:           start,
+		pomodoroService: data.NewPomodoroService(session),
+		report:          &models.PomodoroCommandReport{StartCommandReport: start.report},
+		ctx:             ctx,
+		theme:           utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
+	}
+
+	return pomodoro
+}
+
+// Handle - SlackCustomCommandHandler interface
+// The timer is started the same way `start` does it, then a pomodoro begins for it
+func (c *Pomodoro) Handle(ctx context.Context, slackCommand models.SlackCustomCommand) *ResponseToSlack {
+
+	if slackCommand.Text == "" {
+		return c.errorResponse(
+			fmt.Sprintf("Task name not provided! The correct command would look like: \n>`%s pomodoro My super exciting task`", slackCommand.Command),
+		)
+	}
+
+	if errorMessage := c.start.startTimer(slackCommand); errorMessage != "" {
+		return c.errorResponse(errorMessage)
+	}
+
+	timer := c.report.StartedTimer
+	if timer == nil {
+		timer = c.report.AlreadyStartedTimer
+	}
+	if timer == nil {
+		return c.errorResponse("Failed to start the timer, please try again")
+	}
+
+	now := time.Now()
+	completed, err := c.pomodoroService.CompletedToday(c.report.TeamUser, now)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+	c.report.Completed = completed
+
+	c.report.Pomodoro, err = c.pomodoroService.Start(c.report.TeamUser, timer, now)
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf("Failed to start the pomodoro: %s", err))
+	}
+
+	return c.response()
+}
+
+func (c *Pomodoro) response() *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatPomodoroCommand(c.report)),
+	}
+}
+
+func (c *Pomodoro) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+
+	"fmt"
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/themes"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"time"
+)
+
+//Report - handles the '/timer report` command received from Slack
+type Report struct {
+	session         *mgo.Session
+	teamService     *data.TeamService
+	userService     *data.UserService
+	passService     *data.PassService
+	capacityService *data.CapacityService
+	report          *models.ReportCommandReport
+	ctx             context.Context
+	theme           themes.SlackMessageTheme
+}
+
+func NewReport(ctx context.Context) *Report {
+	session := utils.GetMongoSessionFromContext(ctx)
+
+	report := &Report{
+		session:         session,
+		teamService:     data.NewTeamService(session),
+		userService:     data.NewUserService(session),
+		passService:     data.NewPassService(session),
+		capacityService: data.NewCapacityService(session),
+		report:          &models.ReportCommandReport{},
+		ctx:             ctx,
+		theme:           utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
+	}
+
+	return report
+}
+
+// Handle - SlackCustomCommandHandler interface
+// Shows expected vs tracked time for `week` (the default), `last week` or `month`
+func (c *Report) Handle(ctx context.Context, slackCommand models.SlackCustomCommand) *ResponseToSlack {
+	team, project, err := c.teamService.EnsureTeamSetUp(&slackCommand)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	teamUser, err := c.userService.EnsureUser(team, slackCommand.UserID)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	if err := data.Authorize(teamUser, data.PermissionViewOwnData); err != nil {
+		return c.errorResponse(forbiddenMessage)
+	}
+
+	pass, err := c.passService.EnsurePass(team, teamUser, project)
+	if err != nil {
+		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
+	}
+
+	c.report.Team = team
+	c.report.Project = project
+	c.report.TeamUser = teamUser
+	c.report.Pass = pass
+
+	c.report.PeriodName = slackCommand.Text
+	if c.report.PeriodName == "" {
+		c.report.PeriodName = data.CapacityPeriodWeek
+	}
+
+	c.report.Capacity, err = c.capacityService.PeriodReport(teamUser, team, c.report.PeriodName, time.Now())
+	if err != nil {
+		return c.errorResponse(fmt.Sprintf(
+			"%s! The correct command would look like: \n>`%s report week`, `%s report last week` or `%s report month`",
+			err, slackCommand.Command, slackCommand.Command, slackCommand.Command))
+	}
+
+	return c.response()
+}
+
+func (c *Report) response() *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatReportCommand(c.report)),
+	}
+}
+
+func (c *Report) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
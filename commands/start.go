@@ -19,6 +19,7 @@ type Start struct {
 	timerService *data.TimerService
 	userService  *data.UserService
 	passService  *data.PassService
+	idleService  *data.IdleService
 	report       *models.StartCommandReport
 	ctx          context.Context
 	theme        themes.SlackMessageTheme
@@ -33,6 +34,7 @@ func NewStart(ctx context.Context) *Start {
 		timerService: data.NewTimerService(session),
 		userService:  data.NewUserService(session),
 		passService:  data.NewPassService(session),
+		idleService:  data.NewIdleService(session),
 		report:       &models.StartCommandReport{},
 		ctx:          ctx,
 		theme:        utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
@@ -58,6 +60,15 @@ func (c *Start) Handle(ctx context.Context, slackCommand models.SlackCustomComma
 		)
 	}
 
+	if errorMessage := c.startTimer(slackCommand); errorMessage != "" {
+		return c.errorResponse(errorMessage)
+	}
+
+	return c.response()
+}
+
+// startTimer starts the timer on the task filling the report in, returns a message for the user if it is not allowed
+func (c *Start) startTimer(slackCommand models.SlackCustomCommand) string {
 	team, project, err := c.teamService.EnsureTeamSetUp(&slackCommand)
 	if err != nil {
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
@@ -68,6 +79,10 @@ func (c *Start) Handle(ctx context.Context, slackCommand models.SlackCustomComma
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
 	}
 
+	if err := data.Authorize(teamUser, data.PermissionTrackTime); err != nil {
+		return forbiddenMessage
+	}
+
 	pass, err := c.passService.EnsurePass(team, teamUser, project)
 	if err != nil {
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
@@ -86,11 +101,11 @@ func (c *Start) Handle(ctx context.Context, slackCommand models.SlackCustomComma
 	if timerToStop != nil {
 		if timerToStop.TaskName == slackCommand.Text && timerToStop.ProjectID == project.ID.Hex() {
 			c.report.AlreadyStartedTimer = timerToStop
-			c.report.AlreadyStartedTimerTotalForToday = c.timerService.TotalMinutesForTaskToday(timerToStop)
+			c.report.AlreadyStartedTimerTotalForToday = c.timerService.TotalSecondsForTaskToday(timerToStop)
 		} else {
 			c.timerService.StopTimer(timerToStop)
 			c.report.StoppedTimer = timerToStop
-			c.report.StoppedTaskTotalForToday = c.timerService.TotalMinutesForTaskToday(timerToStop)
+			c.report.StoppedTaskTotalForToday = c.timerService.TotalSecondsForTaskToday(timerToStop)
 		}
 	}
 	if c.report.AlreadyStartedTimer == nil {
@@ -99,13 +114,14 @@ func (c *Start) Handle(ctx context.Context, slackCommand models.SlackCustomComma
 			// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
 		}
 		c.report.StartedTimer = startedTimer
-		c.report.StartedTaskTotalForToday = c.timerService.TotalMinutesForTaskToday(c.report.StartedTimer)
+		c.report.StartedTaskTotalForToday = c.timerService.TotalSecondsForTaskToday(c.report.StartedTimer)
 	}
 
 	day := time.Now().Add(time.Duration(teamUser.SlackUserInfo.TZOffset) * time.Second)
-	c.report.UserTotalForToday = c.timerService.TotalCompletedMinutesForDay(day.Year(), day.Month(), day.Day(), teamUser)
+	c.report.UserTotalForToday = c.timerService.TotalCompletedSecondsForDay(day.Year(), day.Month(), day.Day(), teamUser)
+	c.report.IdlePrompt = idlePrompt(c.idleService, teamUser, slackCommand)
 
-	return c.response()
+	return ""
 }
 
 func (c *Start) response() *ResponseToSlack {
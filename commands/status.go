@@ -18,6 +18,7 @@ type Status struct {
 	timerService *data.TimerService
 	userService  *data.UserService
 	passService  *data.PassService
+	idleService  *data.IdleService
 	report       *models.StatusCommandReport
 	ctx          context.Context
 	theme        themes.SlackMessageTheme
@@ -32,6 +33,7 @@ func NewStatus(ctx context.Context) *Status {
 		timerService: data.NewTimerService(session),
 		userService:  data.NewUserService(session),
 		passService:  data.NewPassService(session),
+		idleService:  data.NewIdleService(session),
 		report:       &models.StatusCommandReport{},
 		ctx:          ctx,
 		theme:        utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
@@ -53,6 +55,10 @@ func (c *Status) Handle(ctx context.Context, slackCommand models.SlackCustomComm
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
 	}
 
+	if err := data.Authorize(teamUser, data.PermissionViewOwnData); err != nil {
+		return c.errorResponse(forbiddenMessage)
+	}
+
 	pass, err := c.passService.EnsurePass(team, teamUser, project)
 	if err != nil {
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
@@ -71,23 +77,26 @@ func (c *Status) Handle(ctx context.Context, slackCommand models.SlackCustomComm
 		c.report.PeriodName = "yesterday"
 	}
 
+	c.report.Day = day
+
 	tasks, err := c.timerService.GetCompletedTasksForDay(day.Year(), day.Month(), day.Day(), teamUser)
 	if err != nil {
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
 	}
 	c.report.Tasks = tasks
-	c.report.UserTotalForPeriod = c.timerService.TotalCompletedMinutesForDay(day.Year(), day.Month(), day.Day(), teamUser)
+	c.report.UserTotalForPeriod = c.timerService.TotalCompletedSecondsForDay(day.Year(), day.Month(), day.Day(), teamUser)
 
 	if c.report.PeriodName == "today" {
 		alreadyStartedTimer, _ := c.timerService.GetActiveTimer(team.ID.Hex(), teamUser.ID.Hex())
 
 		if alreadyStartedTimer != nil {
-			alreadyStartedTimer.Minutes = c.timerService.CalculateMinutesForActiveTimer(alreadyStartedTimer)
+			alreadyStartedTimer.Seconds = c.timerService.CalculateSecondsForActiveTimer(alreadyStartedTimer)
 			c.report.AlreadyStartedTimer = alreadyStartedTimer
-			c.report.AlreadyStartedTimerTotalForToday = c.timerService.TotalMinutesForTaskToday(alreadyStartedTimer)
-			c.report.UserTotalForPeriod += alreadyStartedTimer.Minutes
+			c.report.AlreadyStartedTimerTotalForToday = c.timerService.TotalSecondsForTaskToday(alreadyStartedTimer)
+			c.report.UserTotalForPeriod += alreadyStartedTimer.Seconds
 		}
 	}
+	c.report.IdlePrompt = idlePrompt(c.idleService, teamUser, slackCommand)
 
 	return c.response()
 }
@@ -97,3 +106,9 @@ func (c *Status) response() *ResponseToSlack {
 		Body: []byte(c.theme.FormatStatusCommand(c.report)),
 	}
 }
+
+func (c *Status) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
@@ -18,6 +18,7 @@ type Stop struct {
 	timerService *data.TimerService
 	userService  *data.UserService
 	passService  *data.PassService
+	idleService  *data.IdleService
 	report       *models.StopCommandReport
 	ctx          context.Context
 	theme        themes.SlackMessageTheme
@@ -32,6 +33,7 @@ func NewStop(ctx context.Context) *Stop {
 		timerService: data.NewTimerService(session),
 		userService:  data.NewUserService(session),
 		passService:  data.NewPassService(session),
+		idleService:  data.NewIdleService(session),
 		report:       &models.StopCommandReport{},
 		ctx:          ctx,
 		theme:        utils.GetThemeFromContext(ctx).(themes.SlackMessageTheme),
@@ -58,6 +60,10 @@ func (c *Stop) Handle(ctx context.Context, slackCommand models.SlackCustomComman
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
 	}
 
+	if err := data.Authorize(teamUser, data.PermissionTrackTime); err != nil {
+		return c.errorResponse(forbiddenMessage)
+	}
+
 	pass, err := c.passService.EnsurePass(team, teamUser, project)
 	if err != nil {
 		// todo: format a decent Slack error message so user knows what's wrong and how to solve the issue
@@ -76,11 +82,12 @@ func (c *Stop) Handle(ctx context.Context, slackCommand models.SlackCustomComman
 	if timerToStop != nil {
 		c.timerService.StopTimer(timerToStop)
 		c.report.StoppedTimer = timerToStop
-		c.report.StoppedTaskTotalForToday = c.timerService.TotalMinutesForTaskToday(timerToStop)
+		c.report.StoppedTaskTotalForToday = c.timerService.TotalSecondsForTaskToday(timerToStop)
 	}
 
 	day := time.Now().Add(time.Duration(teamUser.SlackUserInfo.TZOffset) * time.Second)
-	c.report.UserTotalForToday = c.timerService.TotalCompletedMinutesForDay(day.Year(), day.Month(), day.Day(), teamUser)
+	c.report.UserTotalForToday = c.timerService.TotalCompletedSecondsForDay(day.Year(), day.Month(), day.Day(), teamUser)
+	c.report.IdlePrompt = idlePrompt(c.idleService, teamUser, slackCommand)
 
 	return c.response()
 }
@@ -90,3 +97,9 @@ func (c *Stop) response() *ResponseToSlack {
 		Body: []byte(c.theme.FormatStopCommand(c.report)),
 	}
 }
+
+func (c *Stop) errorResponse(errorMessage string) *ResponseToSlack {
+	return &ResponseToSlack{
+		Body: []byte(c.theme.FormatError(errorMessage)),
+	}
+}
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type AccessTokenRepository struct {
+	session    *mgo.Session
+	collection *mgo.Collection
+}
+
+func NewAccessTokenRepository(session *mgo.Session) *AccessTokenRepository {
+	return &AccessTokenRepository{
+		session:    session,
+		collection: session.DB("").C(utils.MongoCollectionTokens),
+	}
+}
+
+func (r *AccessTokenRepository) findByID(id string) (*models.AccessToken, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, nil
+	}
+
+	result := &models.AccessToken{}
+	err := r.collection.FindId(bson.ObjectIdHex(id)).One(result)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+// findActiveByHash returns the token with the hash unless it is revoked, nil if there is none
+func (r *AccessTokenRepository) findActiveByHash(hash string) (*models.AccessToken, error) {
+	result := &models.AccessToken{}
+	err := r.collection.Find(bson.M{"token_hash": hash, "revoked_at": nil}).One(result)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+func (r *AccessTokenRepository) findByUser(userID string) ([]*models.AccessToken, error) {
+	result := []*models.AccessToken{}
+	err := r.collection.Find(bson.M{"team_user_id": userID}).Sort("-created_at").All(&result)
+	return result, err
+}
+
+func (r *AccessTokenRepository) create(token *models.AccessToken) error {
+	token.ID = bson.NewObjectId()
+	return r.collection.Insert(token)
+}
+
+func (r *AccessTokenRepository) revoke(token *models.AccessToken, now time.Time) error {
+	token.RevokedAt = &now
+	return r.collection.UpdateId(token.ID, bson.M{"$set": bson.M{"revoked_at": now}})
+}
+
+// revokeAllByUser revokes every token of the user which is not revoked yet
+func (r *AccessTokenRepository) revokeAllByUser(userID string, now time.Time) error {
+	_, err := r.collection.UpdateAll(
+		bson.M{"team_user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}})
+	return err
+}
+
+func (r *AccessTokenRepository) touch(token *models.AccessToken, now time.Time) error {
+	token.LastUsedAt = &now
+	return r.collection.UpdateId(token.ID, bson.M{"$set": bson.M{"last_used_at": now}})
+}
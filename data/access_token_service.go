@@ -0,0 +1,151 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/satori/go.uuid"
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	// AccessTokenPrefix starts every personal access token, which tells them from JWTs
+	AccessTokenPrefix = "tuna_"
+	// accessTokenDisplayLength - how much of the beginning of a token is kept to tell the tokens apart
+	accessTokenDisplayLength = len(AccessTokenPrefix) + 6
+	// accessTokenTouchInterval - the last use of a token is recorded at most that often
+	accessTokenTouchInterval = time.Minute
+)
+
+// ErrInvalidAccessToken is returned for a token which is unknown or revoked
+var ErrInvalidAccessToken = errors.New("invalid access token")
+
+// accessTokenScopePermissions - the permissions a scope lets a token use. The user's role still has to grant them,
+// the permissions of no scope (like managing the team) are never available to tokens
+var accessTokenScopePermissions = map[string][]string{
+	models.AccessTokenScopeReadTimers:  {PermissionViewOwnData},
+	models.AccessTokenScopeWriteTimers: {PermissionTrackTime, PermissionEditTeamTimers},
+	models.AccessTokenScopeReports:     {PermissionViewTeamReports},
+}
+
+// AccessTokenService - personal access tokens scripts, CLIs and IDE plugins use instead of a session
+type AccessTokenService struct {
+	repository     *AccessTokenRepository
+	userRepository *UserRepository
+}
+
+// NewAccessTokenService constructs an instance of the service
+func NewAccessTokenService(session *mgo.Session) *AccessTokenService {
+	return &AccessTokenService{
+		repository:     NewAccessTokenRepository(session),
+		userRepository: NewUserRepository(session),
+	}
+}
+
+// Tokens lists the user's tokens including the revoked ones, the newest first
+func (s *AccessTokenService) Tokens(user *models.TeamUser) ([]*models.AccessToken, error) {
+	return s.repository.findByUser(user.ID.Hex())
+}
+
+// CreateToken creates a named token with the scopes and returns it along with the token itself,
+// which cannot be looked up later as only its hash is stored
+func (s *AccessTokenService) CreateToken(user *models.TeamUser, name string, scopes []string) (*models.AccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("token name is required")
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if _, ok := accessTokenScopePermissions[scope]; !ok {
+			return nil, "", fmt.Errorf("unknown scope `%s`", scope)
+		}
+	}
+
+	secret := AccessTokenPrefix + strings.Replace(uuid.NewV4().String(), "-", "", -1)
+	token := &models.AccessToken{
+		TeamID:       user.TeamID,
+		TeamUserID:   user.ID.Hex(),
+		Name:         name,
+		TokenHash:    hashAccessToken(secret),
+		Prefix:       secret[:accessTokenDisplayLength],
+		Scopes:       scopes,
+		CreatedAt:    time.Now(),
+		ModelVersion: models.ModelVersionToken,
+	}
+
+	if err := s.repository.create(token); err != nil {
+		return nil, "", err
+	}
+	return token, secret, nil
+}
+
+// RevokeToken stops the user's token from being accepted
+func (s *AccessTokenService) RevokeToken(user *models.TeamUser, id string) (*models.AccessToken, error) {
+	token, err := s.repository.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.TeamUserID != user.ID.Hex() {
+		return nil, errors.New("access token not found")
+	}
+
+	if token.RevokedAt == nil {
+		err = s.repository.revoke(token, time.Now())
+	}
+	return token, err
+}
+
+// Authenticate returns the token and its user for the token presented with a request and records its use.
+// The token is put on the user, so every authorization of the request is limited by its scopes
+func (s *AccessTokenService) Authenticate(secret string, now time.Time) (*models.AccessToken, *models.TeamUser, error) {
+	if !strings.HasPrefix(secret, AccessTokenPrefix) {
+		return nil, nil, ErrInvalidAccessToken
+	}
+
+	token, err := s.repository.findActiveByHash(hashAccessToken(secret))
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil {
+		return nil, nil, ErrInvalidAccessToken
+	}
+
+	user, err := s.userRepository.FindByID(token.TeamUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.repository.touch(token, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	user.AccessToken = token
+	return token, user, nil
+}
+
+// AccessTokenAllows tells whether a scope of the token covers the permission
+func AccessTokenAllows(token *models.AccessToken, permission string) bool {
+	for _, scope := range token.Scopes {
+		for _, p := range accessTokenScopePermissions[scope] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hashAccessToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
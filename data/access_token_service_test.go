@@ -0,0 +1,124 @@
+package data
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestAccessTokenService(t *testing.T) {
+	gosuite.Run(t, &AccessTokenServiceTestSuite{Is: is.New(t)})
+}
+
+func TestAccessTokenAllows(t *testing.T) {
+	s := is.New(t)
+
+	token := &models.AccessToken{Scopes: []string{models.AccessTokenScopeReadTimers, models.AccessTokenScopeReports}}
+	s.True(AccessTokenAllows(token, PermissionViewOwnData))
+	s.True(AccessTokenAllows(token, PermissionViewTeamReports))
+	s.False(AccessTokenAllows(token, PermissionTrackTime))
+	s.False(AccessTokenAllows(token, PermissionManageTeam))
+}
+
+func (s *AccessTokenServiceTestSuite) TestCreateAndAuthenticate(t *testing.T) {
+	token, secret, err := s.service.CreateToken(s.user, " vim plugin ", []string{models.AccessTokenScopeWriteTimers})
+	s.Nil(err)
+	s.Equal(token.Name, "vim plugin")
+	s.Equal(token.Prefix, secret[:len(token.Prefix)])
+	s.NotEqual(token.TokenHash, secret)
+	s.Nil(token.LastUsedAt)
+
+	now := time.Now().Truncate(time.Second)
+	authenticated, user, err := s.service.Authenticate(secret, now)
+	s.Nil(err)
+	s.Equal(authenticated.ID, token.ID)
+	s.Equal(user.ID, s.user.ID)
+
+	tokens, _ := s.service.Tokens(s.user)
+	s.Len(tokens, 1)
+	s.Equal(tokens[0].LastUsedAt.Unix(), now.Unix())
+
+	// the last use is not recorded on every request
+	s.service.Authenticate(secret, now.Add(time.Second))
+	tokens, _ = s.service.Tokens(s.user)
+	s.Equal(tokens[0].LastUsedAt.Unix(), now.Unix())
+
+	_, _, err = s.service.Authenticate(secret+"x", now)
+	s.Equal(err, ErrInvalidAccessToken)
+	_, _, err = s.service.Authenticate("not a token", now)
+	s.Equal(err, ErrInvalidAccessToken)
+}
+
+func (s *AccessTokenServiceTestSuite) TestCreateWithWrongData(t *testing.T) {
+	_, _, err := s.service.CreateToken(s.user, "", []string{models.AccessTokenScopeReadTimers})
+	s.Err(err)
+
+	_, _, err = s.service.CreateToken(s.user, "cli", []string{})
+	s.Err(err)
+
+	_, _, err = s.service.CreateToken(s.user, "cli", []string{"admin"})
+	s.Err(err)
+	s.Equal(err.Error(), "unknown scope `admin`")
+}
+
+func (s *AccessTokenServiceTestSuite) TestRevoke(t *testing.T) {
+	token, secret, _ := s.service.CreateToken(s.user, "cli", []string{models.AccessTokenScopeReadTimers})
+
+	other, _ := NewUserRepository(s.session).Save(&models.TeamUser{TeamID: "team", ExternalUserID: "other", SlackUserInfo: &slack.User{}})
+	_, err := s.service.RevokeToken(other, token.ID.Hex())
+	s.Err(err)
+	s.Equal(err.Error(), "access token not found")
+
+	revoked, err := s.service.RevokeToken(s.user, token.ID.Hex())
+	s.Nil(err)
+	s.NotNil(revoked.RevokedAt)
+
+	_, _, err = s.service.Authenticate(secret, time.Now())
+	s.Equal(err, ErrInvalidAccessToken)
+}
+
+type AccessTokenServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *AccessTokenService
+	user    *models.TeamUser
+}
+
+func (s *AccessTokenServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+}
+
+func (s *AccessTokenServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *AccessTokenServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.service = NewAccessTokenService(s.session)
+	s.user, _ = NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         "team",
+		ExternalUserID: "user-id",
+		SlackUserInfo:  &slack.User{},
+	})
+}
+
+func (s *AccessTokenServiceTestSuite) TearDown() {}
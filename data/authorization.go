@@ -0,0 +1,141 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+// Permissions the roles are granted with. Every frontend handler and Slack command is
+// guarded by one of them, project scoped permissions are additionally checked by services
+const (
+	// PermissionViewOwnData - see own timers, statistics and timesheets
+	PermissionViewOwnData = "view_own_data"
+	// PermissionTrackTime - start, stop, edit and delete own timers, submit timesheets
+	PermissionTrackTime = "track_time"
+	// PermissionEditTeamTimers - edit and delete timers of other team members (project scoped)
+	PermissionEditTeamTimers = "edit_team_timers"
+	// PermissionManageBudgets - set project budgets (project scoped)
+	PermissionManageBudgets = "manage_budgets"
+	// PermissionReviewTimesheets - approve or reject submitted timesheets, see the capacity and book time off
+	// of any team member. It is not project scoped, so managers do not have it
+	PermissionReviewTimesheets = "review_timesheets"
+	// PermissionViewTeamReports - see the time tracked by other team members (project scoped)
+	PermissionViewTeamReports = "view_team_reports"
+	// PermissionAssignRoles - change roles of team members
+	PermissionAssignRoles = "assign_roles"
+	// PermissionImportData - import time entries exported from other time trackers
+	PermissionImportData = "import_data"
+	// PermissionManageWebhooks - subscribe other systems to the team's timer events
+	PermissionManageWebhooks = "manage_webhooks"
+	// PermissionManageTeam - change team-wide settings like issue patterns
+	PermissionManageTeam = "manage_team"
+)
+
+// ErrForbidden is returned when a user's role does not grant the requested permission
+var ErrForbidden = errors.New("forbidden")
+
+var rolePermissions = map[string][]string{
+	models.RoleOwner: {
+		PermissionViewOwnData, PermissionTrackTime, PermissionEditTeamTimers, PermissionManageBudgets,
+		PermissionReviewTimesheets, PermissionViewTeamReports, PermissionAssignRoles, PermissionImportData,
+		PermissionManageWebhooks, PermissionManageTeam,
+	},
+	models.RoleAdmin: {
+		PermissionViewOwnData, PermissionTrackTime, PermissionEditTeamTimers, PermissionManageBudgets,
+		PermissionReviewTimesheets, PermissionViewTeamReports, PermissionImportData, PermissionManageWebhooks,
+		PermissionManageTeam,
+	},
+	models.RoleManager: {
+		PermissionViewOwnData, PermissionTrackTime, PermissionEditTeamTimers, PermissionManageBudgets,
+		PermissionViewTeamReports,
+	},
+	models.RoleMember: {
+		PermissionViewOwnData, PermissionTrackTime,
+	},
+	models.RoleViewer: {
+		PermissionViewOwnData, PermissionViewTeamReports,
+	},
+}
+
+// roles whose project scoped permissions are limited to TeamUser.ManagedProjectIDs
+var projectScopedRoles = map[string]bool{
+	models.RoleManager: true,
+	models.RoleViewer:  true,
+}
+
+// IsValidRole tells whether the value is one of the known roles
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleOf returns the user's role. Users that have never been assigned a role get one
+// based on their Slack owner/admin flags
+func RoleOf(user *models.TeamUser) string {
+	if user.Role != "" {
+		return user.Role
+	}
+
+	if user.SlackUserInfo != nil && user.SlackUserInfo.IsOwner {
+		return models.RoleOwner
+	}
+
+	if user.SlackUserInfo != nil && user.SlackUserInfo.IsAdmin {
+		return models.RoleAdmin
+	}
+
+	return models.RoleMember
+}
+
+// Can tells whether the user's role grants the permission regardless of a project.
+// For a request made with an access token a scope of the token has to cover the permission too
+func Can(user *models.TeamUser, permission string) bool {
+	if user.AccessToken != nil && !AccessTokenAllows(user.AccessToken, permission) {
+		return false
+	}
+
+	for _, p := range rolePermissions[RoleOf(user)] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns ErrForbidden unless the user's role grants the permission
+func Authorize(user *models.TeamUser, permission string) error {
+	if !Can(user, permission) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeForProject is like Authorize but also makes sure that managers and viewers
+// have the project in their scope
+func AuthorizeForProject(user *models.TeamUser, permission, projectID string) error {
+	if err := Authorize(user, permission); err != nil {
+		return err
+	}
+
+	allProjects, projectIDs := ProjectScope(user)
+	if allProjects {
+		return nil
+	}
+
+	for _, id := range projectIDs {
+		if id == projectID {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// ProjectScope returns either true if the user's project scoped permissions apply to any
+// project of the team, or the list of projects they are limited to
+func ProjectScope(user *models.TeamUser) (bool, []string) {
+	if projectScopedRoles[RoleOf(user)] {
+		return false, user.ManagedProjectIDs
+	}
+	return true, nil
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/nlopes/slack"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestRoleOf(t *testing.T) {
+	s := is.New(t)
+
+	s.Equal(RoleOf(&models.TeamUser{SlackUserInfo: &slack.User{}}), models.RoleMember)
+	s.Equal(RoleOf(&models.TeamUser{SlackUserInfo: &slack.User{IsAdmin: true}}), models.RoleAdmin)
+	s.Equal(RoleOf(&models.TeamUser{SlackUserInfo: &slack.User{IsOwner: true, IsAdmin: true}}), models.RoleOwner)
+	s.Equal(RoleOf(&models.TeamUser{SlackUserInfo: &slack.User{IsOwner: true}, Role: models.RoleViewer}), models.RoleViewer)
+}
+
+func TestAuthorize(t *testing.T) {
+	s := is.New(t)
+
+	member := &models.TeamUser{Role: models.RoleMember}
+	s.Nil(Authorize(member, PermissionTrackTime))
+	s.Equal(Authorize(member, PermissionViewTeamReports), ErrForbidden)
+
+	viewer := &models.TeamUser{Role: models.RoleViewer}
+	s.Nil(Authorize(viewer, PermissionViewOwnData))
+	s.Equal(Authorize(viewer, PermissionTrackTime), ErrForbidden)
+
+	admin := &models.TeamUser{Role: models.RoleAdmin}
+	s.Nil(Authorize(admin, PermissionManageBudgets))
+	s.Nil(Authorize(admin, PermissionImportData))
+	s.Equal(Authorize(admin, PermissionAssignRoles), ErrForbidden)
+
+	owner := &models.TeamUser{Role: models.RoleOwner}
+	s.Nil(Authorize(owner, PermissionAssignRoles))
+}
+
+func TestAuthorizeWithAccessToken(t *testing.T) {
+	s := is.New(t)
+
+	// the scopes of the token limit what the role grants
+	admin := &models.TeamUser{
+		Role:        models.RoleAdmin,
+		AccessToken: &models.AccessToken{Scopes: []string{models.AccessTokenScopeReadTimers}},
+	}
+	s.Nil(Authorize(admin, PermissionViewOwnData))
+	s.Equal(Authorize(admin, PermissionViewTeamReports), ErrForbidden)
+	s.Equal(Authorize(admin, PermissionReviewTimesheets), ErrForbidden)
+	s.Equal(AuthorizeForProject(admin, PermissionEditTeamTimers, "project-id"), ErrForbidden)
+
+	// but never grant more than it
+	member := &models.TeamUser{
+		Role:        models.RoleMember,
+		AccessToken: &models.AccessToken{Scopes: []string{models.AccessTokenScopeReports}},
+	}
+	s.Equal(Authorize(member, PermissionViewTeamReports), ErrForbidden)
+}
+
+func TestAuthorizeForProject(t *testing.T) {
+	s := is.New(t)
+
+	manager := &models.TeamUser{Role: models.RoleManager, ManagedProjectIDs: []string{"project-1"}}
+	s.Nil(AuthorizeForProject(manager, PermissionManageBudgets, "project-1"))
+	s.Equal(AuthorizeForProject(manager, PermissionManageBudgets, "project-2"), ErrForbidden)
+	s.Equal(AuthorizeForProject(manager, PermissionAssignRoles, "project-1"), ErrForbidden)
+
+	admin := &models.TeamUser{Role: models.RoleAdmin}
+	s.Nil(AuthorizeForProject(admin, PermissionManageBudgets, "project-2"))
+
+	all, projects := ProjectScope(manager)
+	s.False(all)
+	s.Equal(projects, []string{"project-1"})
+
+	all, _ = ProjectScope(admin)
+	s.True(all)
+}
+
+func TestIsValidRole(t *testing.T) {
+	s := is.New(t)
+
+	s.True(IsValidRole(models.RoleManager))
+	s.False(IsValidRole("superuser"))
+	s.False(IsValidRole(""))
+}
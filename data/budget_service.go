@@ -0,0 +1,227 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+)
+
+// budgetThresholds - percents of the budget consumption the project channel gets notified about
+var budgetThresholds = []int{50, 80, 100}
+
+// BudgetService - calculates project budgets consumption and notifies project channels when it crosses thresholds
+type BudgetService struct {
+	teamRepository  *TeamRepository
+	timerRepository *TimerRepository
+	messenger       SlackMessenger
+}
+
+// NewBudgetService constructs an instance of the service
+func NewBudgetService(session *mgo.Session) *BudgetService {
+	return &BudgetService{
+		teamRepository:  NewTeamRepository(session),
+		timerRepository: NewTimerRepository(session),
+		messenger:       NewSlackMessenger(),
+	}
+}
+
+// UpdateProjectBudget sets the budget of the team's project, passing nil budget removes it
+func (s *BudgetService) UpdateProjectBudget(user *models.TeamUser, team *models.Team, projectID string, budget *models.ProjectBudget) (*models.Project, error) {
+	if user.TeamID != team.ID.Hex() || AuthorizeForProject(user, PermissionManageBudgets, projectID) != nil {
+		return nil, errors.New("update forbidden")
+	}
+
+	project := findProjectByID(team, projectID)
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	if budget != nil {
+		if err := validateBudget(budget); err != nil {
+			return nil, err
+		}
+		// the amount or the period might have changed so the channel should be notified again
+		budget.NotifiedThresholds = []int{}
+		budget.NotifiedPeriod = ""
+	}
+
+	project.Budget = budget
+	return project, s.teamRepository.UpdateProject(team, project)
+}
+
+// ProjectBudgetReport calculates how much of the project budget is consumed for the period the `now` belongs to
+func (s *BudgetService) ProjectBudgetReport(team *models.Team, projectID string, now time.Time) (*models.ProjectBudgetReport, error) {
+	project := findProjectByID(team, projectID)
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	if project.Budget == nil {
+		return nil, errors.New("project has no budget")
+	}
+
+	return s.buildReport(team, project, now), nil
+}
+
+// NotifyThresholdsCrossed goes through all projects having a budget and posts a message to the project channel
+// when the budget consumption has crossed one of the thresholds since the last check
+func (s *BudgetService) NotifyThresholdsCrossed(now time.Time) error {
+	teams, err := s.teamRepository.findTeamsWithBudgets()
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		for _, project := range team.Projects {
+			if project.Budget == nil {
+				continue
+			}
+
+			report := s.buildReport(team, project, now)
+			_, periodKey := budgetPeriod(project.Budget, now)
+
+			if project.Budget.NotifiedPeriod != periodKey {
+				project.Budget.NotifiedPeriod = periodKey
+				project.Budget.NotifiedThresholds = []int{}
+			}
+
+			crossed := newlyCrossedThresholds(project.Budget.NotifiedThresholds, report.Percent)
+			if len(crossed) == 0 {
+				continue
+			}
+
+			// a single message with the highest threshold is enough if several were crossed at once
+			err = s.messenger.PostMessage(team, project.ExternalProjectID, formatBudgetAlert(project, report, crossed[len(crossed)-1]))
+			if err != nil {
+				log.Printf("Failed to post a budget alert to %s channel: %s", project.ExternalProjectName, err)
+				continue
+			}
+
+			project.Budget.NotifiedThresholds = append(project.Budget.NotifiedThresholds, crossed...)
+			if err = s.teamRepository.UpdateProject(team, project); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildReport - the budget is consumed by the time rounded the way it is billed
+func (s *BudgetService) buildReport(team *models.Team, project *models.Project, now time.Time) *models.ProjectBudgetReport {
+	budget := project.Budget
+	periodStart, _ := budgetPeriod(budget, now)
+
+	units, err := s.timerRepository.projectUnits(project.ID.Hex(), periodStart, now)
+	if err != nil {
+		log.Printf("Failed to calculate the time of %s project: %s", project.ExternalProjectName, err)
+	}
+
+	rule := RoundingOf(team, project)
+	seconds, rounded := 0, 0
+	for _, unit := range units {
+		seconds += unit.Seconds
+		rounded += roundEntries(rule, unit.Entries)
+	}
+
+	consumed := float64(rounded) / 3600
+	if budget.Kind == models.BudgetKindMoney {
+		consumed = consumed * budget.HourlyRate
+	}
+
+	report := &models.ProjectBudgetReport{
+		ProjectID:       project.ID.Hex(),
+		Kind:            budget.Kind,
+		Period:          budget.Period,
+		PeriodStart:     periodStart,
+		Budget:          budget.Amount,
+		ConsumedSeconds: seconds,
+		RoundedSeconds:  rounded,
+		Consumed:        consumed,
+		Remaining:       budget.Amount - consumed,
+	}
+
+	if budget.Amount > 0 {
+		report.Percent = consumed / budget.Amount * 100
+	}
+
+	return report
+}
+
+// budgetPeriod returns the start of the budget period `now` belongs to and a key that identifies the period
+func budgetPeriod(budget *models.ProjectBudget, now time.Time) (time.Time, string) {
+	if budget.Period == models.BudgetPeriodMonthly {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), now.Format("2006-01")
+	}
+	return time.Time{}, models.BudgetPeriodTotal
+}
+
+func newlyCrossedThresholds(notified []int, percent float64) []int {
+	result := []int{}
+	for _, threshold := range budgetThresholds {
+		if percent < float64(threshold) {
+			break
+		}
+
+		alreadyNotified := false
+		for _, n := range notified {
+			if n == threshold {
+				alreadyNotified = true
+				break
+			}
+		}
+
+		if !alreadyNotified {
+			result = append(result, threshold)
+		}
+	}
+	return result
+}
+
+func formatBudgetAlert(project *models.Project, report *models.ProjectBudgetReport, threshold int) string {
+	var consumed, budget string
+	if report.Kind == models.BudgetKindMoney {
+		consumed = fmt.Sprintf("%.2f", report.Consumed)
+		budget = fmt.Sprintf("%.2f", report.Budget)
+	} else {
+		consumed = utils.FormatDuration(time.Duration(report.ConsumedSeconds) * time.Second)
+		budget = utils.FormatDuration(time.Duration(report.Budget * float64(time.Hour)))
+	}
+
+	period := "total"
+	if report.Period == models.BudgetPeriodMonthly {
+		period = "monthly"
+	}
+
+	return fmt.Sprintf("<#%s|%s> has consumed %d%% of its %s budget: %s of %s",
+		project.ExternalProjectID, project.ExternalProjectName, threshold, period, consumed, budget)
+}
+
+func validateBudget(budget *models.ProjectBudget) error {
+	if budget.Kind != models.BudgetKindHours && budget.Kind != models.BudgetKindMoney {
+		return errors.New("budget kind must be either `hours` or `money`")
+	}
+
+	if budget.Period == "" {
+		budget.Period = models.BudgetPeriodTotal
+	}
+
+	if budget.Period != models.BudgetPeriodTotal && budget.Period != models.BudgetPeriodMonthly {
+		return errors.New("budget period must be either `total` or `monthly`")
+	}
+
+	if budget.Amount <= 0 {
+		return errors.New("budget amount must be positive")
+	}
+
+	if budget.Kind == models.BudgetKindMoney && budget.HourlyRate <= 0 {
+		return errors.New("hourly rate must be positive for a money budget")
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package data
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestBudgetService(t *testing.T) {
+	gosuite.Run(t, &BudgetServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *BudgetServiceTestSuite) TestUpdateProjectBudget(t *testing.T) {
+	budget := &models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 10}
+
+	project, err := s.service.UpdateProjectBudget(s.admin, s.team, s.project.ID.Hex(), budget)
+	s.Nil(err)
+	s.Equal(project.Budget.Period, models.BudgetPeriodTotal)
+
+	team, err := s.teamRepository.FindByID(s.team.ID.Hex())
+	s.Nil(err)
+	s.Equal(team.Projects[0].Budget.Amount, 10.0)
+	s.Equal(team.Projects[0].Budget.Kind, models.BudgetKindHours)
+}
+
+func (s *BudgetServiceTestSuite) TestUpdateProjectBudgetForbidden(t *testing.T) {
+	member := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), SlackUserInfo: &slack.User{}}
+	budget := &models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 10}
+
+	_, err := s.service.UpdateProjectBudget(member, s.team, s.project.ID.Hex(), budget)
+	s.Err(err)
+	s.Equal(err.Error(), "update forbidden")
+}
+
+func (s *BudgetServiceTestSuite) TestUpdateProjectBudgetValidation(t *testing.T) {
+	budgets := []*models.ProjectBudget{
+		{Kind: "days", Amount: 10},
+		{Kind: models.BudgetKindHours, Amount: 0},
+		{Kind: models.BudgetKindHours, Amount: 10, Period: "weekly"},
+		{Kind: models.BudgetKindMoney, Amount: 1000},
+	}
+
+	for _, budget := range budgets {
+		_, err := s.service.UpdateProjectBudget(s.admin, s.team, s.project.ID.Hex(), budget)
+		s.Err(err)
+	}
+}
+
+func (s *BudgetServiceTestSuite) TestProjectBudgetReport(t *testing.T) {
+	s.setBudget(&models.ProjectBudget{Kind: models.BudgetKindMoney, Amount: 100, HourlyRate: 50})
+	s.createTimer(utils.PT("2016 Dec 10 10:00:00"), 30)
+	s.createTimer(utils.PT("2016 Dec 11 10:00:00"), 30)
+
+	report, err := s.service.ProjectBudgetReport(s.team, s.project.ID.Hex(), utils.PT("2016 Dec 20 10:00:00"))
+	s.Nil(err)
+	s.Equal(report.ConsumedSeconds, 60*60)
+	s.Equal(report.Consumed, 50.0)
+	s.Equal(report.Remaining, 50.0)
+	s.Equal(report.Percent, 50.0)
+}
+
+func (s *BudgetServiceTestSuite) TestProjectBudgetReportMonthly(t *testing.T) {
+	s.setBudget(&models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 2, Period: models.BudgetPeriodMonthly})
+	s.createTimer(utils.PT("2016 Nov 30 10:00:00"), 60)
+	s.createTimer(utils.PT("2016 Dec 01 10:00:00"), 30)
+
+	report, err := s.service.ProjectBudgetReport(s.team, s.project.ID.Hex(), utils.PT("2016 Dec 20 10:00:00"))
+	s.Nil(err)
+	s.Equal(report.ConsumedSeconds, 30*60)
+	s.Equal(report.Percent, 25.0)
+	s.Equal(report.PeriodStart, utils.PT("2016 Dec 01 00:00:00"))
+}
+
+func (s *BudgetServiceTestSuite) TestProjectBudgetReportRounded(t *testing.T) {
+	s.setBudget(&models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 2})
+	s.team.Rounding = &models.RoundingRule{Increment: 15, Direction: models.RoundingUp, Scope: models.RoundingPerEntry}
+	s.createTimer(utils.PT("2016 Dec 10 10:00:00"), 20)
+	s.createTimer(utils.PT("2016 Dec 10 11:00:00"), 5)
+
+	report, err := s.service.ProjectBudgetReport(s.team, s.project.ID.Hex(), utils.PT("2016 Dec 20 10:00:00"))
+	s.Nil(err)
+	s.Equal(report.ConsumedSeconds, 25*60)
+	s.Equal(report.RoundedSeconds, 45*60)
+	s.Equal(report.Percent, 37.5)
+
+	// the day total is rounded once
+	s.team.Rounding.Scope = models.RoundingPerDay
+	report, err = s.service.ProjectBudgetReport(s.team, s.project.ID.Hex(), utils.PT("2016 Dec 20 10:00:00"))
+	s.Nil(err)
+	s.Equal(report.RoundedSeconds, 30*60)
+}
+
+func (s *BudgetServiceTestSuite) TestProjectBudgetReportWithoutBudget(t *testing.T) {
+	_, err := s.service.ProjectBudgetReport(s.team, s.project.ID.Hex(), time.Now())
+	s.Err(err)
+}
+
+func (s *BudgetServiceTestSuite) TestNotifyThresholdsCrossed(t *testing.T) {
+	s.setBudget(&models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 1})
+	s.createTimer(utils.PT("2016 Dec 10 10:00:00"), 50)
+
+	now := utils.PT("2016 Dec 20 10:00:00")
+	s.Nil(s.service.NotifyThresholdsCrossed(now))
+	s.Len(s.messenger.messages, 1)
+	s.Equal(s.messenger.messages[0].channelID, "channel-id")
+	s.Equal(s.messenger.messages[0].text, "<#channel-id|channel-name> has consumed 80% of its total budget: 0:50 of 1:00")
+
+	// nothing new is crossed - no new messages
+	s.Nil(s.service.NotifyThresholdsCrossed(now))
+	s.Len(s.messenger.messages, 1)
+
+	s.createTimer(utils.PT("2016 Dec 11 10:00:00"), 10)
+	s.Nil(s.service.NotifyThresholdsCrossed(now))
+	s.Len(s.messenger.messages, 2)
+
+	team, _ := s.teamRepository.FindByID(s.team.ID.Hex())
+	s.Equal(team.Projects[0].Budget.NotifiedThresholds, []int{50, 80, 100})
+}
+
+func (s *BudgetServiceTestSuite) TestNotifyThresholdsCrossedResetsMonthly(t *testing.T) {
+	s.setBudget(&models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 1, Period: models.BudgetPeriodMonthly})
+	s.createTimer(utils.PT("2016 Nov 10 10:00:00"), 30)
+	s.createTimer(utils.PT("2016 Dec 10 10:00:00"), 30)
+
+	s.Nil(s.service.NotifyThresholdsCrossed(utils.PT("2016 Nov 20 10:00:00")))
+	s.Len(s.messenger.messages, 1)
+
+	s.Nil(s.service.NotifyThresholdsCrossed(utils.PT("2016 Dec 20 10:00:00")))
+	s.Len(s.messenger.messages, 2)
+
+	team, _ := s.teamRepository.FindByID(s.team.ID.Hex())
+	s.Equal(team.Projects[0].Budget.NotifiedPeriod, "2016-12")
+	s.Equal(team.Projects[0].Budget.NotifiedThresholds, []int{50})
+}
+
+func (s *BudgetServiceTestSuite) TestNotifyThresholdsCrossedWithUnbudgetedProjects(t *testing.T) {
+	s.Nil(s.teamRepository.AddProject(s.team, "other-channel-id", "other-channel-name"))
+	s.setBudget(&models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 1})
+	s.Len(s.team.Projects, 2)
+	s.Nil(s.team.Projects[1].Budget)
+
+	s.createTimer(utils.PT("2016 Dec 10 10:00:00"), 30)
+
+	s.Nil(s.service.NotifyThresholdsCrossed(utils.PT("2016 Dec 20 10:00:00")))
+	s.Len(s.messenger.messages, 1)
+	s.Equal(s.messenger.messages[0].channelID, "channel-id")
+}
+
+func (s *BudgetServiceTestSuite) setBudget(budget *models.ProjectBudget) {
+	project, err := s.service.UpdateProjectBudget(s.admin, s.team, s.project.ID.Hex(), budget)
+	s.Nil(err)
+	s.project = project
+	s.team, _ = s.teamRepository.FindByID(s.team.ID.Hex())
+}
+
+func (s *BudgetServiceTestSuite) createTimer(createdAt time.Time, minutes int) {
+	finishedAt := createdAt.Add(time.Duration(minutes) * time.Minute)
+	_, err := s.timerRepository.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     s.team.ID.Hex(),
+		ProjectID:  s.project.ID.Hex(),
+		TeamUserID: s.admin.ID.Hex(),
+		CreatedAt:  createdAt,
+		FinishedAt: &finishedAt,
+		Seconds:    minutes * 60,
+	})
+	s.Nil(err)
+}
+
+func (s *BudgetServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.teamRepository = NewTeamRepository(s.session)
+	s.timerRepository = NewTimerRepository(s.session)
+}
+
+func (s *BudgetServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *BudgetServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.messenger = &testSlackMessenger{}
+	s.service = NewBudgetService(s.session)
+	s.service.messenger = s.messenger
+
+	team, err := s.teamRepository.CreateTeam("team-id", "team-name")
+	s.Nil(err)
+	s.Nil(s.teamRepository.AddProject(team, "channel-id", "channel-name"))
+
+	s.team, _ = s.teamRepository.FindByID(team.ID.Hex())
+	s.project = s.team.Projects[0]
+	s.admin = &models.TeamUser{
+		ID:            bson.NewObjectId(),
+		TeamID:        s.team.ID.Hex(),
+		SlackUserInfo: &slack.User{IsAdmin: true},
+	}
+}
+
+func (s *BudgetServiceTestSuite) TearDown() {
+}
+
+type BudgetServiceTestSuite struct {
+	*is.Is
+	env             *utils.Environment
+	session         *mgo.Session
+	service         *BudgetService
+	teamRepository  *TeamRepository
+	timerRepository *TimerRepository
+	messenger       *testSlackMessenger
+	team            *models.Team
+	project         *models.Project
+	admin           *models.TeamUser
+}
+
+// testSlackMessenger is a SlackMessenger that records messages instead of sending them
+type testSlackMessenger struct {
+	messages []*testSlackMessage
+}
+
+type testSlackMessage struct {
+	channelID string
+	text      string
+}
+
+func (m *testSlackMessenger) PostMessage(team *models.Team, channelID, text string) error {
+	m.messages = append(m.messages, &testSlackMessage{channelID: channelID, text: text})
+	return nil
+}
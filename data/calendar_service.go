@@ -0,0 +1,110 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/satori/go.uuid"
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	// CalendarFeedDefaultDays - how many days back the feed covers unless asked otherwise
+	CalendarFeedDefaultDays = 60
+	calendarFeedMaxDays     = 366
+)
+
+// CalendarService - serves personal iCalendar feeds of tracked time
+type CalendarService struct {
+	userRepository  *UserRepository
+	timerRepository *TimerRepository
+}
+
+// NewCalendarService constructs an instance of the service
+func NewCalendarService(session *mgo.Session) *CalendarService {
+	return &CalendarService{
+		userRepository:  NewUserRepository(session),
+		timerRepository: NewTimerRepository(session),
+	}
+}
+
+// FeedToken returns the token of the user's feed turning the feed on if it is off
+func (s *CalendarService) FeedToken(user *models.TeamUser) (string, error) {
+	if user.CalendarToken != "" {
+		return user.CalendarToken, nil
+	}
+	return s.RegenerateFeedToken(user)
+}
+
+// RegenerateFeedToken replaces the token so the previously shared feed URL stops working
+func (s *CalendarService) RegenerateFeedToken(user *models.TeamUser) (string, error) {
+	user.CalendarToken = strings.Replace(uuid.NewV4().String(), "-", "", -1)
+	_, err := s.userRepository.Save(user)
+	return user.CalendarToken, err
+}
+
+// RevokeFeedToken turns the user's feed off
+func (s *CalendarService) RevokeFeedToken(user *models.TeamUser) error {
+	user.CalendarToken = ""
+	_, err := s.userRepository.Save(user)
+	return err
+}
+
+// Feed writes the iCalendar feed of the token's owner covering the given number of days back from now.
+// A running timer is included as a tentative event ending now
+func (s *CalendarService) Feed(w io.Writer, token string, days int, now time.Time) error {
+	if token == "" {
+		return mgo.ErrNotFound
+	}
+	if days <= 0 || days > calendarFeedMaxDays {
+		return fmt.Errorf("days should be between 1 and %d", calendarFeedMaxDays)
+	}
+
+	user, err := s.userRepository.findByCalendarToken(token)
+	if err != nil {
+		return err
+	}
+	if user.SlackUserInfo == nil {
+		return errors.New("user has no Slack profile")
+	}
+
+	timers, err := s.timerRepository.findUserTasksByRange(user.ID.Hex(), now.AddDate(0, 0, -days), now)
+	if err != nil {
+		return err
+	}
+
+	events := make([]*utils.ICalEvent, len(timers))
+	for i, timer := range timers {
+		events[i] = calendarEvent(timer, now)
+	}
+
+	writer := &utils.ICalWriter{Name: "Tuna Timer", TZOffset: user.SlackUserInfo.TZOffset}
+	return writer.Write(w, events, now)
+}
+
+func calendarEvent(timer *models.Timer, now time.Time) *utils.ICalEvent {
+	event := &utils.ICalEvent{
+		UID:        timer.ID.Hex() + "@tuna-timer",
+		Summary:    fmt.Sprintf("%s: %s", timer.ProjectExternalName, timer.TaskName),
+		Categories: append([]string{timer.ProjectExternalName}, timer.Tags...),
+		Start:      timer.CreatedAt,
+	}
+
+	if timer.FinishedAt == nil {
+		event.End = now
+		event.Tentative = true
+		event.Summary += " (running)"
+	} else {
+		// edits change the tracked time so the event is as long as the timer's seconds
+		event.End = timer.CreatedAt.Add(time.Duration(timer.Seconds) * time.Second)
+	}
+
+	event.Description = fmt.Sprintf("Project: %s\nTask: %s\nTracked: %s", timer.ProjectExternalName, timer.TaskName,
+		utils.FormatDuration(event.End.Sub(event.Start)))
+	return event
+}
@@ -0,0 +1,125 @@
+package data
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestCalendarService(t *testing.T) {
+	gosuite.Run(t, &CalendarServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *CalendarServiceTestSuite) TestFeed(t *testing.T) {
+	user, _ := NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         "team",
+		ExternalUserID: "user",
+		SlackUserInfo:  &slack.User{TZOffset: 7200},
+	})
+
+	token, err := s.service.FeedToken(user)
+	s.Nil(err)
+	s.NotEqual(token, "")
+
+	again, _ := s.service.FeedToken(user)
+	s.Equal(again, token)
+
+	timerRepository := NewTimerRepository(s.session)
+	finishedAt := utils.PT("2016 Dec 05 11:00:00")
+	timerRepository.CreateTimer(&models.Timer{
+		ID:                  bson.NewObjectId(),
+		TeamUserID:          user.ID.Hex(),
+		ProjectExternalName: "general",
+		TaskName:            "fix login",
+		CreatedAt:           utils.PT("2016 Dec 05 10:00:00"),
+		FinishedAt:          &finishedAt,
+		Seconds:             90 * 60,
+	})
+	timerRepository.CreateTimer(&models.Timer{
+		ID:                  bson.NewObjectId(),
+		TeamUserID:          user.ID.Hex(),
+		ProjectExternalName: "general",
+		TaskName:            "review",
+		CreatedAt:           utils.PT("2016 Dec 05 12:00:00"),
+	})
+	// out of the window
+	timerRepository.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamUserID: user.ID.Hex(),
+		TaskName:   "ancient",
+		CreatedAt:  utils.PT("2016 Sep 05 12:00:00"),
+		FinishedAt: &finishedAt,
+	})
+
+	var feed bytes.Buffer
+	s.Nil(s.service.Feed(&feed, token, CalendarFeedDefaultDays, utils.PT("2016 Dec 05 12:30:00")))
+	s.Equal(strings.Count(feed.String(), "BEGIN:VEVENT"), 2)
+	s.True(strings.Contains(feed.String(), "SUMMARY:general: fix login\r\n"))
+	s.True(strings.Contains(feed.String(), "DTSTART;TZID=UTC+0200:20161205T120000\r\n"))
+	s.True(strings.Contains(feed.String(), "DTEND;TZID=UTC+0200:20161205T133000\r\n"))
+	s.True(strings.Contains(feed.String(), "SUMMARY:general: review (running)\r\n"))
+	s.True(strings.Contains(feed.String(), "DTEND;TZID=UTC+0200:20161205T143000\r\n"))
+}
+
+func (s *CalendarServiceTestSuite) TestRegenerateAndRevokeFeedToken(t *testing.T) {
+	user, _ := NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         "team",
+		ExternalUserID: "user",
+		SlackUserInfo:  &slack.User{},
+	})
+	now := utils.PT("2016 Dec 05 12:30:00")
+
+	token, _ := s.service.FeedToken(user)
+	newToken, err := s.service.RegenerateFeedToken(user)
+	s.Nil(err)
+	s.NotEqual(newToken, token)
+
+	var feed bytes.Buffer
+	s.Equal(s.service.Feed(&feed, token, CalendarFeedDefaultDays, now), mgo.ErrNotFound)
+	s.Nil(s.service.Feed(&feed, newToken, CalendarFeedDefaultDays, now))
+
+	s.Nil(s.service.RevokeFeedToken(user))
+	s.Equal(s.service.Feed(&feed, newToken, CalendarFeedDefaultDays, now), mgo.ErrNotFound)
+	s.Equal(s.service.Feed(&feed, "", CalendarFeedDefaultDays, now), mgo.ErrNotFound)
+}
+
+type CalendarServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *CalendarService
+}
+
+func (s *CalendarServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.service = NewCalendarService(s.session)
+}
+
+func (s *CalendarServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *CalendarServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+}
+
+func (s *CalendarServiceTestSuite) TearDown() {}
@@ -0,0 +1,208 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2"
+)
+
+const capacityDateLayout = "2006-01-02"
+
+// Periods of capacity reports requested from Slack
+const (
+	CapacityPeriodWeek     = "week"
+	CapacityPeriodLastWeek = "last week"
+	CapacityPeriodMonth    = "month"
+)
+
+// DefaultWorkSchedule - eight hours from Monday to Friday
+var DefaultWorkSchedule = models.WorkSchedule{
+	Hours:     []float64{0, 8, 8, 8, 8, 8, 0},
+	WeekStart: time.Monday,
+}
+
+// CapacityService - compares the time users track with the hours of their work schedules
+type CapacityService struct {
+	timerRepository *TimerRepository
+	userRepository  *UserRepository
+	timeOffService  *TimeOffService
+}
+
+// NewCapacityService constructs an instance of the service
+func NewCapacityService(session *mgo.Session) *CapacityService {
+	return &CapacityService{
+		timerRepository: NewTimerRepository(session),
+		userRepository:  NewUserRepository(session),
+		timeOffService:  NewTimeOffService(session),
+	}
+}
+
+// WorkScheduleOf returns the schedule the user works by: the user's own one, the team's one or the default
+func WorkScheduleOf(user *models.TeamUser, team *models.Team) *models.WorkSchedule {
+	if user.WorkSchedule != nil {
+		return user.WorkSchedule
+	}
+	if team != nil && team.WorkSchedule != nil {
+		return team.WorkSchedule
+	}
+	schedule := DefaultWorkSchedule
+	return &schedule
+}
+
+// UpdateUserSchedule sets the user's own work schedule, nil schedule makes the user work by the team's one
+func (s *CapacityService) UpdateUserSchedule(user *models.TeamUser, team *models.Team, schedule *models.WorkSchedule) (*models.WorkSchedule, error) {
+	if schedule != nil {
+		if err := validateWorkSchedule(schedule); err != nil {
+			return nil, err
+		}
+	}
+
+	user.WorkSchedule = schedule
+	if _, err := s.userRepository.Save(user); err != nil {
+		return nil, err
+	}
+	return WorkScheduleOf(user, team), nil
+}
+
+// Report compares the time the user has tracked with the user's schedule for the range of days (like 2016-12-1),
+// both ends are inclusive. Holidays and user's time off reduce the expected time.
+// Reports of other team members require a permission to review their time
+func (s *CapacityService) Report(viewer, user *models.TeamUser, team *models.Team, startDate, endDate string) (*models.CapacityReport, error) {
+	if viewer.ID != user.ID && (viewer.TeamID != user.TeamID || !Can(viewer, PermissionReviewTimesheets)) {
+		return nil, ErrForbidden
+	}
+
+	start, end, err := parseDays(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime, endTime, err := ParseDateRange(startDate, endDate, user.SlackUserInfo.TZOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	statistics, err := s.timerRepository.userStatistics(user, startTime, endTime, ReportGroupByDay)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := map[string]int{}
+	for _, day := range statistics {
+		tracked[day.Date] = day.Seconds
+	}
+
+	daysOff, err := s.timeOffService.DaysOff(user, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := WorkScheduleOf(user, team)
+	report := buildCapacityReport(schedule, start, end, scheduledSeconds(schedule, start, end), tracked, daysOff)
+	report.TeamUserID = user.ID.Hex()
+	return report, nil
+}
+
+// PeriodReport is the Report of the user for the current week or month up to today, or for the whole last week
+func (s *CapacityService) PeriodReport(user *models.TeamUser, team *models.Team, period string, now time.Time) (*models.CapacityReport, error) {
+	local := now.UTC().Add(time.Duration(user.SlackUserInfo.TZOffset) * time.Second)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := weekStartOf(today, WorkScheduleOf(user, team).WeekStart)
+
+	var start, end time.Time
+	switch period {
+	case CapacityPeriodWeek:
+		start, end = weekStart, today
+	case CapacityPeriodLastWeek:
+		start, end = weekStart.AddDate(0, 0, -7), weekStart.AddDate(0, 0, -1)
+	case CapacityPeriodMonth:
+		start, end = today.AddDate(0, 0, 1-today.Day()), today
+	default:
+		return nil, fmt.Errorf("unknown period `%s`", period)
+	}
+
+	return s.Report(user, user, team, start.Format(capacityDateLayout), end.Format(capacityDateLayout))
+}
+
+// scheduledSeconds returns the seconds the schedule expects for every day of the range, keyed by date
+func scheduledSeconds(schedule *models.WorkSchedule, start, end time.Time) map[string]int {
+	result := map[string]int{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		result[day.Format(capacityDateLayout)] = int(schedule.Hours[day.Weekday()] * 60 * 60)
+	}
+	return result
+}
+
+// buildCapacityReport lays the expected and tracked seconds (keyed by date) out by days and by weeks of the schedule,
+// days off expect nothing or half of the scheduled time
+func buildCapacityReport(schedule *models.WorkSchedule, start, end time.Time, expected, tracked map[string]int, daysOff map[string]*DayOff) *models.CapacityReport {
+	report := &models.CapacityReport{
+		StartDate: start.Format(capacityDateLayout),
+		EndDate:   end.Format(capacityDateLayout),
+		Schedule:  schedule,
+		Days:      []*models.CapacityDay{},
+		Weeks:     []*models.CapacityWeek{},
+	}
+
+	var week *models.CapacityWeek
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(capacityDateLayout)
+
+		off := ""
+		if dayOff := daysOff[date]; dayOff != nil {
+			off = dayOff.Reason
+			if dayOff.HalfDay {
+				expected[date] = expected[date] / 2
+			} else {
+				expected[date] = 0
+			}
+		}
+
+		report.ExpectedSeconds += expected[date]
+		report.TrackedSeconds += tracked[date]
+		report.BalanceSeconds = report.TrackedSeconds - report.ExpectedSeconds
+
+		report.Days = append(report.Days, &models.CapacityDay{
+			Date:              date,
+			Off:               off,
+			ExpectedSeconds:   expected[date],
+			TrackedSeconds:    tracked[date],
+			BalanceSeconds:    tracked[date] - expected[date],
+			CumulativeBalance: report.BalanceSeconds,
+		})
+
+		if week == nil || day.Weekday() == schedule.WeekStart {
+			week = &models.CapacityWeek{WeekStart: weekStartOf(day, schedule.WeekStart).Format(capacityDateLayout)}
+			report.Weeks = append(report.Weeks, week)
+		}
+		week.ExpectedSeconds += expected[date]
+		week.TrackedSeconds += tracked[date]
+		week.BalanceSeconds = week.TrackedSeconds - week.ExpectedSeconds
+		week.CumulativeBalance = report.BalanceSeconds
+	}
+
+	return report
+}
+
+// weekStartOf returns the date of the first day of the week the day belongs to
+func weekStartOf(day time.Time, weekStart time.Weekday) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(weekStart) + 7) % 7))
+}
+
+func validateWorkSchedule(schedule *models.WorkSchedule) error {
+	if len(schedule.Hours) != 7 {
+		return errors.New("schedule must have hours for each of 7 weekdays starting with Sunday")
+	}
+	for _, hours := range schedule.Hours {
+		if hours < 0 || hours > 24 {
+			return fmt.Errorf("wrong number of hours %v, it must be between 0 and 24", hours)
+		}
+	}
+	if schedule.WeekStart < time.Sunday || schedule.WeekStart > time.Saturday {
+		return errors.New("week must start with a weekday from 0 (Sunday) to 6 (Saturday)")
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package data
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestBuildCapacityReport(t *testing.T) {
+	s := is.New(t)
+
+	schedule := &models.WorkSchedule{Hours: []float64{0, 8, 8, 8, 8, 6, 0}, WeekStart: time.Monday}
+	// from Friday to Tuesday
+	start := utils.PT("2016 Dec 02 00:00:00")
+	end := utils.PT("2016 Dec 06 00:00:00")
+	tracked := map[string]int{
+		"2016-12-02": 7 * 60 * 60,
+		"2016-12-03": 60 * 60,
+		"2016-12-05": (8*60 + 30) * 60,
+	}
+
+	report := buildCapacityReport(schedule, start, end, scheduledSeconds(schedule, start, end), tracked, nil)
+	s.Equal(report.StartDate, "2016-12-02")
+	s.Equal(report.EndDate, "2016-12-06")
+	s.Equal(report.ExpectedSeconds, 22*60*60)
+	s.Equal(report.TrackedSeconds, (16*60+30)*60)
+	s.Equal(report.BalanceSeconds, (-5*60-30)*60)
+
+	s.Len(report.Days, 5)
+	s.Equal(report.Days[0].BalanceSeconds, 60*60)
+	s.Equal(report.Days[1].ExpectedSeconds, 0)
+	s.Equal(report.Days[1].CumulativeBalance, 2*60*60)
+	s.Equal(report.Days[3].BalanceSeconds, 30*60)
+	s.Equal(report.Days[4].BalanceSeconds, -8*60*60)
+	s.Equal(report.Days[4].CumulativeBalance, (-5*60-30)*60)
+
+	s.Len(report.Weeks, 2)
+	s.Equal(report.Weeks[0].WeekStart, "2016-11-28")
+	s.Equal(report.Weeks[0].ExpectedSeconds, 6*60*60)
+	s.Equal(report.Weeks[0].BalanceSeconds, 2*60*60)
+	s.Equal(report.Weeks[1].WeekStart, "2016-12-05")
+	s.Equal(report.Weeks[1].BalanceSeconds, (-7*60-30)*60)
+	s.Equal(report.Weeks[1].CumulativeBalance, (-5*60-30)*60)
+}
+
+func TestBuildCapacityReportWithDaysOff(t *testing.T) {
+	s := is.New(t)
+
+	schedule := &DefaultWorkSchedule
+	start := utils.PT("2016 Dec 05 00:00:00")
+	end := utils.PT("2016 Dec 07 00:00:00")
+	daysOff := map[string]*DayOff{
+		"2016-12-05": {Reason: "vacation"},
+		"2016-12-06": {Reason: "St. Nicholas Day", HalfDay: true},
+	}
+
+	report := buildCapacityReport(schedule, start, end, scheduledSeconds(schedule, start, end), map[string]int{}, daysOff)
+	s.Equal(report.ExpectedSeconds, 12*60*60)
+	s.Equal(report.Days[0].Off, "vacation")
+	s.Equal(report.Days[0].ExpectedSeconds, 0)
+	s.Equal(report.Days[1].Off, "St. Nicholas Day")
+	s.Equal(report.Days[1].ExpectedSeconds, 4*60*60)
+	s.Equal(report.Days[2].Off, "")
+	s.Equal(report.Days[2].BalanceSeconds, -8*60*60)
+}
+
+func TestWeekStartOf(t *testing.T) {
+	s := is.New(t)
+	tuesday := utils.PT("2016 Dec 06 00:00:00")
+
+	s.Equal(weekStartOf(tuesday, time.Monday), utils.PT("2016 Dec 05 00:00:00"))
+	s.Equal(weekStartOf(tuesday, time.Sunday), utils.PT("2016 Dec 04 00:00:00"))
+	s.Equal(weekStartOf(tuesday, time.Tuesday), tuesday)
+	s.Equal(weekStartOf(tuesday, time.Wednesday), utils.PT("2016 Nov 30 00:00:00"))
+}
+
+func TestCapacityService(t *testing.T) {
+	gosuite.Run(t, &CapacityServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *CapacityServiceTestSuite) TestReport(t *testing.T) {
+	finished := utils.PT("2016 Dec 05 23:00:00")
+	timerRepository := NewTimerRepository(s.session)
+	// Dec 05 23:00 UTC is Dec 06 in user's timezone
+	timerRepository.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamUserID: s.user.ID.Hex(),
+		CreatedAt:  utils.PT("2016 Dec 05 23:00:00"),
+		FinishedAt: &finished,
+		Seconds:    9 * 60 * 60,
+	})
+	timerRepository.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamUserID: s.user.ID.Hex(),
+		CreatedAt:  utils.PT("2016 Dec 05 08:00:00"),
+		FinishedAt: &finished,
+		Seconds:    4 * 60 * 60,
+	})
+
+	report, err := s.service.Report(s.user, s.user, s.team, "2016-12-5", "2016-12-6")
+	s.Nil(err)
+	s.Equal(report.ExpectedSeconds, 16*60*60)
+	s.Equal(report.TrackedSeconds, 13*60*60)
+	s.Equal(report.Days[0].BalanceSeconds, -4*60*60)
+	s.Equal(report.Days[1].BalanceSeconds, 60*60)
+	s.Equal(report.BalanceSeconds, -3*60*60)
+
+	_, err = s.service.Report(s.user, s.user, s.team, "2016-12-6", "2016-12-5")
+	s.Err(err)
+
+	member := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleMember}
+	_, err = s.service.Report(member, s.user, s.team, "2016-12-5", "2016-12-6")
+	s.Equal(err, ErrForbidden)
+
+	manager := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleManager, ManagedProjectIDs: []string{"project"}}
+	_, err = s.service.Report(manager, s.user, s.team, "2016-12-5", "2016-12-6")
+	s.Equal(err, ErrForbidden)
+
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleAdmin}
+	_, err = s.service.Report(admin, s.user, s.team, "2016-12-5", "2016-12-6")
+	s.Nil(err)
+}
+
+func (s *CapacityServiceTestSuite) TestSchedules(t *testing.T) {
+	s.Equal(*WorkScheduleOf(s.user, s.team), DefaultWorkSchedule)
+
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleAdmin}
+	teamSchedule := &models.WorkSchedule{Hours: []float64{0, 6, 6, 6, 6, 6, 0}, WeekStart: time.Sunday}
+
+	_, err := NewTeamService(s.session).UpdateWorkSchedule(s.user, s.team, teamSchedule)
+	s.Equal(err, ErrForbidden)
+
+	_, err = NewTeamService(s.session).UpdateWorkSchedule(admin, s.team, &models.WorkSchedule{Hours: []float64{8}})
+	s.Err(err)
+
+	team, err := NewTeamService(s.session).UpdateWorkSchedule(admin, s.team, teamSchedule)
+	s.Nil(err)
+	s.Equal(WorkScheduleOf(s.user, team).WeekStart, time.Sunday)
+
+	schedule, err := s.service.UpdateUserSchedule(s.user, team, &models.WorkSchedule{Hours: []float64{0, 4, 4, 4, 4, 4, 0}, WeekStart: time.Monday})
+	s.Nil(err)
+	s.Equal(schedule.Hours[1], 4.0)
+
+	user, _ := NewUserRepository(s.session).FindByID(s.user.ID.Hex())
+	s.Equal(WorkScheduleOf(user, team).Hours[1], 4.0)
+
+	schedule, err = s.service.UpdateUserSchedule(user, team, nil)
+	s.Nil(err)
+	s.Equal(schedule.Hours[1], 6.0)
+}
+
+type CapacityServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *CapacityService
+	team    *models.Team
+	user    *models.TeamUser
+}
+
+func (s *CapacityServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.service = NewCapacityService(s.session)
+}
+
+func (s *CapacityServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *CapacityServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.team, _ = NewTeamRepository(s.session).CreateTeam("team-id", "team-name")
+	s.user, _ = NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "user",
+		SlackUserInfo:  &slack.User{TZOffset: 7200},
+	})
+}
+
+func (s *CapacityServiceTestSuite) TearDown() {}
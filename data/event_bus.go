@@ -0,0 +1,109 @@
+package data
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// eventSubscriptionBuffer - events a subscriber may lag behind before the next ones are dropped for it
+const eventSubscriptionBuffer = 64
+
+// EventBus delivers timer events to the subscribers of the instance. The in-process bus serves a single
+// instance of the API, a bus shared by several instances (e.g. a capped Mongo collection tailed by each of
+// them) implements the same interface and replaces DefaultEventBus at startup
+type EventBus interface {
+	TimerEventPublisher
+	Subscribe() *EventSubscription
+	Unsubscribe(subscription *EventSubscription)
+}
+
+// DefaultEventBus is fed by every TimerService and read by the event stream of the frontend
+var DefaultEventBus EventBus = NewMemoryEventBus()
+
+// EventSubscription - the events published after subscribing arrive to Events until unsubscribed
+type EventSubscription struct {
+	Events chan *models.TimerEvent
+}
+
+// MemoryEventBus - an EventBus within the process
+type MemoryEventBus struct {
+	mutex       sync.RWMutex
+	subscribers map[*EventSubscription]bool
+}
+
+// NewMemoryEventBus constructs an instance of the bus
+func NewMemoryEventBus() *MemoryEventBus {
+	return &MemoryEventBus{subscribers: map[*EventSubscription]bool{}}
+}
+
+// Subscribe starts delivering the events to a new subscription
+func (b *MemoryEventBus) Subscribe() *EventSubscription {
+	subscription := &EventSubscription{Events: make(chan *models.TimerEvent, eventSubscriptionBuffer)}
+
+	b.mutex.Lock()
+	b.subscribers[subscription] = true
+	b.mutex.Unlock()
+	return subscription
+}
+
+// Unsubscribe stops the deliveries and closes the Events of the subscription
+func (b *MemoryEventBus) Unsubscribe(subscription *EventSubscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.subscribers[subscription] {
+		delete(b.subscribers, subscription)
+		close(subscription.Events)
+	}
+}
+
+// Fire publishes the event to every subscriber, never waiting for them: a subscriber whose buffer is full
+// misses the event rather than holds the timer change up
+func (b *MemoryEventBus) Fire(event string, timer *models.Timer) {
+	// a copy so that the changes made to the timer after the event do not leak into it
+	snapshot := *timer
+	timerEvent := &models.TimerEvent{
+		ID:         bson.NewObjectId().Hex(),
+		Event:      event,
+		TeamID:     timer.TeamID,
+		TeamUserID: timer.TeamUserID,
+		OccurredAt: time.Now(),
+		Timer:      &snapshot,
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for subscription := range b.subscribers {
+		select {
+		case subscription.Events <- timerEvent:
+		default:
+			log.Printf("Dropped %s of timer %s for a slow subscriber", event, timer.ID.Hex())
+		}
+	}
+}
+
+// CanSeeTimerEvent tells whether the event belongs to the user's stream: users get the events of their own timers,
+// those who see team reports also get the ones of the team's timers of the projects in their scope
+func CanSeeTimerEvent(user *models.TeamUser, event *models.TimerEvent) bool {
+	if event.TeamUserID == user.ID.Hex() {
+		return true
+	}
+	if event.TeamID != user.TeamID {
+		return false
+	}
+	return AuthorizeForProject(user, PermissionViewTeamReports, event.Timer.ProjectID) == nil
+}
+
+// timerEventPublishers fans a timer event out to all of its publishers
+type timerEventPublishers []TimerEventPublisher
+
+func (p timerEventPublishers) Fire(event string, timer *models.Timer) {
+	for _, publisher := range p {
+		publisher.Fire(event, timer)
+	}
+}
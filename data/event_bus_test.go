@@ -0,0 +1,58 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestMemoryEventBus(t *testing.T) {
+	s := is.New(t)
+	bus := NewMemoryEventBus()
+
+	first := bus.Subscribe()
+	second := bus.Subscribe()
+
+	timer := &models.Timer{ID: bson.NewObjectId(), TeamID: "team", TeamUserID: "user", TaskName: "write docs"}
+	bus.Fire(models.WebhookEventTimerStarted, timer)
+	timer.TaskName = "renamed"
+
+	event := <-first.Events
+	s.Equal(event.Event, models.WebhookEventTimerStarted)
+	s.Equal(event.TeamID, "team")
+	s.Equal(event.TeamUserID, "user")
+	s.Equal(event.Timer.TaskName, "write docs")
+	s.Equal((<-second.Events).ID, event.ID)
+
+	bus.Unsubscribe(first)
+	_, open := <-first.Events
+	s.False(open)
+	bus.Unsubscribe(first)
+
+	// a subscriber that does not read misses the events over its buffer, the others still get them
+	for i := 0; i < eventSubscriptionBuffer+1; i++ {
+		bus.Fire(models.WebhookEventTimerUpdated, timer)
+	}
+	s.Equal(len(second.Events), eventSubscriptionBuffer)
+}
+
+func TestCanSeeTimerEvent(t *testing.T) {
+	s := is.New(t)
+
+	member := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleMember}
+	manager := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleManager, ManagedProjectIDs: []string{"project"}}
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleAdmin}
+	stranger := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "other", Role: models.RoleAdmin}
+
+	event := &models.TimerEvent{TeamID: "team", TeamUserID: member.ID.Hex(), Timer: &models.Timer{ProjectID: "project"}}
+	s.True(CanSeeTimerEvent(member, event))
+	s.True(CanSeeTimerEvent(manager, event))
+	s.True(CanSeeTimerEvent(admin, event))
+	s.False(CanSeeTimerEvent(stranger, event))
+
+	event = &models.TimerEvent{TeamID: "team", TeamUserID: admin.ID.Hex(), Timer: &models.Timer{ProjectID: "another"}}
+	s.False(CanSeeTimerEvent(member, event))
+	s.False(CanSeeTimerEvent(manager, event))
+}
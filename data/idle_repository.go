@@ -0,0 +1,72 @@
+package data
+
+import (
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type IdleRepository struct {
+	session    *mgo.Session
+	collection *mgo.Collection
+}
+
+func NewIdleRepository(session *mgo.Session) *IdleRepository {
+	return &IdleRepository{
+		session:    session,
+		collection: session.DB("").C(utils.MongoCollectionIdle),
+	}
+}
+
+func (r *IdleRepository) findByID(id string) (*models.IdleInterval, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, nil
+	}
+
+	result := &models.IdleInterval{}
+	err := r.collection.FindId(bson.ObjectIdHex(id)).One(result)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+// findPendingByUser returns the intervals the user has not decided about yet, the earliest first
+func (r *IdleRepository) findPendingByUser(userID string) ([]*models.IdleInterval, error) {
+	result := []*models.IdleInterval{}
+	err := r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"status":       models.IdleStatusPending,
+	}).Sort("started_at").All(&result)
+	return result, err
+}
+
+func (r *IdleRepository) create(interval *models.IdleInterval) error {
+	interval.ID = bson.NewObjectId()
+	return r.collection.Insert(interval)
+}
+
+// claimPending marks the pending interval resolved, so the interval is resolved once even if
+// the user answers from Slack and the frontend at the same time. Nil means it is resolved already
+func (r *IdleRepository) claimPending(id bson.ObjectId, status string, now time.Time) (*models.IdleInterval, error) {
+	result := &models.IdleInterval{}
+	_, err := r.collection.Find(bson.M{
+		"_id":    id,
+		"status": models.IdleStatusPending,
+	}).Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"status": status, "resolved_at": now}},
+		ReturnNew: true,
+	}, result)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+func (r *IdleRepository) update(interval *models.IdleInterval) error {
+	return r.collection.UpdateId(interval.ID, interval)
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// IdleThreshold - a heartbeat gap longer than this on a running timer is recorded as idle time
+const IdleThreshold = 10 * time.Minute
+
+// Answers to an idle interval
+const (
+	IdleActionKeep    = "keep"
+	IdleActionDiscard = "discard"
+	IdleActionSplit   = "split"
+)
+
+var idleActionStatuses = map[string]string{
+	IdleActionKeep:    models.IdleStatusKept,
+	IdleActionDiscard: models.IdleStatusDiscarded,
+	IdleActionSplit:   models.IdleStatusSplit,
+}
+
+// IdleService - notices gaps in heartbeats the frontend or a desktop helper sends while a timer runs
+// and lets users decide what to do with the time they were away
+type IdleService struct {
+	repository   *IdleRepository
+	timerService *TimerService
+}
+
+// NewIdleService constructs an instance of the service
+func NewIdleService(session *mgo.Session) *IdleService {
+	return &IdleService{
+		repository:   NewIdleRepository(session),
+		timerService: NewTimerService(session),
+	}
+}
+
+// Heartbeat tells the user is active at the moment. If the heartbeat before it on the user's running timer
+// was longer than IdleThreshold ago, the gap is recorded as an idle interval. Returns the pending intervals of the user
+func (s *IdleService) Heartbeat(user *models.TeamUser, now time.Time) ([]*models.IdleInterval, error) {
+	timer, err := s.timerService.repository.findActiveByUser(user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	if timer != nil {
+		previous, err := s.timerService.repository.touchHeartbeat(timer.ID, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if previous != nil && previous.LastHeartbeatAt != nil && now.Sub(*previous.LastHeartbeatAt) > IdleThreshold {
+			err = s.repository.create(&models.IdleInterval{
+				TeamID:       timer.TeamID,
+				TeamUserID:   timer.TeamUserID,
+				TimerID:      timer.ID.Hex(),
+				TaskName:     timer.TaskName,
+				StartedAt:    *previous.LastHeartbeatAt,
+				EndedAt:      now,
+				Status:       models.IdleStatusPending,
+				CreatedAt:    now,
+				ModelVersion: models.ModelVersionIdle,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.Pending(user)
+}
+
+// Pending returns the idle intervals the user has not decided about yet
+func (s *IdleService) Pending(user *models.TeamUser) ([]*models.IdleInterval, error) {
+	return s.repository.findPendingByUser(user.ID.Hex())
+}
+
+// Resolve applies the user's answer to the idle interval: keep leaves the timer as it is,
+// discard takes the idle time off the timer and split moves it to a separate timer of the same task
+func (s *IdleService) Resolve(user *models.TeamUser, id, action string, now time.Time) (*models.IdleInterval, error) {
+	status, ok := idleActionStatuses[action]
+	if !ok {
+		return nil, fmt.Errorf("unknown action `%s`, should be one of keep, discard or split", action)
+	}
+
+	interval, err := s.repository.findByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if interval == nil || interval.TeamUserID != user.ID.Hex() {
+		return nil, errors.New("idle interval not found")
+	}
+
+	var timer *models.Timer
+	if action != IdleActionKeep {
+		timer, err = s.timerService.repository.findByID(interval.TimerID)
+		if err != nil {
+			return nil, err
+		}
+		if timer.DeletedAt != nil {
+			return nil, errors.New("the timer of the idle interval is deleted")
+		}
+		if err := s.timerService.ensureNotLocked(timer.TeamUserID, interval.StartedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	claimed, err := s.repository.claimPending(interval.ID, status, now)
+	if err != nil {
+		return nil, err
+	}
+	if claimed == nil {
+		return nil, errors.New("idle interval is resolved already")
+	}
+
+	if timer == nil {
+		return claimed, nil
+	}
+
+	// the split timer is created before the time is discarded as it is easy to remove if the discard fails
+	var splitTimer *models.Timer
+	if action == IdleActionSplit {
+		if splitTimer, err = s.split(timer, claimed); err != nil {
+			return nil, s.unclaim(claimed, err)
+		}
+	}
+
+	if err := s.discard(user, timer, claimed, now); err != nil {
+		if splitTimer != nil {
+			if removeErr := s.timerService.repository.remove(splitTimer.ID); removeErr != nil {
+				return nil, removeErr
+			}
+		}
+		return nil, s.unclaim(claimed, err)
+	}
+
+	if splitTimer != nil {
+		s.timerService.events.Fire(models.WebhookEventTimerCreated, splitTimer)
+
+		claimed.SplitTimerID = splitTimer.ID.Hex()
+		if err := s.repository.update(claimed); err != nil {
+			return nil, err
+		}
+	}
+	return claimed, nil
+}
+
+// unclaim brings the interval back to pending when its answer could not be applied
+func (s *IdleService) unclaim(interval *models.IdleInterval, cause error) error {
+	interval.Status = models.IdleStatusPending
+	interval.ResolvedAt = nil
+	if err := s.repository.update(interval); err != nil {
+		return err
+	}
+	return cause
+}
+
+// discard takes the idle time off the timer by a negative edit, a running timer gets it applied when it stops
+func (s *IdleService) discard(user *models.TeamUser, timer *models.Timer, interval *models.IdleInterval, now time.Time) error {
+	updated, err := s.timerService.repository.pushEdit(timer.ID, &models.TimeEdit{
+		TeamUserID: user.ID.Hex(),
+		CreatedAt:  now,
+		Seconds:    -idleSeconds(interval),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.timerService.events.Fire(models.WebhookEventTimerUpdated, updated)
+	return nil
+}
+
+// split creates a finished timer of the timer's task that covers the idle interval, Resolve fires its event
+func (s *IdleService) split(timer *models.Timer, interval *models.IdleInterval) (*models.Timer, error) {
+	finishedAt := interval.EndedAt
+	seconds := idleSeconds(interval)
+
+	splitTimer, err := s.timerService.repository.CreateTimer(&models.Timer{
+		ID:                  bson.NewObjectId(),
+		TeamID:              timer.TeamID,
+		ProjectID:           timer.ProjectID,
+		ProjectExternalName: timer.ProjectExternalName,
+		ProjectExternalID:   timer.ProjectExternalID,
+		TeamUserID:          timer.TeamUserID,
+		TeamUserTZOffset:    timer.TeamUserTZOffset,
+		TaskName:            timer.TaskName,
+		TaskHash:            timer.TaskHash,
+		CreatedAt:           interval.StartedAt,
+		FinishedAt:          &finishedAt,
+		Seconds:             seconds,
+		ActualSeconds:       seconds,
+		Tags:                timer.Tags,
+		Issues:              timer.Issues,
+		ModelVersion:        models.ModelVersionTimer,
+	})
+	return splitTimer, err
+}
+
+func idleSeconds(interval *models.IdleInterval) int {
+	return int(interval.EndedAt.Sub(interval.StartedAt).Seconds())
+}
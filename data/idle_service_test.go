@@ -0,0 +1,186 @@
+package data
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestIdleService(t *testing.T) {
+	gosuite.Run(t, &IdleServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *IdleServiceTestSuite) TestHeartbeat(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	// no running timer - nothing to watch
+	intervals, err := s.service.Heartbeat(s.user, now)
+	s.Nil(err)
+	s.Len(intervals, 0)
+
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+
+	intervals, _ = s.service.Heartbeat(s.user, now)
+	s.Len(intervals, 0)
+	intervals, _ = s.service.Heartbeat(s.user, now.Add(5*time.Minute))
+	s.Len(intervals, 0)
+
+	intervals, err = s.service.Heartbeat(s.user, now.Add(30*time.Minute))
+	s.Nil(err)
+	s.Len(intervals, 1)
+	s.Equal(intervals[0].TimerID, timer.ID.Hex())
+	s.Equal(intervals[0].TaskName, "write docs")
+	s.Equal(intervals[0].Status, models.IdleStatusPending)
+	s.Equal(intervals[0].StartedAt.Unix(), now.Add(5*time.Minute).Unix())
+	s.Equal(intervals[0].EndedAt.Unix(), now.Add(30*time.Minute).Unix())
+
+	// heartbeats of the next timer are not compared with the ones of the stopped timer
+	s.service.timerService.StopTimer(timer)
+	s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "review")
+	intervals, _ = s.service.Heartbeat(s.user, now.Add(time.Hour))
+	s.Len(intervals, 1)
+}
+
+func (s *IdleServiceTestSuite) TestResolveDiscard(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	s.service.Heartbeat(s.user, now)
+	intervals, _ := s.service.Heartbeat(s.user, now.Add(25*time.Minute))
+	s.Len(intervals, 1)
+
+	interval, err := s.service.Resolve(s.user, intervals[0].ID.Hex(), IdleActionDiscard, now)
+	s.Nil(err)
+	s.Equal(interval.Status, models.IdleStatusDiscarded)
+	s.NotNil(interval.ResolvedAt)
+
+	// the running timer gets the discarded time off when it stops
+	timer, _ = s.service.timerService.FindByID(timer.ID.Hex())
+	s.Len(timer.Edits, 1)
+	s.Equal(timer.Edits[0].Seconds, -25*60)
+	s.service.timerService.StopTimer(timer)
+	s.Equal(timer.Seconds, timer.ActualSeconds-25*60)
+
+	_, err = s.service.Resolve(s.user, interval.ID.Hex(), IdleActionKeep, now)
+	s.Err(err)
+	s.Equal(err.Error(), "idle interval is resolved already")
+
+	pending, _ := s.service.Pending(s.user)
+	s.Len(pending, 0)
+}
+
+func (s *IdleServiceTestSuite) TestResolveSplit(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	s.service.Heartbeat(s.user, now)
+	intervals, _ := s.service.Heartbeat(s.user, now.Add(40*time.Minute))
+	s.service.timerService.StopTimer(timer)
+
+	interval, err := s.service.Resolve(s.user, intervals[0].ID.Hex(), IdleActionSplit, now)
+	s.Nil(err)
+	s.Equal(interval.Status, models.IdleStatusSplit)
+
+	timer, _ = s.service.timerService.FindByID(timer.ID.Hex())
+	s.Equal(timer.Seconds, timer.ActualSeconds-40*60)
+
+	splitTimer, err := s.service.timerService.FindByID(interval.SplitTimerID)
+	s.Nil(err)
+	s.Equal(splitTimer.TaskName, "write docs")
+	s.Equal(splitTimer.Seconds, 40*60)
+	s.Equal(splitTimer.CreatedAt.Unix(), interval.StartedAt.Unix())
+	s.Equal(splitTimer.FinishedAt.Unix(), interval.EndedAt.Unix())
+}
+
+func (s *IdleServiceTestSuite) TestDiscardKeepsStoppedTimer(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	s.service.Heartbeat(s.user, now)
+	intervals, _ := s.service.Heartbeat(s.user, now.Add(15*time.Minute))
+
+	// the timer is stopped after Resolve has loaded it as running
+	running := *timer
+	s.Nil(s.service.timerService.StopTimer(timer))
+	claimed, err := s.service.repository.claimPending(intervals[0].ID, models.IdleStatusDiscarded, now)
+	s.Nil(err)
+	s.Nil(s.service.discard(s.user, &running, claimed, now))
+
+	timer, _ = s.service.timerService.FindByID(timer.ID.Hex())
+	s.NotNil(timer.FinishedAt)
+	s.Len(timer.Edits, 1)
+	s.Equal(timer.Seconds, timer.ActualSeconds-15*60)
+}
+
+func (s *IdleServiceTestSuite) TestResolveErrors(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	s.service.Heartbeat(s.user, now)
+	intervals, _ := s.service.Heartbeat(s.user, now.Add(time.Hour))
+
+	_, err := s.service.Resolve(s.user, intervals[0].ID.Hex(), "ignore", now)
+	s.Err(err)
+	s.Equal(err.Error(), "unknown action `ignore`, should be one of keep, discard or split")
+
+	other := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), SlackUserInfo: &slack.User{}}
+	_, err = s.service.Resolve(other, intervals[0].ID.Hex(), IdleActionKeep, now)
+	s.Err(err)
+	s.Equal(err.Error(), "idle interval not found")
+
+	interval, err := s.service.Resolve(s.user, intervals[0].ID.Hex(), IdleActionKeep, now)
+	s.Nil(err)
+	s.Equal(interval.Status, models.IdleStatusKept)
+}
+
+type IdleServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *IdleService
+	team    *models.Team
+	project *models.Project
+	user    *models.TeamUser
+}
+
+func (s *IdleServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+}
+
+func (s *IdleServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *IdleServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.service = NewIdleService(s.session)
+
+	teamRepository := NewTeamRepository(s.session)
+	team, _ := teamRepository.CreateTeam("team-id", "team-name")
+	teamRepository.AddProject(team, "channel-id", "channel-name")
+	s.team, _ = teamRepository.FindByID(team.ID.Hex())
+	s.project = s.team.Projects[0]
+
+	s.user, _ = NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "user-id",
+		SlackUserInfo:  &slack.User{},
+	})
+}
+
+func (s *IdleServiceTestSuite) TearDown() {}
@@ -0,0 +1,192 @@
+package data
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2"
+)
+
+// ImportService - imports the history of time entries exported from other time trackers
+type ImportService struct {
+	teamRepository  *TeamRepository
+	timerRepository *TimerRepository
+	userRepository  *UserRepository
+	timerService    *TimerService
+}
+
+// NewImportService constructs an instance of the service
+func NewImportService(session *mgo.Session) *ImportService {
+	return &ImportService{
+		teamRepository:  NewTeamRepository(session),
+		timerRepository: NewTimerRepository(session),
+		userRepository:  NewUserRepository(session),
+		timerService:    NewTimerService(session),
+	}
+}
+
+// importCandidate is an entry mapped to a team user which is going to become a timer
+type importCandidate struct {
+	entry       *importEntry
+	user        *models.TeamUser
+	projectName string
+	sourceID    string
+}
+
+// Import creates finished timers out of a CSV export of the source tracker. Users are mapped by Slack email
+// or name, projects by channel name; unknown projects are created as non-channel ones. Entries of unmapped
+// users, entries imported before and entries of the time locked by approved timesheets are skipped.
+// The dry run only reports what would be imported
+func (s *ImportService) Import(team *models.Team, source string, r io.Reader, dryRun bool) (*models.ImportReport, error) {
+	entries, parseErrors, err := parseImportCSV(source, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{
+		Source:        source,
+		DryRun:        dryRun,
+		Entries:       len(entries),
+		UnmappedUsers: []string{},
+		NewProjects:   []string{},
+		Duplicates:    []string{},
+		Locked:        []string{},
+		Errors:        parseErrors,
+	}
+
+	users, err := s.userRepository.FindByTeamID(team.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	usersIndex := importUsersIndex(users)
+
+	candidates := []*importCandidate{}
+	sourceIDs := []string{}
+	unmapped := map[string]bool{}
+	for _, entry := range entries {
+		user := usersIndex[strings.ToLower(entry.UserEmail)]
+		if user == nil {
+			user = usersIndex[strings.ToLower(entry.UserName)]
+		}
+		if user == nil {
+			name := firstNotBlank(entry.UserEmail, entry.UserName)
+			if !unmapped[name] {
+				unmapped[name] = true
+				report.UnmappedUsers = append(report.UnmappedUsers, name)
+			}
+			continue
+		}
+
+		projectName := strings.TrimPrefix(entry.Project, "#")
+		if projectName == "" {
+			report.Errors = append(report.Errors, fmt.Sprintf("row %d: no project", entry.Row))
+			continue
+		}
+
+		candidate := &importCandidate{
+			entry:       entry,
+			user:        user,
+			projectName: projectName,
+			sourceID:    importSourceID(source, user, projectName, entry),
+		}
+		candidates = append(candidates, candidate)
+		sourceIDs = append(sourceIDs, candidate.sourceID)
+	}
+
+	imported, err := s.timerRepository.findSourceIDs(team.ID.Hex(), sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := map[string]*models.Project{}
+	for _, project := range team.Projects {
+		projects[strings.ToLower(project.ExternalProjectName)] = project
+	}
+
+	for _, candidate := range candidates {
+		entry := candidate.entry
+		if imported[candidate.sourceID] {
+			report.Duplicates = append(report.Duplicates, fmt.Sprintf("row %d: %s, %s, %s at %s",
+				entry.Row, candidate.user.ExternalUserName, candidate.projectName, entry.TaskName, entry.Start.Format("2006-01-02 15:04")))
+			continue
+		}
+		imported[candidate.sourceID] = true
+
+		timer := importedTimer(candidate, source)
+		locked, err := s.timerService.IsLocked(candidate.user.ID.Hex(), timer.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			report.Locked = append(report.Locked, fmt.Sprintf("row %d: %s, %s, %s at %s",
+				entry.Row, candidate.user.ExternalUserName, candidate.projectName, entry.TaskName, entry.Start.Format("2006-01-02 15:04")))
+			continue
+		}
+
+		project := projects[strings.ToLower(candidate.projectName)]
+		if project == nil {
+			report.NewProjects = append(report.NewProjects, candidate.projectName)
+			if dryRun {
+				project = &models.Project{ExternalProjectName: candidate.projectName}
+			} else if project, err = s.teamRepository.addNonChannelProject(team, candidate.projectName); err != nil {
+				return nil, err
+			}
+			projects[strings.ToLower(candidate.projectName)] = project
+		}
+
+		report.Imported++
+		if dryRun {
+			continue
+		}
+
+		if _, err = s.timerService.createFinishedTimer(candidate.user, team, project, timer); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// importedTimer is the timer of the entry, the start of the entry is in the user's timezone.
+// The rest of the timer is filled in by TimerService.createFinishedTimer
+func importedTimer(candidate *importCandidate, source string) *models.Timer {
+	entry := candidate.entry
+	return &models.Timer{
+		TaskName:      entry.TaskName,
+		CreatedAt:     entry.Start.Add(time.Duration(tzOffsetOf(candidate.user)) * time.Second * -1),
+		Seconds:       entry.Seconds,
+		ActualSeconds: entry.Seconds,
+		Edits:         []*models.TimeEdit{},
+		Tags:          entry.Tags,
+		Source:        source,
+		SourceID:      candidate.sourceID,
+	}
+}
+
+// importUsersIndex maps lowercased Slack emails and names to the team users
+func importUsersIndex(users []*models.TeamUser) map[string]*models.TeamUser {
+	index := map[string]*models.TeamUser{}
+	for _, user := range users {
+		keys := []string{user.ExternalUserName}
+		if user.SlackUserInfo != nil {
+			keys = append(keys, user.SlackUserInfo.Profile.Email, user.SlackUserInfo.RealName, user.SlackUserInfo.Name)
+		}
+		for _, key := range keys {
+			if key != "" {
+				index[strings.ToLower(key)] = user
+			}
+		}
+	}
+	return index
+}
+
+// importSourceID hashes the user, project, task, start and duration in minutes of the entry to recognise re-imports
+func importSourceID(source string, user *models.TeamUser, projectName string, entry *importEntry) string {
+	seed := fmt.Sprintf("%s|%s|%s|%s|%s|%d", source, user.ID.Hex(), strings.ToLower(projectName),
+		entry.TaskName, entry.Start.Format(time.RFC3339), (entry.Seconds+30)/60)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(seed)))[0:16]
+}
@@ -0,0 +1,172 @@
+package data
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/tylerb/is.v1"
+)
+
+const togglExport = "User,Email,Project,Description,Start date,Start time,Duration,Tags\n" +
+	"Alice,alice@example.com,general,Fix login,2016-12-05,10:00:00,01:00:00,\n" +
+	"Alice,alice@example.com,#general,Fix login,2016-12-05,10:00:00,01:00:00,\n" +
+	"Alice,alice@example.com,marketing,Landing page,2016-12-06,12:00:00,00:30:00,\n" +
+	"Mallory,mallory@example.com,general,Review,2016-12-06,12:00:00,00:30:00,\n"
+
+func TestImportService(t *testing.T) {
+	gosuite.Run(t, &ImportServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *ImportServiceTestSuite) TestImportDryRun(t *testing.T) {
+	team, alice := s.createTeam()
+
+	report, err := s.service.Import(team, models.TimerSourceToggl, strings.NewReader(togglExport), true)
+	s.Nil(err)
+	s.True(report.DryRun)
+	s.Equal(report.Entries, 4)
+	s.Equal(report.Imported, 2)
+	s.Equal(report.UnmappedUsers, []string{"mallory@example.com"})
+	s.Equal(report.NewProjects, []string{"marketing"})
+	s.Len(report.Duplicates, 1)
+
+	timers, _ := NewTimerRepository(s.session).findUserTasksByRange(alice.ID.Hex(), utils.PT("2016 Dec 01 00:00:00"), utils.PT("2016 Dec 31 00:00:00"))
+	s.Len(timers, 0)
+
+	team, _ = NewTeamRepository(s.session).FindByID(team.ID.Hex())
+	s.Len(team.Projects, 1)
+}
+
+func (s *ImportServiceTestSuite) TestImport(t *testing.T) {
+	team, alice := s.createTeam()
+
+	report, err := s.service.Import(team, models.TimerSourceToggl, strings.NewReader(togglExport), false)
+	s.Nil(err)
+	s.Equal(report.Imported, 2)
+
+	timers, _ := NewTimerRepository(s.session).findUserTasksByRange(alice.ID.Hex(), utils.PT("2016 Dec 01 00:00:00"), utils.PT("2016 Dec 31 00:00:00"))
+	s.Len(timers, 2)
+
+	// Alice is 3 hours ahead of UTC
+	s.Equal(timers[0].CreatedAt, utils.PT("2016 Dec 05 07:00:00"))
+	s.Equal(*timers[0].FinishedAt, utils.PT("2016 Dec 05 08:00:00"))
+	s.Equal(timers[0].Seconds, 60*60)
+	s.Equal(timers[0].ProjectID, team.Projects[0].ID.Hex())
+	s.Equal(timers[0].Source, models.TimerSourceToggl)
+	s.NotEqual(timers[0].SourceID, "")
+
+	team, _ = NewTeamRepository(s.session).FindByID(team.ID.Hex())
+	s.Len(team.Projects, 2)
+	s.Equal(team.Projects[1].ExternalProjectName, "marketing")
+	s.Equal(team.Projects[1].ExternalProjectID, "")
+	s.Equal(timers[1].ProjectID, team.Projects[1].ID.Hex())
+
+	// importing the same export again changes nothing
+	report, err = s.service.Import(team, models.TimerSourceToggl, strings.NewReader(togglExport), false)
+	s.Nil(err)
+	s.Equal(report.Imported, 0)
+	s.Len(report.Duplicates, 3)
+	s.Len(report.NewProjects, 0)
+}
+
+func (s *ImportServiceTestSuite) TestImportSkipsLockedTime(t *testing.T) {
+	team, alice := s.createTeam()
+
+	// Alice's Monday, Dec 5 is approved
+	s.Nil(NewTimesheetRepository(s.session).save(&models.Timesheet{
+		TeamID:     team.ID.Hex(),
+		TeamUserID: alice.ID.Hex(),
+		WeekStart:  utils.PT("2016 Dec 05 00:00:00"),
+		StartsAt:   utils.PT("2016 Dec 04 21:00:00"),
+		EndsAt:     utils.PT("2016 Dec 05 21:00:00"),
+		Status:     models.TimesheetStatusApproved,
+	}))
+
+	report, err := s.service.Import(team, models.TimerSourceToggl, strings.NewReader(togglExport), false)
+	s.Nil(err)
+	s.Equal(report.Imported, 1)
+	s.Len(report.Locked, 1)
+	s.True(strings.HasPrefix(report.Locked[0], "row 2:"))
+
+	timers, _ := NewTimerRepository(s.session).findUserTasksByRange(alice.ID.Hex(), utils.PT("2016 Dec 01 00:00:00"), utils.PT("2016 Dec 31 00:00:00"))
+	s.Len(timers, 1)
+	s.Equal(timers[0].TaskName, "Landing page")
+}
+
+func (s *ImportServiceTestSuite) TestImportUserWithoutSlackInfo(t *testing.T) {
+	team, _ := s.createTeam()
+	bob, err := NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:           team.ID.Hex(),
+		ExternalUserID:   "bob",
+		ExternalUserName: "bob",
+	})
+	s.Nil(err)
+
+	export := "User,Email,Project,Description,Start date,Start time,Duration,Tags\n" +
+		"Bob,,general,Deploy,2016-12-05,10:00:00,00:30:00,\n"
+	report, err := s.service.Import(team, models.TimerSourceToggl, strings.NewReader(export), false)
+	s.Nil(err)
+	s.Equal(report.Imported, 1)
+
+	// his time is taken as UTC
+	timers, _ := NewTimerRepository(s.session).findUserTasksByRange(bob.ID.Hex(), utils.PT("2016 Dec 01 00:00:00"), utils.PT("2016 Dec 31 00:00:00"))
+	s.Len(timers, 1)
+	s.Equal(timers[0].CreatedAt, utils.PT("2016 Dec 05 10:00:00"))
+}
+
+func (s *ImportServiceTestSuite) createTeam() (*models.Team, *models.TeamUser) {
+	teamRepository := NewTeamRepository(s.session)
+	team, err := teamRepository.CreateTeam("team-id", "team-name")
+	s.Nil(err)
+	s.Nil(teamRepository.AddProject(team, "channel-id", "general"))
+	team, _ = teamRepository.FindByID(team.ID.Hex())
+
+	alice, err := NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         team.ID.Hex(),
+		ExternalUserID: "alice",
+		SlackUserInfo: &slack.User{
+			TZOffset: 10800,
+			Profile:  slack.UserProfile{Email: "alice@example.com"},
+		},
+	})
+	s.Nil(err)
+
+	return team, alice
+}
+
+type ImportServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *ImportService
+}
+
+func (s *ImportServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.service = NewImportService(s.session)
+}
+
+func (s *ImportServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *ImportServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+}
+
+func (s *ImportServiceTestSuite) TearDown() {}
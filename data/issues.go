@@ -0,0 +1,57 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+// ExtractIssues finds the references to issue trackers in the task name using the team's patterns.
+// Every key is reported once, in the order it appears in the task name
+func ExtractIssues(patterns []*models.IssuePattern, taskName string) []*models.IssueReference {
+	result := []*models.IssueReference{}
+	seen := map[string]bool{}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			continue
+		}
+
+		for _, match := range re.FindAllStringSubmatch(taskName, -1) {
+			key := match[0]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			url := strings.Replace(pattern.URL, "{key}", key, -1)
+			for i, name := range re.SubexpNames() {
+				if name != "" {
+					url = strings.Replace(url, "{"+name+"}", match[i], -1)
+				}
+			}
+
+			result = append(result, &models.IssueReference{Key: key, URL: url})
+		}
+	}
+
+	return result
+}
+
+func validateIssuePatterns(patterns []*models.IssuePattern) error {
+	for _, pattern := range patterns {
+		if pattern.Pattern == "" {
+			return fmt.Errorf("issue pattern can not be blank")
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			return fmt.Errorf("wrong issue pattern `%s`: %s", pattern.Pattern, err)
+		}
+		if !strings.HasPrefix(pattern.URL, "http://") && !strings.HasPrefix(pattern.URL, "https://") {
+			return fmt.Errorf("wrong issue url `%s`", pattern.URL)
+		}
+	}
+	return nil
+}
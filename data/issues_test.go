@@ -0,0 +1,40 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/tylerb/is.v1"
+)
+
+var testIssuePatterns = []*models.IssuePattern{
+	{Pattern: `\b[A-Z][A-Z0-9]+-\d+\b`, URL: "https://acme.atlassian.net/browse/{key}"},
+	{Pattern: `(?P<repo>[\w.-]+/[\w.-]+)#(?P<number>\d+)`, URL: "https://github.com/{repo}/issues/{number}"},
+}
+
+func TestExtractIssues(t *testing.T) {
+	s := is.New(t)
+
+	issues := ExtractIssues(testIssuePatterns, "PROJ-123 fix login, see cleverua/tuna#45 and PROJ-123")
+	s.Len(issues, 2)
+	s.Equal(issues[0].Key, "PROJ-123")
+	s.Equal(issues[0].URL, "https://acme.atlassian.net/browse/PROJ-123")
+	s.Equal(issues[1].Key, "cleverua/tuna#45")
+	s.Equal(issues[1].URL, "https://github.com/cleverua/tuna/issues/45")
+
+	s.Len(ExtractIssues(testIssuePatterns, "fix login"), 0)
+	s.Len(ExtractIssues(nil, "PROJ-123 fix login"), 0)
+}
+
+func TestValidateIssuePatterns(t *testing.T) {
+	s := is.New(t)
+
+	s.Nil(validateIssuePatterns(testIssuePatterns))
+
+	err := validateIssuePatterns([]*models.IssuePattern{{Pattern: "PROJ-(", URL: "https://example.com/{key}"}})
+	s.Err(err)
+
+	err = validateIssuePatterns([]*models.IssuePattern{{Pattern: "PROJ-\\d+", URL: "example.com/{key}"}})
+	s.Err(err)
+	s.Equal(err.Error(), "wrong issue url `example.com/{key}`")
+}
@@ -0,0 +1,83 @@
+package data
+
+import (
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type MeetingRepository struct {
+	session    *mgo.Session
+	collection *mgo.Collection
+}
+
+func NewMeetingRepository(session *mgo.Session) *MeetingRepository {
+	return &MeetingRepository{
+		session:    session,
+		collection: session.DB("").C(utils.MongoCollectionMeetings),
+	}
+}
+
+func (r *MeetingRepository) findPendingByUser(userID string) ([]*models.MeetingProposal, error) {
+	result := []*models.MeetingProposal{}
+	err := r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"status":       models.MeetingProposalPending,
+	}).Sort("starts_at").All(&result)
+	return result, err
+}
+
+// findPendingByIDs returns the user's pending proposals out of the given ones, others are ignored
+func (r *MeetingRepository) findPendingByIDs(userID string, proposalIDs []string) ([]*models.MeetingProposal, error) {
+	ids := []bson.ObjectId{}
+	for _, id := range proposalIDs {
+		if bson.IsObjectIdHex(id) {
+			ids = append(ids, bson.ObjectIdHex(id))
+		}
+	}
+
+	result := []*models.MeetingProposal{}
+	err := r.collection.Find(bson.M{
+		"_id":          bson.M{"$in": ids},
+		"team_user_id": userID,
+		"status":       models.MeetingProposalPending,
+	}).Sort("starts_at").All(&result)
+	return result, err
+}
+
+// exists tells if the proposal has been made already, the same way the unique index tells it on create
+func (r *MeetingRepository) exists(proposal *models.MeetingProposal) (bool, error) {
+	count, err := r.collection.Find(bson.M{
+		"team_user_id": proposal.TeamUserID,
+		"event_uid":    proposal.EventUID,
+		"starts_at":    proposal.StartsAt,
+	}).Count()
+	return count > 0, err
+}
+
+// claimPending moves the pending proposal to the status, so the proposal becomes a timer once even if
+// it is accepted twice at the same time. Nil means it is not pending anymore
+func (r *MeetingRepository) claimPending(id bson.ObjectId, status string) (*models.MeetingProposal, error) {
+	result := &models.MeetingProposal{}
+	_, err := r.collection.Find(bson.M{
+		"_id":    id,
+		"status": models.MeetingProposalPending,
+	}).Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"status": status}},
+		ReturnNew: true,
+	}, result)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+func (r *MeetingRepository) create(proposal *models.MeetingProposal) error {
+	return r.collection.Insert(proposal)
+}
+
+func (r *MeetingRepository) update(proposal *models.MeetingProposal) error {
+	return r.collection.UpdateId(proposal.ID, proposal)
+}
@@ -0,0 +1,223 @@
+package data
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MeetingService - turns the events of uploaded calendars into timers the attendees review and accept
+type MeetingService struct {
+	repository     *MeetingRepository
+	userRepository *UserRepository
+	timerService   *TimerService
+}
+
+// NewMeetingService constructs an instance of the service
+func NewMeetingService(session *mgo.Session) *MeetingService {
+	return &MeetingService{
+		repository:     NewMeetingRepository(session),
+		userRepository: NewUserRepository(session),
+		timerService:   NewTimerService(session),
+	}
+}
+
+// ProposeFromCalendar reads an ICS file and proposes a timer to every team user attending its past events.
+// Attendees are matched by Slack profile email, the same event is never proposed to a user twice.
+// Uploaders who may not import the team's data only get the proposals of their own and learn nothing
+// about the other attendees, the command line import passes no uploader. A dry run only reports what would be proposed
+func (s *MeetingService) ProposeFromCalendar(uploader *models.TeamUser, team *models.Team, r io.Reader, now time.Time, dryRun bool) (*models.MeetingProposalsReport, error) {
+	events, err := utils.ParseICalendar(r)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.userRepository.FindByTeamID(team.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	everyone := uploader == nil || Can(uploader, PermissionImportData)
+
+	usersByEmail := map[string]*models.TeamUser{}
+	for _, user := range users {
+		if !everyone && user.ID != uploader.ID {
+			continue
+		}
+		if user.SlackUserInfo != nil && user.SlackUserInfo.Profile.Email != "" {
+			usersByEmail[strings.ToLower(user.SlackUserInfo.Profile.Email)] = user
+		}
+	}
+
+	report := &models.MeetingProposalsReport{DryRun: dryRun, Events: len(events), UnmatchedAttendees: []string{}}
+	unmatched := map[string]bool{}
+
+	for _, event := range events {
+		// all-day events are not meetings but holidays, vacations and the like
+		if event.AllDay {
+			continue
+		}
+
+		proposedTo := map[string]bool{}
+		for _, email := range event.Attendees {
+			user := usersByEmail[email]
+			if user == nil {
+				if everyone && !unmatched[email] {
+					unmatched[email] = true
+					report.UnmatchedAttendees = append(report.UnmatchedAttendees, email)
+				}
+				continue
+			}
+			if proposedTo[user.ID.Hex()] {
+				continue
+			}
+			proposedTo[user.ID.Hex()] = true
+
+			proposal := meetingProposal(team, user, event, now)
+			if proposal == nil {
+				continue
+			}
+
+			if dryRun {
+				exists, err := s.repository.exists(proposal)
+				if err != nil {
+					return nil, err
+				}
+				if exists {
+					report.AlreadyProposed++
+				} else {
+					report.Proposed++
+				}
+				continue
+			}
+
+			err = s.repository.create(proposal)
+			if mgo.IsDup(err) {
+				report.AlreadyProposed++
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			report.Proposed++
+		}
+	}
+
+	return report, nil
+}
+
+// Proposals returns the user's proposals waiting for a review
+func (s *MeetingService) Proposals(user *models.TeamUser) ([]*models.MeetingProposal, error) {
+	return s.repository.findPendingByUser(user.ID.Hex())
+}
+
+// Accept turns the user's proposals into finished timers of the project
+func (s *MeetingService) Accept(user *models.TeamUser, team *models.Team, proposalIDs []string, projectID string) ([]*models.Timer, error) {
+	project := findProjectByID(team, projectID)
+	if user.TeamID != team.ID.Hex() || project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	proposals, err := s.repository.findPendingByIDs(user.ID.Hex(), proposalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	timers := []*models.Timer{}
+	for _, proposal := range proposals {
+		if err = s.timerService.ensureNotLocked(user.ID.Hex(), proposal.StartsAt); err != nil {
+			return timers, err
+		}
+
+		claimed, err := s.repository.claimPending(proposal.ID, models.MeetingProposalAccepted)
+		if err != nil {
+			return timers, err
+		}
+		if claimed == nil {
+			continue
+		}
+		proposal = claimed
+
+		timer, err := s.timerService.createFinishedTimer(user, team, project, &models.Timer{
+			TaskName:      proposal.Summary,
+			CreatedAt:     proposal.StartsAt,
+			Seconds:       proposal.Seconds,
+			ActualSeconds: proposal.Seconds,
+			Edits:         []*models.TimeEdit{},
+			Source:        models.TimerSourceMeeting,
+			SourceID:      proposal.EventUID,
+		})
+		if err != nil {
+			// back to pending, so that it can be accepted again
+			proposal.Status = models.MeetingProposalPending
+			if updateErr := s.repository.update(proposal); updateErr != nil {
+				return timers, updateErr
+			}
+			return timers, err
+		}
+		timers = append(timers, timer)
+
+		proposal.TimerID = timer.ID.Hex()
+		if err = s.repository.update(proposal); err != nil {
+			return timers, err
+		}
+	}
+
+	return timers, nil
+}
+
+// Dismiss rejects the user's proposals so they do not show up again
+func (s *MeetingService) Dismiss(user *models.TeamUser, proposalIDs []string) error {
+	proposals, err := s.repository.findPendingByIDs(user.ID.Hex(), proposalIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, proposal := range proposals {
+		if _, err = s.repository.claimPending(proposal.ID, models.MeetingProposalDismissed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// meetingProposal builds the user's proposal for the event, events which have not finished yet are not proposed
+func meetingProposal(team *models.Team, user *models.TeamUser, event *utils.ICalEvent, now time.Time) *models.MeetingProposal {
+	startsAt, endsAt := event.Start, event.End
+	if event.Floating && user.SlackUserInfo != nil {
+		// floating times are the wall clock of the attendee
+		offset := time.Duration(user.SlackUserInfo.TZOffset) * time.Second
+		startsAt, endsAt = startsAt.Add(-offset), endsAt.Add(-offset)
+	}
+
+	seconds := int(endsAt.Sub(startsAt).Seconds())
+	if seconds <= 0 || endsAt.After(now) {
+		return nil
+	}
+
+	uid := event.UID
+	if uid == "" {
+		uid = fmt.Sprintf("%x", sha256.Sum256([]byte(event.Summary+startsAt.String())))[0:16]
+	}
+
+	return &models.MeetingProposal{
+		ID:           bson.NewObjectId(),
+		TeamID:       team.ID.Hex(),
+		TeamUserID:   user.ID.Hex(),
+		EventUID:     uid,
+		Summary:      event.Summary,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		Seconds:      seconds,
+		Status:       models.MeetingProposalPending,
+		CreatedAt:    now,
+		ModelVersion: models.ModelVersionMeeting,
+	}
+}
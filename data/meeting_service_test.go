@@ -0,0 +1,240 @@
+package data
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+const testMeetingsCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:standup-1
+SUMMARY:Standup
+DTSTART:20161205T090000Z
+DTEND:20161205T091500Z
+ORGANIZER:mailto:Alice@example.com
+ATTENDEE;CN=Bob:mailto:bob@example.com
+ATTENDEE;CN=Guest:mailto:guest@example.org
+END:VEVENT
+BEGIN:VEVENT
+UID:retro-1
+SUMMARY:Retro
+DTSTART:20161205T150000
+DURATION:PT1H
+ATTENDEE:mailto:alice@example.com
+END:VEVENT
+BEGIN:VEVENT
+UID:planning-1
+SUMMARY:Planning
+DTSTART:20161207T100000Z
+DTEND:20161207T110000Z
+ATTENDEE:mailto:alice@example.com
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestMeetingService(t *testing.T) {
+	gosuite.Run(t, &MeetingServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *MeetingServiceTestSuite) TestProposeFromCalendar(t *testing.T) {
+	now := utils.PT("2016 Dec 06 10:00:00")
+
+	report, err := s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), now, false)
+	s.Nil(err)
+	s.Equal(report.Events, 3)
+	s.Equal(report.Proposed, 3)
+	s.Equal(report.AlreadyProposed, 0)
+	s.Equal(report.UnmatchedAttendees, []string{"guest@example.org"})
+
+	proposals, err := s.service.Proposals(s.alice)
+	s.Nil(err)
+	s.Len(proposals, 2)
+	s.Equal(proposals[0].Summary, "Standup")
+	s.Equal(proposals[0].Seconds, 15*60)
+	// floating time of the event is the wall clock of the attendee
+	s.Equal(proposals[1].Summary, "Retro")
+	s.Equal(proposals[1].StartsAt, utils.PT("2016 Dec 05 13:00:00"))
+	s.Equal(proposals[1].Seconds, 60*60)
+
+	proposals, _ = s.service.Proposals(s.bob)
+	s.Len(proposals, 1)
+
+	report, err = s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), now, false)
+	s.Nil(err)
+	s.Equal(report.Proposed, 0)
+	s.Equal(report.AlreadyProposed, 3)
+}
+
+func (s *MeetingServiceTestSuite) TestProposeFromCalendarByMember(t *testing.T) {
+	report, err := s.service.ProposeFromCalendar(s.bob, s.team, strings.NewReader(testMeetingsCalendar), utils.PT("2016 Dec 06 10:00:00"), false)
+	s.Nil(err)
+	s.Equal(report.Proposed, 1)
+	s.Equal(report.UnmatchedAttendees, []string{})
+
+	proposals, _ := s.service.Proposals(s.alice)
+	s.Len(proposals, 0)
+	proposals, _ = s.service.Proposals(s.bob)
+	s.Len(proposals, 1)
+
+	// those who import the team's data propose to everybody
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleAdmin}
+	report, err = s.service.ProposeFromCalendar(admin, s.team, strings.NewReader(testMeetingsCalendar), utils.PT("2016 Dec 06 10:00:00"), false)
+	s.Nil(err)
+	s.Equal(report.Proposed, 2)
+	s.Equal(report.AlreadyProposed, 1)
+	s.Equal(report.UnmatchedAttendees, []string{"guest@example.org"})
+}
+
+func (s *MeetingServiceTestSuite) TestProposeFromCalendarDryRun(t *testing.T) {
+	now := utils.PT("2016 Dec 06 10:00:00")
+
+	report, err := s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), now, true)
+	s.Nil(err)
+	s.True(report.DryRun)
+	s.Equal(report.Proposed, 3)
+	s.Equal(report.UnmatchedAttendees, []string{"guest@example.org"})
+
+	proposals, _ := s.service.Proposals(s.alice)
+	s.Len(proposals, 0)
+
+	s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), now, false)
+
+	report, err = s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), now, true)
+	s.Nil(err)
+	s.Equal(report.Proposed, 0)
+	s.Equal(report.AlreadyProposed, 3)
+}
+
+func (s *MeetingServiceTestSuite) TestProposeSkipsAllDayEvents(t *testing.T) {
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:offsite-1\r\n" +
+		"SUMMARY:Offsite\r\n" +
+		"DTSTART;VALUE=DATE:20161205\r\n" +
+		"DTEND;VALUE=DATE:20161206\r\n" +
+		"ATTENDEE:mailto:alice@example.com\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	report, err := s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(calendar), utils.PT("2016 Dec 06 10:00:00"), false)
+	s.Nil(err)
+	s.Equal(report.Events, 1)
+	s.Equal(report.Proposed, 0)
+
+	proposals, _ := s.service.Proposals(s.alice)
+	s.Len(proposals, 0)
+}
+
+func (s *MeetingServiceTestSuite) TestAcceptAndDismiss(t *testing.T) {
+	s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), utils.PT("2016 Dec 06 10:00:00"), false)
+	proposals, _ := s.service.Proposals(s.alice)
+	bobProposals, _ := s.service.Proposals(s.bob)
+
+	_, err := s.service.Accept(s.alice, s.team, []string{proposals[0].ID.Hex()}, "unknown")
+	s.Err(err)
+
+	// proposals of other users are ignored
+	timers, err := s.service.Accept(s.alice, s.team, []string{proposals[0].ID.Hex(), bobProposals[0].ID.Hex()}, s.project.ID.Hex())
+	s.Nil(err)
+	s.Len(timers, 1)
+	s.Equal(timers[0].TaskName, "Standup")
+	s.Equal(timers[0].Seconds, 15*60)
+	s.Equal(timers[0].Source, models.TimerSourceMeeting)
+	s.Equal(timers[0].SourceID, "standup-1")
+	s.Equal(timers[0].TeamUserID, s.alice.ID.Hex())
+	s.Equal(*timers[0].FinishedAt, utils.PT("2016 Dec 05 09:15:00"))
+
+	s.Nil(s.service.Dismiss(s.alice, []string{proposals[1].ID.Hex()}))
+
+	pending, _ := s.service.Proposals(s.alice)
+	s.Len(pending, 0)
+	pending, _ = s.service.Proposals(s.bob)
+	s.Len(pending, 1)
+
+	// accepted proposals are not accepted again
+	timers, err = s.service.Accept(s.alice, s.team, []string{proposals[0].ID.Hex()}, s.project.ID.Hex())
+	s.Nil(err)
+	s.Len(timers, 0)
+}
+
+func (s *MeetingServiceTestSuite) TestClaimPendingProposal(t *testing.T) {
+	s.service.ProposeFromCalendar(nil, s.team, strings.NewReader(testMeetingsCalendar), utils.PT("2016 Dec 06 10:00:00"), false)
+	proposals, _ := s.service.Proposals(s.alice)
+
+	// of two concurrent accepts only the first one claims the proposal and creates a timer
+	claimed, err := s.service.repository.claimPending(proposals[0].ID, models.MeetingProposalAccepted)
+	s.Nil(err)
+	s.Equal(claimed.Status, models.MeetingProposalAccepted)
+
+	claimed, err = s.service.repository.claimPending(proposals[0].ID, models.MeetingProposalAccepted)
+	s.Nil(err)
+	s.Nil(claimed)
+
+	claimed, err = s.service.repository.claimPending(proposals[0].ID, models.MeetingProposalDismissed)
+	s.Nil(err)
+	s.Nil(claimed)
+}
+
+type MeetingServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *MeetingService
+	team    *models.Team
+	project *models.Project
+	alice   *models.TeamUser
+	bob     *models.TeamUser
+}
+
+func (s *MeetingServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.service = NewMeetingService(s.session)
+}
+
+func (s *MeetingServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *MeetingServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	teamRepository := NewTeamRepository(s.session)
+	team, _ := teamRepository.CreateTeam("team-id", "team-name")
+	teamRepository.AddProject(team, "project-id", "general")
+	s.team, _ = teamRepository.FindByID(team.ID.Hex())
+	s.project = s.team.Projects[0]
+
+	userRepository := NewUserRepository(s.session)
+	s.alice, _ = userRepository.Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "alice",
+		SlackUserInfo:  &slack.User{TZOffset: 7200, Profile: slack.UserProfile{Email: "alice@example.com"}},
+	})
+	s.bob, _ = userRepository.Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "bob",
+		SlackUserInfo:  &slack.User{Profile: slack.UserProfile{Email: "Bob@Example.com"}},
+	})
+}
+
+func (s *MeetingServiceTestSuite) TearDown() {}
@@ -40,6 +40,7 @@ func (r *PassRepository) FindActivePassByToken(token string) (*models.Pass, erro
 	return pass, err
 }
 
+// FindActiveByUserID returns the user's unclaimed pass expiring last
 func (r *PassRepository) FindActiveByUserID(userID string) (*models.Pass, error) {
 	pass := &models.Pass{}
 
@@ -47,7 +48,7 @@ func (r *PassRepository) FindActiveByUserID(userID string) (*models.Pass, error)
 		"team_user_id": userID,
 		"expires_at":   bson.M{"$gt": time.Now()},
 		"claimed_at":   nil,
-	}).One(pass)
+	}).Sort("-expires_at").One(pass)
 
 	if err != nil && err == mgo.ErrNotFound {
 		pass = nil
@@ -56,17 +57,69 @@ func (r *PassRepository) FindActiveByUserID(userID string) (*models.Pass, error)
 	return pass, err
 }
 
+// claim marks the pass claimed in one findAndModify, so of the concurrent logins with the same pass only one
+// gets it. Returns nil if there is no such pass, it is expired or claimed already
+func (r *PassRepository) claim(token string, now time.Time) (*models.Pass, error) {
+	pass := &models.Pass{}
+	_, err := r.collection.Find(bson.M{
+		"token":      token,
+		"expires_at": bson.M{"$gt": now},
+		"claimed_at": nil,
+	}).Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"claimed_at": now}},
+		ReturnNew: true,
+	}, pass)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return pass, err
+}
+
+// exists tells whether there is a pass with the token, claimed and expired ones included
+func (r *PassRepository) exists(token string) (bool, error) {
+	count, err := r.collection.Find(bson.M{"token": token}).Count()
+	return count > 0, err
+}
+
+func (r *PassRepository) insertClaim(claim *models.PassClaim) error {
+	return r.session.DB("").C(utils.MongoCollectionClaims).Insert(claim)
+}
+
+// findClaims returns the latest logins of the user
+func (r *PassRepository) findClaims(userID string, limit int) ([]*models.PassClaim, error) {
+	result := []*models.PassClaim{}
+	err := r.session.DB("").C(utils.MongoCollectionClaims).Find(bson.M{
+		"team_user_id": userID,
+	}).Sort("-claimed_at").Limit(limit).All(&result)
+	return result, err
+}
+
+func (r *PassRepository) insertLoginFailure(failure *models.LoginFailure) error {
+	return r.session.DB("").C(utils.MongoCollectionFailures).Insert(failure)
+}
+
+// countLoginFailures counts the failed logins from the IP address made since the date
+func (r *PassRepository) countLoginFailures(ip string, since time.Time) (int, error) {
+	return r.session.DB("").C(utils.MongoCollectionFailures).Find(bson.M{
+		"ip":         ip,
+		"created_at": bson.M{"$gt": since},
+	}).Count()
+}
+
 func (r *PassRepository) removeExpiredPasses() error {
-	return r.collection.Remove(bson.M{
+	_, err := r.collection.RemoveAll(bson.M{
 		"expires_at": bson.M{"$lt": time.Now()},
 		"claimed_at": nil,
 	})
+	return err
 }
 
 func (r *PassRepository) removePassesClaimedBefore(date time.Time) error {
-	return r.collection.Remove(bson.M{
+	_, err := r.collection.RemoveAll(bson.M{
 		"claimed_at": bson.M{"$lt": date},
 	})
+	return err
 }
 
 func (r *PassRepository) Insert(pass *models.Pass) error {
@@ -1,6 +1,8 @@
 package data
 
 import (
+	"errors"
+
 	"github.com/satori/go.uuid"
 	"github.com/cleverua/tuna-timer-api/models"
 	"github.com/cleverua/tuna-timer-api/utils"
@@ -9,6 +11,15 @@ import (
 	"time"
 )
 
+const (
+	// passMinimumLifetime - a pass expiring sooner is not sent again, the user gets a new one
+	passMinimumLifetime = time.Minute
+	claimsLimit         = 50
+)
+
+// ErrTooManyLoginAttempts - the IP address made too many failed logins lately
+var ErrTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+
 type PassService struct {
 	repository *PassRepository
 }
@@ -19,17 +30,63 @@ func NewPassService(session *mgo.Session) *PassService {
 	}
 }
 
+// EnsurePass returns the user's unclaimed pass or a new one. Passes are never prolonged,
+// a pass about to expire is left to expire and a new one is created instead
 func (s *PassService) EnsurePass(team *models.Team, user *models.TeamUser, project *models.Project) (*models.Pass, error) {
 	pass, _ := s.repository.FindActiveByUserID(user.ID.Hex())
 
-	if pass == nil {
+	if pass == nil || pass.ExpiresAt.Sub(time.Now()) < passMinimumLifetime {
 		return s.createPass(team, user, project.ID.Hex())
 	}
+	return pass, nil
+}
 
-	pass.ExpiresAt = time.Now().Add(utils.PassExpiresInMinutes * time.Minute)
-	err := s.repository.update(pass)
+// ClaimPass logs in with the pass: the pass is claimed once and refused afterwards and the login is recorded
+// to the user's log. Returns nil if the pass is unknown, expired or used. Only unknown passes count as failed logins
+// of the IP address, as every link of a Slack message carries the same pass and following an older one is no attack;
+// an address with too many failed logins lately gets ErrTooManyLoginAttempts without the pass being tried
+func (s *PassService) ClaimPass(token, userAgent, ip string, now time.Time) (*models.Pass, error) {
+	failures, err := s.repository.countLoginFailures(ip, now.Add(-utils.LoginFailuresWindowMinutes*time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	if failures >= utils.MaxLoginFailures {
+		return nil, ErrTooManyLoginAttempts
+	}
 
-	return pass, err
+	pass, err := s.repository.claim(token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if pass == nil {
+		known, err := s.repository.exists(token)
+		if err != nil || known {
+			return nil, err
+		}
+
+		return nil, s.repository.insertLoginFailure(&models.LoginFailure{
+			ID:        bson.NewObjectId(),
+			IP:        ip,
+			CreatedAt: now,
+		})
+	}
+
+	return pass, s.repository.insertClaim(&models.PassClaim{
+		ID:           bson.NewObjectId(),
+		TeamID:       pass.TeamID,
+		TeamUserID:   pass.TeamUserID,
+		PassID:       pass.ID.Hex(),
+		UserAgent:    userAgent,
+		IP:           ip,
+		ClaimedAt:    now,
+		ModelVersion: models.ModelVersionPassClaim,
+	})
+}
+
+// Claims returns the latest logins of the user
+func (s *PassService) Claims(user *models.TeamUser) ([]*models.PassClaim, error) {
+	return s.repository.findClaims(user.ID.Hex(), claimsLimit)
 }
 
 func (s *PassService) FindPassByToken(token string) (*models.Pass, error) {
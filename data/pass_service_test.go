@@ -87,7 +87,7 @@ func (s *PassServiceTestSuite) TestEnsurePassExistingOne(t *testing.T) {
 	s.Nil(err)
 	s.NotNil(pass)
 
-	// let's change ExpiresAt for this timer a little bit to be able to assert it is prolonged
+	// the pass is reused but not prolonged
 	pass.ExpiresAt = pass.ExpiresAt.Add(-3 * time.Minute)
 	s.repository.update(pass)
 
@@ -100,9 +100,100 @@ func (s *PassServiceTestSuite) TestEnsurePassExistingOne(t *testing.T) {
 	s.Equal(projectID.Hex(), ensuredPass.ProjectID)
 	s.Equal(teamID.Hex(), ensuredPass.TeamID)
 	s.NotEqual("", ensuredPass.Token)
-	//s.IsType(time.Time{}, ensuredPass.CreatedAt) //todo, find a way to assert this using Is package
 	s.Nil(ensuredPass.ClaimedAt)
-	s.True(utils.PassExpiresInMinutes*time.Minute.Seconds() - ensuredPass.ExpiresAt.Sub(time.Now()).Seconds() < 0.01)
+	s.True(ensuredPass.ExpiresAt.Sub(time.Now()) < 2*time.Minute+time.Second)
+
+	// a pass about to expire is replaced with a new one
+	pass.ExpiresAt = time.Now().Add(30 * time.Second)
+	s.repository.update(pass)
+
+	newPass, err := s.service.EnsurePass(team, user, project)
+	s.Nil(err)
+	s.NotEqual(pass.ID.Hex(), newPass.ID.Hex())
+	s.NotEqual(pass.Token, newPass.Token)
+}
+
+func (s *PassServiceTestSuite) TestClaimPass(t *testing.T) {
+	team := &models.Team{ID: bson.NewObjectId()}
+	user := &models.TeamUser{ID: bson.NewObjectId()}
+
+	pass, err := s.service.createPass(team, user, "project-id")
+	s.Nil(err)
+
+	now := time.Now()
+	claimed, err := s.service.ClaimPass(pass.Token, "browser", "10.0.0.1", now)
+	s.Nil(err)
+	s.NotNil(claimed)
+	s.Equal(pass.ID.Hex(), claimed.ID.Hex())
+	s.NotNil(claimed.ClaimedAt)
+
+	// a pass logs in once
+	claimed, err = s.service.ClaimPass(pass.Token, "browser", "10.0.0.1", now)
+	s.Nil(err)
+	s.Nil(claimed)
+
+	claims, err := s.service.Claims(user)
+	s.Nil(err)
+	s.Len(claims, 1)
+	s.Equal(pass.ID.Hex(), claims[0].PassID)
+	s.Equal(user.ID.Hex(), claims[0].TeamUserID)
+	s.Equal("browser", claims[0].UserAgent)
+	s.Equal("10.0.0.1", claims[0].IP)
+
+	// neither does an expired one
+	expired, err := s.service.createPass(team, user, "project-id")
+	s.Nil(err)
+	claimed, err = s.service.ClaimPass(expired.Token, "browser", "10.0.0.1", now.Add(6*time.Minute))
+	s.Nil(err)
+	s.Nil(claimed)
+}
+
+func (s *PassServiceTestSuite) TestClaimPassTooManyFailures(t *testing.T) {
+	team := &models.Team{ID: bson.NewObjectId()}
+	user := &models.TeamUser{ID: bson.NewObjectId()}
+
+	pass, err := s.service.createPass(team, user, "project-id")
+	s.Nil(err)
+
+	now := time.Now()
+	for i := 0; i < utils.MaxLoginFailures; i++ {
+		claimed, err := s.service.ClaimPass("wrong-token", "browser", "10.0.0.1", now)
+		s.Nil(err)
+		s.Nil(claimed)
+	}
+
+	// even a valid pass is refused from the address now
+	_, err = s.service.ClaimPass(pass.Token, "browser", "10.0.0.1", now)
+	s.Equal(ErrTooManyLoginAttempts, err)
+
+	// but not from another one or after a while
+	claimed, err := s.service.ClaimPass(pass.Token, "browser", "10.0.0.2", now)
+	s.Nil(err)
+	s.NotNil(claimed)
+
+	another, err := s.service.createPass(team, user, "project-id")
+	s.Nil(err)
+	another.ExpiresAt = now.Add(time.Hour)
+	s.Nil(s.repository.update(another))
+	claimed, err = s.service.ClaimPass(another.Token, "browser", "10.0.0.1",
+		now.Add(utils.LoginFailuresWindowMinutes*time.Minute))
+	s.Nil(err)
+	s.NotNil(claimed)
+
+	// used and expired passes are refused but do not count
+	used, err := s.service.createPass(team, user, "project-id")
+	s.Nil(err)
+	claimed, err = s.service.ClaimPass(used.Token, "browser", "10.0.0.3", now)
+	s.Nil(err)
+	s.NotNil(claimed)
+	for i := 0; i < utils.MaxLoginFailures; i++ {
+		claimed, err := s.service.ClaimPass(used.Token, "browser", "10.0.0.3", now)
+		s.Nil(err)
+		s.Nil(claimed)
+	}
+	failures, err := s.repository.countLoginFailures("10.0.0.3", now.Add(-time.Minute))
+	s.Nil(err)
+	s.Equal(0, failures)
 }
 
 func (s *PassServiceTestSuite) TestRemoveStalePasses(t *testing.T) {
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type PomodoroRepository struct {
+	session    *mgo.Session
+	collection *mgo.Collection
+}
+
+func NewPomodoroRepository(session *mgo.Session) *PomodoroRepository {
+	return &PomodoroRepository{
+		session:    session,
+		collection: session.DB("").C(utils.MongoCollectionPomodoros),
+	}
+}
+
+// findRunningByUser returns the interval the user is in at the moment, nil if there is none
+func (r *PomodoroRepository) findRunningByUser(userID string) (*models.Pomodoro, error) {
+	result := &models.Pomodoro{}
+	err := r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"status":       models.PomodoroStatusRunning,
+	}).Sort("-starts_at").One(result)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+// claimDue takes a running interval which is over at the moment and marks it completed,
+// so the interval is processed once even if several jobs run concurrently
+func (r *PomodoroRepository) claimDue(now time.Time) (*models.Pomodoro, error) {
+	result := &models.Pomodoro{}
+	_, err := r.collection.Find(bson.M{
+		"status":  models.PomodoroStatusRunning,
+		"ends_at": bson.M{"$lte": now},
+	}).Sort("ends_at").Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"status": models.PomodoroStatusCompleted}},
+		ReturnNew: true,
+	}, result)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+// countCompletedWork returns how many pomodoros the user has completed since the moment
+func (r *PomodoroRepository) countCompletedWork(userID string, since time.Time) (int, error) {
+	return r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"kind":         models.PomodoroKindWork,
+		"status":       models.PomodoroStatusCompleted,
+		"starts_at":    bson.M{"$gte": since},
+	}).Count()
+}
+
+func (r *PomodoroRepository) create(pomodoro *models.Pomodoro) error {
+	return r.collection.Insert(pomodoro)
+}
+
+func (r *PomodoroRepository) update(pomodoro *models.Pomodoro) error {
+	return r.collection.UpdateId(pomodoro.ID, pomodoro)
+}
@@ -0,0 +1,235 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxPomodoroMinutes limits every configurable pomodoro duration
+const maxPomodoroMinutes = 240
+
+// DefaultPomodoroSettings - the classic 25 minutes of work, 5 minute breaks and a 15 minute break after every fourth pomodoro
+var DefaultPomodoroSettings = models.PomodoroSettings{
+	WorkMinutes:       25,
+	ShortBreakMinutes: 5,
+	LongBreakMinutes:  15,
+	LongBreakEvery:    4,
+}
+
+// PomodoroService - runs pomodoro intervals on top of timers and lets users know when to take a break.
+// A work interval stops its timer when it is over so breaks are never tracked as work
+type PomodoroService struct {
+	repository     *PomodoroRepository
+	timerService   *TimerService
+	userRepository *UserRepository
+	teamRepository *TeamRepository
+	messenger      SlackMessenger
+}
+
+// NewPomodoroService constructs an instance of the service
+func NewPomodoroService(session *mgo.Session) *PomodoroService {
+	return &PomodoroService{
+		repository:     NewPomodoroRepository(session),
+		timerService:   NewTimerService(session),
+		userRepository: NewUserRepository(session),
+		teamRepository: NewTeamRepository(session),
+		messenger:      NewSlackMessenger(),
+	}
+}
+
+// PomodoroSettingsOf returns the user's pomodoro durations falling back to the defaults
+func PomodoroSettingsOf(user *models.TeamUser) *models.PomodoroSettings {
+	if user.PomodoroSettings == nil {
+		settings := DefaultPomodoroSettings
+		return &settings
+	}
+	return user.PomodoroSettings
+}
+
+// UpdateSettings changes the user's pomodoro durations, nil settings bring the defaults back
+func (s *PomodoroService) UpdateSettings(user *models.TeamUser, settings *models.PomodoroSettings) (*models.PomodoroSettings, error) {
+	if settings != nil {
+		for _, minutes := range []int{settings.WorkMinutes, settings.ShortBreakMinutes, settings.LongBreakMinutes} {
+			if minutes <= 0 || minutes > maxPomodoroMinutes {
+				return nil, fmt.Errorf("pomodoro durations must be between 1 and %d minutes", maxPomodoroMinutes)
+			}
+		}
+		if settings.LongBreakEvery <= 0 {
+			return nil, errors.New("long break must come after at least one pomodoro")
+		}
+	}
+
+	user.PomodoroSettings = settings
+	if _, err := s.userRepository.Save(user); err != nil {
+		return nil, err
+	}
+	return PomodoroSettingsOf(user), nil
+}
+
+// Start begins a work interval for the user's running timer. The pomodoro already running for the timer is kept,
+// any other interval of the user is cut short: an unfinished pomodoro is interrupted, a break is over
+func (s *PomodoroService) Start(user *models.TeamUser, timer *models.Timer, now time.Time) (*models.Pomodoro, error) {
+	running, err := s.repository.findRunningByUser(user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	if running != nil {
+		if running.Kind == models.PomodoroKindWork && running.TimerID == timer.ID.Hex() {
+			return running, nil
+		}
+
+		running.Status = models.PomodoroStatusCompleted
+		if running.Kind == models.PomodoroKindWork {
+			running.Status = models.PomodoroStatusInterrupted
+		}
+		running.EndsAt = now
+		if err = s.repository.update(running); err != nil {
+			return nil, err
+		}
+	}
+
+	pomodoro := &models.Pomodoro{
+		ID:           bson.NewObjectId(),
+		TeamID:       timer.TeamID,
+		TeamUserID:   user.ID.Hex(),
+		TimerID:      timer.ID.Hex(),
+		TaskName:     timer.TaskName,
+		Kind:         models.PomodoroKindWork,
+		Status:       models.PomodoroStatusRunning,
+		StartsAt:     now,
+		EndsAt:       now.Add(time.Duration(PomodoroSettingsOf(user).WorkMinutes) * time.Minute),
+		CreatedAt:    now,
+		ModelVersion: models.ModelVersionPomodoro,
+	}
+	return pomodoro, s.repository.create(pomodoro)
+}
+
+// CompletedToday returns the number of pomodoros the user has completed today by his/her timezone
+func (s *PomodoroService) CompletedToday(user *models.TeamUser, now time.Time) (int, error) {
+	return s.repository.countCompletedWork(user.ID.Hex(), userDayStart(user, now))
+}
+
+// CompleteDue finishes every interval which is over at the moment. A finished pomodoro stops its timer
+// at the moment the pomodoro ended and starts a break, the user gets a direct message both when the break starts
+// and when it is over. An interval which fails before its timer is stopped is running again, so the next run retries it
+func (s *PomodoroService) CompleteDue(now time.Time) error {
+	for {
+		pomodoro, err := s.repository.claimDue(now)
+		if err != nil {
+			return err
+		}
+		if pomodoro == nil {
+			return nil
+		}
+
+		if err = s.complete(pomodoro, now); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *PomodoroService) complete(pomodoro *models.Pomodoro, now time.Time) error {
+	user, err := s.userRepository.FindByID(pomodoro.TeamUserID)
+	if err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return s.retry(pomodoro, err)
+	}
+
+	if pomodoro.Kind != models.PomodoroKindWork {
+		s.notify(pomodoro.TeamID, user, fmt.Sprintf(
+			"Your break is over. Run `/timer pomodoro %s` to start the next pomodoro", pomodoro.TaskName))
+		return nil
+	}
+
+	timer, err := s.timerService.FindByID(pomodoro.TimerID)
+	if err != nil && err != mgo.ErrNotFound {
+		return s.retry(pomodoro, err)
+	}
+
+	// the timer has been stopped before the pomodoro was over
+	if err == mgo.ErrNotFound || timer.FinishedAt != nil || timer.DeletedAt != nil {
+		pomodoro.Status = models.PomodoroStatusInterrupted
+		return s.repository.update(pomodoro)
+	}
+
+	timer.Pomodoros++
+	if err = s.timerService.StopTimerAt(timer, pomodoro.EndsAt); err != nil {
+		return s.retry(pomodoro, err)
+	}
+
+	completed, err := s.CompletedToday(user, now)
+	if err != nil {
+		return err
+	}
+
+	settings := PomodoroSettingsOf(user)
+	kind, minutes := models.PomodoroKindShortBreak, settings.ShortBreakMinutes
+	if completed%settings.LongBreakEvery == 0 {
+		kind, minutes = models.PomodoroKindLongBreak, settings.LongBreakMinutes
+	}
+
+	breakInterval := &models.Pomodoro{
+		ID:           bson.NewObjectId(),
+		TeamID:       pomodoro.TeamID,
+		TeamUserID:   pomodoro.TeamUserID,
+		TaskName:     pomodoro.TaskName,
+		Kind:         kind,
+		Status:       models.PomodoroStatusRunning,
+		StartsAt:     pomodoro.EndsAt,
+		EndsAt:       pomodoro.EndsAt.Add(time.Duration(minutes) * time.Minute),
+		CreatedAt:    now,
+		ModelVersion: models.ModelVersionPomodoro,
+	}
+	if err = s.repository.create(breakInterval); err != nil {
+		return err
+	}
+
+	s.notify(pomodoro.TeamID, user, formatPomodoroBreak(pomodoro, completed, kind, minutes))
+	return nil
+}
+
+// retry sets the claimed pomodoro running again, so it is completed by the next run
+func (s *PomodoroService) retry(pomodoro *models.Pomodoro, err error) error {
+	pomodoro.Status = models.PomodoroStatusRunning
+	if updateErr := s.repository.update(pomodoro); updateErr != nil {
+		log.Printf("Failed to set pomodoro %s running again: %s", pomodoro.ID.Hex(), updateErr)
+	}
+	return err
+}
+
+// notify sends a direct message to the user, failures are only logged
+func (s *PomodoroService) notify(teamID string, user *models.TeamUser, text string) {
+	team, err := s.teamRepository.FindByID(teamID)
+	if err != nil {
+		log.Printf("Failed to find team %s to notify about a pomodoro: %s", teamID, err)
+		return
+	}
+
+	if err = s.messenger.PostMessage(team, user.ExternalUserID, text); err != nil {
+		log.Printf("Failed to notify %s about a pomodoro: %s", user.ExternalUserName, err)
+	}
+}
+
+func formatPomodoroBreak(pomodoro *models.Pomodoro, completed int, kind string, minutes int) string {
+	breakName := "break"
+	if kind == models.PomodoroKindLongBreak {
+		breakName = "long break"
+	}
+	return fmt.Sprintf("Pomodoro #%d on *%s* is done, your timer is stopped. Time for a %d minute %s!",
+		completed, pomodoro.TaskName, minutes, breakName)
+}
+
+// userDayStart returns the moment the user's day the `now` belongs to has started
+func userDayStart(user *models.TeamUser, now time.Time) time.Time {
+	offset := time.Duration(user.SlackUserInfo.TZOffset) * time.Second
+	local := now.UTC().Add(offset)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Add(-offset)
+}
@@ -0,0 +1,197 @@
+package data
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestPomodoroService(t *testing.T) {
+	gosuite.Run(t, &PomodoroServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *PomodoroServiceTestSuite) TestPomodoroCycle(t *testing.T) {
+	_, err := s.service.UpdateSettings(s.user, &models.PomodoroSettings{
+		WorkMinutes:       25,
+		ShortBreakMinutes: 5,
+		LongBreakMinutes:  15,
+		LongBreakEvery:    2,
+	})
+	s.Nil(err)
+
+	now := time.Now()
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	pomodoro, err := s.service.Start(s.user, timer, now)
+	s.Nil(err)
+	s.Equal(pomodoro.Kind, models.PomodoroKindWork)
+	s.Equal(pomodoro.EndsAt, now.Add(25*time.Minute))
+
+	// the running pomodoro of the timer is kept
+	again, _ := s.service.Start(s.user, timer, now.Add(time.Minute))
+	s.Equal(again.ID, pomodoro.ID)
+
+	s.Nil(s.service.CompleteDue(now.Add(24 * time.Minute)))
+	s.Len(s.messenger.messages, 0)
+
+	s.Nil(s.service.CompleteDue(now.Add(25 * time.Minute)))
+	s.Len(s.messenger.messages, 1)
+	s.Equal(s.messenger.messages[0].channelID, "user-id")
+	s.Equal(s.messenger.messages[0].text, "Pomodoro #1 on *write docs* is done, your timer is stopped. Time for a 5 minute break!")
+
+	timer, _ = s.service.timerService.FindByID(timer.ID.Hex())
+	s.NotNil(timer.FinishedAt)
+	s.Equal(timer.Pomodoros, 1)
+
+	breakInterval, _ := s.service.repository.findRunningByUser(s.user.ID.Hex())
+	s.Equal(breakInterval.Kind, models.PomodoroKindShortBreak)
+	s.Equal(breakInterval.TimerID, "")
+
+	s.Nil(s.service.CompleteDue(now.Add(30 * time.Minute)))
+	s.Len(s.messenger.messages, 2)
+	s.Equal(s.messenger.messages[1].text, "Your break is over. Run `/timer pomodoro write docs` to start the next pomodoro")
+
+	completed, _ := s.service.CompletedToday(s.user, now)
+	s.Equal(completed, 1)
+
+	timer, _ = s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	s.service.Start(s.user, timer, now.Add(30*time.Minute))
+	s.Nil(s.service.CompleteDue(now.Add(55 * time.Minute)))
+	s.Equal(s.messenger.messages[2].text, "Pomodoro #2 on *write docs* is done, your timer is stopped. Time for a 15 minute long break!")
+
+	tasks, _ := NewTimerRepository(s.session).completedTasksForUser(s.user.ID.Hex(), now.Add(-time.Hour), now.Add(time.Hour))
+	s.Len(tasks, 1)
+	s.Equal(tasks[0].Pomodoros, 2)
+}
+
+func (s *PomodoroServiceTestSuite) TestLateCompletionStopsTimerWhenPomodoroEnds(t *testing.T) {
+	now := time.Now()
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	pomodoro, _ := s.service.Start(s.user, timer, now)
+
+	// the job runs ten minutes late, the break is not tracked
+	s.Nil(s.service.CompleteDue(now.Add(35 * time.Minute)))
+
+	timer, _ = s.service.timerService.FindByID(timer.ID.Hex())
+	s.True(timer.FinishedAt.Equal(pomodoro.EndsAt))
+	s.True(timer.Seconds <= 25*60)
+
+	breakInterval, _ := s.service.repository.findRunningByUser(s.user.ID.Hex())
+	s.True(breakInterval.StartsAt.Equal(pomodoro.EndsAt))
+	s.True(breakInterval.EndsAt.Equal(pomodoro.EndsAt.Add(5 * time.Minute)))
+}
+
+func (s *PomodoroServiceTestSuite) TestRetryKeepsPomodoroRunning(t *testing.T) {
+	now := time.Now()
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	s.service.Start(s.user, timer, now)
+
+	pomodoro, err := s.service.repository.claimDue(now.Add(25 * time.Minute))
+	s.Nil(err)
+	s.Equal(pomodoro.Status, models.PomodoroStatusCompleted)
+
+	s.Equal(s.service.retry(pomodoro, mgo.ErrCursor), mgo.ErrCursor)
+
+	running, _ := s.service.repository.findRunningByUser(s.user.ID.Hex())
+	s.Equal(running.ID, pomodoro.ID)
+}
+
+func (s *PomodoroServiceTestSuite) TestInterruptedPomodoro(t *testing.T) {
+	now := time.Now()
+	timer, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "write docs")
+	first, _ := s.service.Start(s.user, timer, now)
+
+	// a pomodoro for another task interrupts the running one
+	other, _ := s.service.timerService.StartTimer(s.team.ID.Hex(), s.project, s.user, "review")
+	second, err := s.service.Start(s.user, other, now.Add(time.Minute))
+	s.Nil(err)
+	s.NotEqual(second.ID, first.ID)
+
+	s.Nil(s.service.timerService.StopTimer(other))
+	s.Nil(s.service.CompleteDue(now.Add(time.Hour)))
+	s.Len(s.messenger.messages, 0)
+
+	running, _ := s.service.repository.findRunningByUser(s.user.ID.Hex())
+	s.Nil(running)
+
+	completed, _ := s.service.CompletedToday(s.user, now)
+	s.Equal(completed, 0)
+
+	other, _ = s.service.timerService.FindByID(other.ID.Hex())
+	s.Equal(other.Pomodoros, 0)
+}
+
+func (s *PomodoroServiceTestSuite) TestUpdateSettings(t *testing.T) {
+	s.Equal(*PomodoroSettingsOf(s.user), DefaultPomodoroSettings)
+
+	_, err := s.service.UpdateSettings(s.user, &models.PomodoroSettings{WorkMinutes: 0, ShortBreakMinutes: 5, LongBreakMinutes: 15, LongBreakEvery: 4})
+	s.Err(err)
+
+	settings, err := s.service.UpdateSettings(s.user, &models.PomodoroSettings{WorkMinutes: 50, ShortBreakMinutes: 10, LongBreakMinutes: 30, LongBreakEvery: 3})
+	s.Nil(err)
+	s.Equal(settings.WorkMinutes, 50)
+
+	user, _ := NewUserRepository(s.session).FindByID(s.user.ID.Hex())
+	s.Equal(PomodoroSettingsOf(user).LongBreakEvery, 3)
+
+	settings, err = s.service.UpdateSettings(user, nil)
+	s.Nil(err)
+	s.Equal(*settings, DefaultPomodoroSettings)
+}
+
+type PomodoroServiceTestSuite struct {
+	*is.Is
+	env       *utils.Environment
+	session   *mgo.Session
+	service   *PomodoroService
+	messenger *testSlackMessenger
+	team      *models.Team
+	project   *models.Project
+	user      *models.TeamUser
+}
+
+func (s *PomodoroServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+}
+
+func (s *PomodoroServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *PomodoroServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.messenger = &testSlackMessenger{}
+	s.service = NewPomodoroService(s.session)
+	s.service.messenger = s.messenger
+
+	teamRepository := NewTeamRepository(s.session)
+	team, _ := teamRepository.CreateTeam("team-id", "team-name")
+	teamRepository.AddProject(team, "channel-id", "channel-name")
+	s.team, _ = teamRepository.FindByID(team.ID.Hex())
+	s.project = s.team.Projects[0]
+
+	s.user, _ = NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "user-id",
+		SlackUserInfo:  &slack.User{},
+	})
+}
+
+func (s *PomodoroServiceTestSuite) TearDown() {}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"log"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2"
+)
+
+// ReminderHour - the hour of users' local time they are reminded of a working day they haven't tracked
+const ReminderHour = 17
+
+// ReminderService - reminds users in Slack to track the time of their working days
+type ReminderService struct {
+	userRepository  *UserRepository
+	teamRepository  *TeamRepository
+	timerRepository *TimerRepository
+	capacityService *CapacityService
+	messenger       SlackMessenger
+}
+
+// NewReminderService constructs an instance of the service
+func NewReminderService(session *mgo.Session) *ReminderService {
+	return &ReminderService{
+		userRepository:  NewUserRepository(session),
+		teamRepository:  NewTeamRepository(session),
+		timerRepository: NewTimerRepository(session),
+		capacityService: NewCapacityService(session),
+		messenger:       NewSlackMessenger(),
+	}
+}
+
+// RemindUntrackedDays sends a direct message to the users whose local time is ReminderHour o'clock and who have
+// tracked nothing today although their schedule expects them to work. Holidays and booked days off expect nothing,
+// so nobody is reminded of them
+func (s *ReminderService) RemindUntrackedDays(now time.Time) error {
+	users, err := s.userRepository.findAll()
+	if err != nil {
+		return err
+	}
+
+	teams := map[string]*models.Team{}
+	for _, user := range users {
+		// the schedule is in user's timezone, there is nothing to compare with until Slack tells it
+		if user.SlackUserInfo == nil {
+			continue
+		}
+
+		local := now.UTC().Add(time.Duration(user.SlackUserInfo.TZOffset) * time.Second)
+		if local.Hour() != ReminderHour {
+			continue
+		}
+
+		running, err := s.timerRepository.findActiveByUser(user.ID.Hex())
+		if err != nil {
+			return err
+		}
+		if running != nil {
+			continue
+		}
+
+		team, ok := teams[user.TeamID]
+		if !ok {
+			if team, err = s.teamRepository.FindByID(user.TeamID); err != nil {
+				log.Printf("Failed to find team %s to remind %s: %s", user.TeamID, user.ExternalUserName, err)
+				continue
+			}
+			teams[user.TeamID] = team
+		}
+
+		today := local.Format(capacityDateLayout)
+		report, err := s.capacityService.Report(user, user, team, today, today)
+		if err != nil {
+			return err
+		}
+		if report.ExpectedSeconds == 0 || report.TrackedSeconds > 0 {
+			continue
+		}
+
+		if err = s.messenger.PostMessage(team, user.ExternalUserID, untrackedDayReminder); err != nil {
+			log.Printf("Failed to remind %s of untracked time: %s", user.ExternalUserName, err)
+		}
+	}
+
+	return nil
+}
+
+const untrackedDayReminder = "You haven't tracked any time today. Use `/timer start` or add the timers in the web app " +
+	"before the day is over."
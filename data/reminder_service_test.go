@@ -0,0 +1,100 @@
+package data
+
+import (
+	"log"
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestReminderService(t *testing.T) {
+	gosuite.Run(t, &ReminderServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *ReminderServiceTestSuite) TestRemindUntrackedDays(t *testing.T) {
+	idle := s.createUser("idle")
+	tracking := s.createUser("tracking")
+	vacationing := s.createUser("vacationing")
+
+	finishedAt := utils.PT("2016 Dec 05 10:00:00")
+	_, err := NewTimerRepository(s.session).CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     s.team.ID.Hex(),
+		TeamUserID: tracking.ID.Hex(),
+		CreatedAt:  utils.PT("2016 Dec 05 08:00:00"),
+		FinishedAt: &finishedAt,
+		Seconds:    7200,
+	})
+	s.Nil(err)
+
+	_, err = NewTimeOffService(s.session).CreateTimeOff(vacationing, vacationing, &TimeOffRequest{StartDate: "2016-12-5", EndDate: "2016-12-9"})
+	s.Nil(err)
+
+	// it is 16:55 of Monday for the users
+	s.Nil(s.service.RemindUntrackedDays(utils.PT("2016 Dec 05 14:55:00")))
+	s.Len(s.messenger.messages, 0)
+
+	// 17:55
+	s.Nil(s.service.RemindUntrackedDays(utils.PT("2016 Dec 05 15:55:00")))
+	s.Len(s.messenger.messages, 1)
+	s.Equal(s.messenger.messages[0].channelID, idle.ExternalUserID)
+
+	// nobody works on Saturday
+	s.Nil(s.service.RemindUntrackedDays(utils.PT("2016 Dec 10 15:55:00")))
+	s.Len(s.messenger.messages, 1)
+}
+
+func (s *ReminderServiceTestSuite) createUser(externalUserID string) *models.TeamUser {
+	user, err := NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: externalUserID,
+		SlackUserInfo:  &slack.User{TZOffset: 7200},
+	})
+	s.Nil(err)
+	return user
+}
+
+func (s *ReminderServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+}
+
+func (s *ReminderServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *ReminderServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.messenger = &testSlackMessenger{}
+	s.service = NewReminderService(s.session)
+	s.service.messenger = s.messenger
+
+	s.team, _ = NewTeamRepository(s.session).CreateTeam("team-id", "team-name")
+}
+
+func (s *ReminderServiceTestSuite) TearDown() {}
+
+type ReminderServiceTestSuite struct {
+	*is.Is
+	env       *utils.Environment
+	session   *mgo.Session
+	service   *ReminderService
+	messenger *testSlackMessenger
+	team      *models.Team
+}
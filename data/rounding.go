@@ -0,0 +1,80 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+// maxRoundingIncrement - increments longer than a working day make no sense for billing
+const maxRoundingIncrement = 8 * 60
+
+// RoundingOf returns the rule the time of the project is billed by: its own one, the team's one or nil
+func RoundingOf(team *models.Team, project *models.Project) *models.RoundingRule {
+	if project != nil && project.Rounding != nil {
+		return project.Rounding
+	}
+	return team.Rounding
+}
+
+// RoundSeconds rounds the seconds to the increment (in minutes) of the rule, nil rule leaves them as they are
+func RoundSeconds(rule *models.RoundingRule, seconds int) int {
+	if rule == nil || rule.Increment <= 0 {
+		return seconds
+	}
+
+	increment := rule.Increment * 60
+	switch rule.Direction {
+	case models.RoundingDown:
+		return seconds / increment * increment
+	case models.RoundingNearest:
+		return (seconds + increment/2) / increment * increment
+	default:
+		return (seconds + increment - 1) / increment * increment
+	}
+}
+
+// roundEntries rounds the seconds of the timers a user tracked for a project during a day:
+// each of them separately or their total, depending on the scope of the rule
+func roundEntries(rule *models.RoundingRule, entries []int) int {
+	result := 0
+	if rule != nil && rule.Scope == models.RoundingPerDay {
+		for _, seconds := range entries {
+			result += seconds
+		}
+		return RoundSeconds(rule, result)
+	}
+
+	for _, seconds := range entries {
+		result += RoundSeconds(rule, seconds)
+	}
+	return result
+}
+
+// teamRoundingRules maps IDs of the team's projects to the rules their time is billed by
+func teamRoundingRules(team *models.Team) map[string]*models.RoundingRule {
+	result := map[string]*models.RoundingRule{}
+	for _, project := range team.Projects {
+		result[project.ID.Hex()] = RoundingOf(team, project)
+	}
+	return result
+}
+
+func validateRoundingRule(rule *models.RoundingRule) error {
+	if rule.Increment < 1 || rule.Increment > maxRoundingIncrement {
+		return fmt.Errorf("rounding increment should be from 1 to %d minutes", maxRoundingIncrement)
+	}
+
+	switch rule.Direction {
+	case models.RoundingUp, models.RoundingDown, models.RoundingNearest:
+	default:
+		return fmt.Errorf("unknown rounding direction `%s`", rule.Direction)
+	}
+
+	switch rule.Scope {
+	case models.RoundingPerEntry, models.RoundingPerDay:
+	default:
+		return fmt.Errorf("unknown rounding scope `%s`", rule.Scope)
+	}
+	return nil
+}
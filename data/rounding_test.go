@@ -0,0 +1,116 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestRoundSeconds(t *testing.T) {
+	s := is.New(t)
+
+	s.Equal(RoundSeconds(nil, 7), 7)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 15, Direction: models.RoundingUp}, 1), 15*60)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 15, Direction: models.RoundingUp}, 15*60), 15*60)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 15, Direction: models.RoundingUp}, 15*60+1), 30*60)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 15, Direction: models.RoundingUp}, 0), 0)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 15, Direction: models.RoundingDown}, 29*60+59), 15*60)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 6, Direction: models.RoundingNearest}, 8*60+59), 6*60)
+	s.Equal(RoundSeconds(&models.RoundingRule{Increment: 6, Direction: models.RoundingNearest}, 9*60), 12*60)
+}
+
+func TestRoundEntries(t *testing.T) {
+	s := is.New(t)
+
+	perEntry := &models.RoundingRule{Increment: 15, Direction: models.RoundingUp, Scope: models.RoundingPerEntry}
+	perDay := &models.RoundingRule{Increment: 15, Direction: models.RoundingUp, Scope: models.RoundingPerDay}
+
+	s.Equal(roundEntries(nil, []int{5 * 60, 10*60 + 30}), 15*60+30)
+	s.Equal(roundEntries(perEntry, []int{5 * 60, 10*60 + 30}), 30*60)
+	s.Equal(roundEntries(perDay, []int{5 * 60, 10*60 + 30}), 30*60)
+	s.Equal(roundEntries(perDay, []int{5 * 60, 10 * 60}), 15*60)
+}
+
+func TestBuildTeamReport(t *testing.T) {
+	s := is.New(t)
+
+	project := &models.Project{ID: bson.NewObjectId(), ExternalProjectName: "b-project"}
+	team := &models.Team{
+		Projects: []*models.Project{project},
+		Rounding: &models.RoundingRule{Increment: 15, Direction: models.RoundingUp, Scope: models.RoundingPerDay},
+	}
+	project.Rounding = &models.RoundingRule{Increment: 6, Direction: models.RoundingUp, Scope: models.RoundingPerEntry}
+
+	unit := func(user, projectID, projectName, day string, entries ...int) *teamReportUnit {
+		result := &teamReportUnit{ProjectExternalName: projectName, Entries: entries, TimersCount: len(entries)}
+		result.Key.TeamUserID = user
+		result.Key.ProjectID = projectID
+		result.Key.Day = day
+		for _, seconds := range entries {
+			result.Seconds += seconds
+		}
+		return result
+	}
+
+	units := []*teamReportUnit{
+		unit("user-1", project.ID.Hex(), "b-project", "2016-12-01", 5*60, 5*60),
+		unit("user-1", "other", "a-project", "2016-12-01", 5*60, 5*60),
+		unit("user-2", "other", "a-project", "2016-12-02", 20*60),
+	}
+
+	rows := buildTeamReport(team, []string{ReportGroupByProject}, units)
+	s.Len(rows, 2)
+	// the project unknown to the team is rounded by the team's rule
+	s.Equal(rows[0].ProjectExternalName, "a-project")
+	s.Equal(rows[0].Seconds, 30*60)
+	s.Equal(rows[0].RoundedSeconds, (15+30)*60)
+	s.Equal(rows[0].TimersCount, 3)
+	s.Equal(rows[1].ProjectExternalName, "b-project")
+	s.Equal(rows[1].Seconds, 10*60)
+	s.Equal(rows[1].RoundedSeconds, 12*60)
+
+	rows = buildTeamReport(team, []string{ReportGroupByUser}, units)
+	s.Len(rows, 2)
+	s.Equal(rows[0].TeamUserID, "user-1")
+	s.Equal(rows[0].RoundedSeconds, (12+15)*60)
+	s.Zero(rows[0].ProjectID)
+
+	rows = buildTeamReport(&models.Team{}, nil, units)
+	s.Len(rows, 1)
+	s.Equal(rows[0].Seconds, 40*60)
+	s.Equal(rows[0].RoundedSeconds, 40*60)
+}
+
+func TestBuildTeamReportRoundsDaysOnce(t *testing.T) {
+	s := is.New(t)
+
+	team := &models.Team{
+		Rounding: &models.RoundingRule{Increment: 15, Direction: models.RoundingUp, Scope: models.RoundingPerDay},
+	}
+
+	unit := func(task string, seconds int) *teamReportUnit {
+		result := &teamReportUnit{TaskName: task, Seconds: seconds, Entries: []int{seconds}, TimersCount: 1}
+		result.Key.TeamUserID = "user-1"
+		result.Key.ProjectID = "project"
+		result.Key.Day = "2016-12-01"
+		result.Key.TaskHash = task
+		return result
+	}
+
+	// 5 + 5 + 10 minutes of a day are rounded up to 30 minutes once, not to 15 minutes for every task
+	units := []*teamReportUnit{unit("a-task", 5*60), unit("b-task", 5*60), unit("c-task", 10*60)}
+
+	rows := buildTeamReport(team, []string{ReportGroupByProject}, units)
+	s.Len(rows, 1)
+	s.Equal(rows[0].Seconds, 20*60)
+	s.Equal(rows[0].RoundedSeconds, 30*60)
+
+	rows = buildTeamReport(team, []string{ReportGroupByTask}, units)
+	s.Len(rows, 3)
+	s.Equal(rows[0].RoundedSeconds, 7*60+30)
+	s.Equal(rows[1].RoundedSeconds, 7*60+30)
+	s.Equal(rows[2].RoundedSeconds, 15*60)
+	s.Equal(rows[0].RoundedSeconds+rows[1].RoundedSeconds+rows[2].RoundedSeconds, 30*60)
+}
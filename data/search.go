@@ -0,0 +1,79 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchFilter - what a search looks for and in which timers. Text follows the syntax of Mongo text search:
+// words match any of them, "quoted phrases" must be present and -words must be absent.
+// Blank UserIDs mean the searcher's own timers, zero dates leave the range open
+type SearchFilter struct {
+	Text       string
+	TeamID     string
+	UserIDs    []string
+	ProjectIDs []string
+	StartDate  time.Time
+	EndDate    time.Time
+	Limit      int
+}
+
+// SearchTasks looks for the text in the names of the tasks of the viewer or, for those who see team reports,
+// of the given team members and returns the matching tasks with the totals of their timers
+func (s *TimerService) SearchTasks(viewer *models.TeamUser, filter *SearchFilter) ([]*models.TaskSearchResult, error) {
+	filter.Text = strings.TrimSpace(filter.Text)
+	if filter.Text == "" {
+		return nil, errors.New("search text is required")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+
+	othersIncluded := false
+	for _, userID := range filter.UserIDs {
+		if userID != viewer.ID.Hex() {
+			othersIncluded = true
+		}
+	}
+	if len(filter.UserIDs) == 0 {
+		filter.UserIDs = []string{viewer.ID.Hex()}
+	}
+
+	// searching the time of the others is the same as looking at it in the team report
+	if othersIncluded {
+		if err := Authorize(viewer, PermissionViewTeamReports); err != nil {
+			return nil, err
+		}
+
+		allProjects, scope := ProjectScope(viewer)
+		if !allProjects {
+			if len(filter.ProjectIDs) == 0 {
+				if len(scope) == 0 {
+					return []*models.TaskSearchResult{}, nil
+				}
+				filter.ProjectIDs = scope
+			}
+
+			for _, projectID := range filter.ProjectIDs {
+				if AuthorizeForProject(viewer, PermissionViewTeamReports, projectID) != nil {
+					return nil, ErrForbidden
+				}
+			}
+		}
+	}
+
+	filter.TeamID = viewer.TeamID
+	return s.repository.searchTasks(filter)
+}
@@ -0,0 +1,27 @@
+package data
+
+import (
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/nlopes/slack"
+)
+
+// SlackMessenger posts messages on behalf of the team's bot. Services that notify users or channels
+// depend on this interface so unit tests can inject their own impl. and bypass network calls to Slack
+type SlackMessenger interface {
+	PostMessage(team *models.Team, channelID, text string) error
+}
+
+type slackMessengerImpl struct{}
+
+// NewSlackMessenger constructs a SlackMessenger that talks to the real Slack API
+func NewSlackMessenger() SlackMessenger {
+	return &slackMessengerImpl{}
+}
+
+func (m *slackMessengerImpl) PostMessage(team *models.Team, channelID, text string) error {
+	slackAPI := slack.New(team.SlackOAuth.Bot.BotAccessToken)
+	params := slack.NewPostMessageParameters()
+	params.AsUser = true
+	_, _, err := slackAPI.PostMessage(channelID, text, params)
+	return err
+}
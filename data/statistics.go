@@ -0,0 +1,114 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+// Periods of user's statistics
+const (
+	StatisticsPeriodWeek    = "week"
+	StatisticsPeriodMonth   = "month"
+	StatisticsPeriodQuarter = "quarter"
+	StatisticsPeriodYear    = "year"
+)
+
+// statisticsBuckets - what the time of a period is split into
+var statisticsBuckets = map[string]string{
+	StatisticsPeriodWeek:    ReportGroupByDay,
+	StatisticsPeriodMonth:   ReportGroupByDay,
+	StatisticsPeriodQuarter: ReportGroupByWeek,
+	StatisticsPeriodYear:    ReportGroupByMonth,
+}
+
+// heatmapWeeks - the heatmap shows the week of the day and the full weeks of the year before it
+const heatmapWeeks = 53
+
+// UserStatistics aggregates user's time of the week, month, quarter or year the date (2006-1-2 in user's timezone)
+// belongs to. Weeks and months are split by days, quarters by ISO weeks and years by months.
+// Buckets without time are left out
+func (s *TimerService) UserStatistics(user *models.TeamUser, period, date string) ([]*models.UserStatisticsAggregation, error) {
+	bucket, ok := statisticsBuckets[period]
+	if !ok {
+		return nil, fmt.Errorf("unknown period `%s`, should be one of week, month, quarter or year", period)
+	}
+
+	day, err := time.Parse("2006-1-2", date)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := statisticsPeriod(period, day)
+	offset := time.Duration(user.SlackUserInfo.TZOffset) * time.Second
+	return s.repository.userStatistics(user, start.Add(-offset), end.Add(-offset), bucket)
+}
+
+// UserHeatmap returns the time of every day of user's timezone for the year up to the date, 2006-1-2.
+// The heatmap starts on a Monday so the days lay out in full weeks
+func (s *TimerService) UserHeatmap(user *models.TeamUser, date string) ([]*models.HeatmapDay, error) {
+	last, err := time.Parse("2006-1-2", date)
+	if err != nil {
+		return nil, err
+	}
+
+	first := startOfWeek(last).AddDate(0, 0, -7*(heatmapWeeks-1))
+	end := last.AddDate(0, 0, 1).Add(-time.Second)
+
+	offset := time.Duration(user.SlackUserInfo.TZOffset) * time.Second
+	stats, err := s.repository.userStatistics(user, first.Add(-offset), end.Add(-offset), ReportGroupByDay)
+	if err != nil {
+		return nil, err
+	}
+	return heatmapDays(first, last, stats), nil
+}
+
+// statisticsPeriod returns the first and the last second of the period the day belongs to
+func statisticsPeriod(period string, day time.Time) (time.Time, time.Time) {
+	var start, next time.Time
+	switch period {
+	case StatisticsPeriodWeek:
+		start = startOfWeek(day)
+		next = start.AddDate(0, 0, 7)
+	case StatisticsPeriodMonth:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		next = start.AddDate(0, 1, 0)
+	case StatisticsPeriodQuarter:
+		start = time.Date(day.Year(), day.Month()-(day.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+		next = start.AddDate(0, 3, 0)
+	default:
+		start = time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		next = start.AddDate(1, 0, 0)
+	}
+	return start, next.Add(-time.Second)
+}
+
+// startOfWeek returns the Monday of the day's week
+func startOfWeek(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// heatmapDays lays the daily statistics out on every day from first to last and grades them by the busiest day
+func heatmapDays(first, last time.Time, stats []*models.UserStatisticsAggregation) []*models.HeatmapDay {
+	seconds := map[string]int{}
+	busiest := 0
+	for _, stat := range stats {
+		seconds[stat.Date] = stat.Seconds
+		if stat.Seconds > busiest {
+			busiest = stat.Seconds
+		}
+	}
+
+	days := []*models.HeatmapDay{}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		heatmapDay := &models.HeatmapDay{Date: date, Seconds: seconds[date]}
+		if heatmapDay.Seconds > 0 {
+			heatmapDay.Level = int(math.Ceil(4 * float64(heatmapDay.Seconds) / float64(busiest)))
+		}
+		days = append(days, heatmapDay)
+	}
+	return days
+}
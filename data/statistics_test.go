@@ -0,0 +1,52 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestStatisticsPeriod(t *testing.T) {
+	s := is.New(t)
+	day := utils.PT("2016 Nov 16 00:00:00")
+
+	cases := []struct {
+		period     string
+		start, end string
+	}{
+		{StatisticsPeriodWeek, "2016 Nov 14 00:00:00", "2016 Nov 20 23:59:59"},
+		{StatisticsPeriodMonth, "2016 Nov 01 00:00:00", "2016 Nov 30 23:59:59"},
+		{StatisticsPeriodQuarter, "2016 Oct 01 00:00:00", "2016 Dec 31 23:59:59"},
+		{StatisticsPeriodYear, "2016 Jan 01 00:00:00", "2016 Dec 31 23:59:59"},
+	}
+	for _, c := range cases {
+		start, end := statisticsPeriod(c.period, day)
+		s.Equal(start, utils.PT(c.start))
+		s.Equal(end, utils.PT(c.end))
+	}
+
+	// a Sunday belongs to the week started on Monday before it
+	s.Equal(startOfWeek(utils.PT("2016 Nov 20 00:00:00")), utils.PT("2016 Nov 14 00:00:00"))
+	s.Equal(startOfWeek(utils.PT("2016 Nov 14 00:00:00")), utils.PT("2016 Nov 14 00:00:00"))
+}
+
+func TestHeatmapDays(t *testing.T) {
+	s := is.New(t)
+
+	days := heatmapDays(utils.PT("2016 Nov 14 00:00:00"), utils.PT("2016 Nov 20 00:00:00"), []*models.UserStatisticsAggregation{
+		{Date: "2016-11-15", Seconds: 3600},
+		{Date: "2016-11-16", Seconds: 4 * 3600},
+		{Date: "2016-11-18", Seconds: 2*3600 + 1},
+	})
+
+	s.Len(days, 7)
+	s.Equal(days[0].Date, "2016-11-14")
+	s.Equal(days[0].Seconds, 0)
+	s.Equal(days[0].Level, 0)
+	s.Equal(days[1].Level, 1)
+	s.Equal(days[2].Level, 4)
+	s.Equal(days[4].Level, 3)
+	s.Equal(days[6].Date, "2016-11-20")
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+const (
+	defaultSuggestionsLimit = 10
+	maxSuggestionsLimit     = 50
+
+	// suggestionsPeriod - tasks not worked on for longer than this are not suggested
+	suggestionsPeriod = 90 * 24 * time.Hour
+	// ownTimerWeight - a timer of the user counts as much as this many timers of the team
+	ownTimerWeight = 3
+	// suggestionsHalfLife - a task gets half the score when its last timer is this old
+	suggestionsHalfLife = 7 * 24 * time.Hour
+)
+
+// SuggestTasks returns the recent and frequent tasks of the user's team, optionally of the project, whose names
+// have a word starting with the prefix. The ones the user worked on often and lately come first
+func (s *TimerService) SuggestTasks(user *models.TeamUser, projectID, prefix string, limit int, now time.Time) ([]*models.TaskSuggestion, error) {
+	if limit <= 0 {
+		limit = defaultSuggestionsLimit
+	}
+	if limit > maxSuggestionsLimit {
+		limit = maxSuggestionsLimit
+	}
+
+	suggestions, err := s.repository.taskSuggestions(user.TeamID, user.ID.Hex(), projectID,
+		strings.TrimSpace(prefix), now.Add(-suggestionsPeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	rankSuggestions(suggestions, now)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// rankSuggestions scores the suggestions by the number of their timers, the user's ones weighing more,
+// divided by 1 + age/suggestionsHalfLife where age is the time since the last of them, and sorts them by the score.
+// The decay is hyperbolic: the score is a half at suggestionsHalfLife, a third at twice that and so on
+func rankSuggestions(suggestions []*models.TaskSuggestion, now time.Time) {
+	for _, suggestion := range suggestions {
+		frequency := float64(suggestion.TimersCount + (ownTimerWeight-1)*suggestion.OwnTimersCount)
+		age := now.Sub(suggestion.LastStartedAt)
+		if age < 0 {
+			age = 0
+		}
+		suggestion.Score = frequency / (1 + float64(age)/float64(suggestionsHalfLife))
+	}
+	sort.Sort(suggestionsByScore(suggestions))
+}
+
+type suggestionsByScore []*models.TaskSuggestion
+
+func (s suggestionsByScore) Len() int      { return len(s) }
+func (s suggestionsByScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s suggestionsByScore) Less(i, j int) bool {
+	if s[i].Score != s[j].Score {
+		return s[i].Score > s[j].Score
+	}
+	if !s[i].LastStartedAt.Equal(s[j].LastStartedAt) {
+		return s[i].LastStartedAt.After(s[j].LastStartedAt)
+	}
+	return s[i].TaskName < s[j].TaskName
+}
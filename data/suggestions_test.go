@@ -0,0 +1,37 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestRankSuggestions(t *testing.T) {
+	s := is.New(t)
+	now := utils.PT("2016 Apr 30 10:00:00")
+
+	suggestions := []*models.TaskSuggestion{
+		{TaskHash: "old", TaskName: "Old and frequent", TimersCount: 20, LastStartedAt: now.Add(-60 * 24 * time.Hour)},
+		{TaskHash: "team", TaskName: "Team task", TimersCount: 3, LastStartedAt: now.Add(-time.Hour)},
+		{TaskHash: "own", TaskName: "Own task", TimersCount: 2, OwnTimersCount: 2, LastStartedAt: now.Add(-2 * time.Hour)},
+		{TaskHash: "recent", TaskName: "Recent task", TimersCount: 1, LastStartedAt: now},
+	}
+
+	rankSuggestions(suggestions, now)
+	s.Equal(suggestions[0].TaskHash, "own")
+	s.Equal(suggestions[1].TaskHash, "team")
+	s.Equal(suggestions[2].TaskHash, "old")
+	s.Equal(suggestions[3].TaskHash, "recent")
+	s.Equal(suggestions[3].Score, 1.0)
+
+	// equal scores go by recency
+	suggestions = []*models.TaskSuggestion{
+		{TaskHash: "a", TimersCount: 1, LastStartedAt: now.Add(time.Hour)},
+		{TaskHash: "b", TimersCount: 1, LastStartedAt: now.Add(2 * time.Hour)},
+	}
+	rankSuggestions(suggestions, now)
+	s.Equal(suggestions[0].TaskHash, "b")
+}
@@ -79,6 +79,37 @@ func (r *TeamRepository) AddProject(team *models.Team, externalProjectID, extern
 	return nil
 }
 
+// addNonChannelProject adds a project which is not bound to any Slack channel
+func (r *TeamRepository) addNonChannelProject(team *models.Team, name string) (*models.Project, error) {
+	project := &models.Project{
+		ID:                  bson.NewObjectId(),
+		ExternalProjectName: name,
+		CreatedAt:           time.Now(),
+	}
+
+	err := r.collection.Update(bson.M{"_id": team.ID}, bson.M{"$push": bson.M{"projects": project}})
+	if err != nil {
+		return nil, err
+	}
+
+	team.Projects = append(team.Projects, project)
+	return project, nil
+}
+
+// UpdateProject saves the changes of a project embedded in the team
+func (r *TeamRepository) UpdateProject(team *models.Team, project *models.Project) error {
+	return r.collection.Update(
+		bson.M{"_id": team.ID, "projects._id": project.ID},
+		bson.M{"$set": bson.M{"projects.$": project}})
+}
+
+// findTeamsWithBudgets returns the teams having at least one project with a budget
+func (r *TeamRepository) findTeamsWithBudgets() ([]*models.Team, error) {
+	result := []*models.Team{}
+	err := r.collection.Find(bson.M{"projects": bson.M{"$elemMatch": bson.M{"budget": bson.M{"$ne": nil}}}}).All(&result)
+	return result, err
+}
+
 // CreateTeam creates a new team - this method used for tests only!
 func (r *TeamRepository) CreateTeam(externalID, externalName string) (*models.Team, error) {
 
@@ -84,6 +84,76 @@ func (s *TeamService) FindByID(teamID string) (*models.Team, error) {
 	return team, err
 }
 
+// UpdateIssuePatterns replaces the patterns recognising issue references in task names of the team
+func (s *TeamService) UpdateIssuePatterns(user *models.TeamUser, team *models.Team, patterns []*models.IssuePattern) (*models.Team, error) {
+	if user.TeamID != team.ID.Hex() || Authorize(user, PermissionManageTeam) != nil {
+		return nil, ErrForbidden
+	}
+
+	if patterns == nil {
+		patterns = []*models.IssuePattern{}
+	}
+	if err := validateIssuePatterns(patterns); err != nil {
+		return nil, err
+	}
+
+	team.IssuePatterns = patterns
+	return team, s.repository.save(team)
+}
+
+// UpdateWorkSchedule sets the default schedule of the team members, nil schedule brings the built-in default back
+func (s *TeamService) UpdateWorkSchedule(user *models.TeamUser, team *models.Team, schedule *models.WorkSchedule) (*models.Team, error) {
+	if user.TeamID != team.ID.Hex() || Authorize(user, PermissionManageTeam) != nil {
+		return nil, ErrForbidden
+	}
+
+	if schedule != nil {
+		if err := validateWorkSchedule(schedule); err != nil {
+			return nil, err
+		}
+	}
+
+	team.WorkSchedule = schedule
+	return team, s.repository.save(team)
+}
+
+// UpdateRounding sets the rule the time of the team's projects is billed by, nil rule stops rounding
+func (s *TeamService) UpdateRounding(user *models.TeamUser, team *models.Team, rule *models.RoundingRule) (*models.Team, error) {
+	if user.TeamID != team.ID.Hex() || Authorize(user, PermissionManageTeam) != nil {
+		return nil, ErrForbidden
+	}
+
+	if rule != nil {
+		if err := validateRoundingRule(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	team.Rounding = rule
+	return team, s.repository.save(team)
+}
+
+// UpdateProjectRounding sets the project's own rounding rule, nil rule makes the project follow the team's one
+func (s *TeamService) UpdateProjectRounding(user *models.TeamUser, team *models.Team, projectID string, rule *models.RoundingRule) (*models.Project, error) {
+	if user.TeamID != team.ID.Hex() || Authorize(user, PermissionManageTeam) != nil {
+		return nil, ErrForbidden
+	}
+
+	project := findProjectByID(team, projectID)
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	if rule != nil {
+		if err := validateRoundingRule(rule); err != nil {
+			return nil, err
+		}
+	}
+
+	project.Rounding = rule
+	return project, s.repository.save(team)
+}
+
 func (s *TeamService) findProject(team *models.Team, externalProjectID string) *models.Project {
 	var result *models.Project
 	for _, project := range team.Projects {
@@ -94,3 +164,12 @@ func (s *TeamService) findProject(team *models.Team, externalProjectID string) *
 	}
 	return result
 }
+
+func findProjectByID(team *models.Team, projectID string) *models.Project {
+	for _, project := range team.Projects {
+		if project.ID.Hex() == projectID {
+			return project
+		}
+	}
+	return nil
+}
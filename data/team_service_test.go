@@ -259,6 +259,53 @@ func (r *testTeamRepositoryImpl) save(team *models.Team) error {
 	return nil
 }
 
+func (s *TeamServiceTestSuite) TestUpdateIssuePatterns(t *testing.T) {
+	team, err := s.repository.CreateTeam("team-id", "team-domain")
+	s.Nil(err)
+
+	admin := &models.TeamUser{TeamID: team.ID.Hex(), Role: models.RoleAdmin}
+	_, err = s.service.UpdateIssuePatterns(admin, team, testIssuePatterns)
+	s.Nil(err)
+
+	team, _ = s.repository.FindByID(team.ID.Hex())
+	s.Len(team.IssuePatterns, 2)
+	s.Equal(team.IssuePatterns[0].URL, "https://acme.atlassian.net/browse/{key}")
+
+	member := &models.TeamUser{TeamID: team.ID.Hex(), Role: models.RoleMember}
+	_, err = s.service.UpdateIssuePatterns(member, team, nil)
+	s.Equal(err, ErrForbidden)
+}
+
+func (s *TeamServiceTestSuite) TestUpdateRounding(t *testing.T) {
+	team, err := s.repository.CreateTeam("team-id", "team-domain")
+	s.Nil(err)
+	s.Nil(s.repository.AddProject(team, "channel-id", "channel-name"))
+	team, _ = s.repository.FindByID(team.ID.Hex())
+
+	member := &models.TeamUser{TeamID: team.ID.Hex(), Role: models.RoleMember}
+	_, err = s.service.UpdateRounding(member, team, nil)
+	s.Equal(err, ErrForbidden)
+
+	admin := &models.TeamUser{TeamID: team.ID.Hex(), Role: models.RoleAdmin}
+	_, err = s.service.UpdateRounding(admin, team, &models.RoundingRule{Increment: 15, Direction: "sideways", Scope: models.RoundingPerDay})
+	s.Err(err)
+
+	_, err = s.service.UpdateRounding(admin, team, &models.RoundingRule{Increment: 15, Direction: models.RoundingUp, Scope: models.RoundingPerDay})
+	s.Nil(err)
+
+	projectID := team.Projects[0].ID.Hex()
+	_, err = s.service.UpdateProjectRounding(admin, team, projectID, &models.RoundingRule{Increment: 6, Direction: models.RoundingNearest, Scope: models.RoundingPerEntry})
+	s.Nil(err)
+
+	team, _ = s.repository.FindByID(team.ID.Hex())
+	s.Equal(team.Rounding.Increment, 15)
+	s.Equal(RoundingOf(team, team.Projects[0]).Increment, 6)
+	s.Equal(RoundingOf(team, nil).Scope, models.RoundingPerDay)
+
+	_, err = s.service.UpdateProjectRounding(admin, team, "unknown", nil)
+	s.Err(err)
+}
+
 type TeamServiceTestSuite struct {
 	*is.Is
 	env        *utils.Environment
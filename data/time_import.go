@@ -0,0 +1,188 @@
+package data
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+)
+
+// importEntry is a row of a CSV export of another time tracker. Start is the wall clock time of the user
+// who tracked it, the entry gets its real moment once it is mapped to a TeamUser with a known timezone
+type importEntry struct {
+	Row       int
+	UserEmail string
+	UserName  string
+	Project   string
+	TaskName  string
+	Start     time.Time
+	Seconds   int
+	Tags      []string
+}
+
+type importRowParser func(row map[string]string) (*importEntry, error)
+
+var importRowParsers = map[string]importRowParser{
+	models.TimerSourceToggl:    parseTogglRow,
+	models.TimerSourceHarvest:  parseHarvestRow,
+	models.TimerSourceClockify: parseClockifyRow,
+}
+
+// parseImportCSV reads the rows of a CSV export, broken rows are reported as errors and skipped
+func parseImportCSV(source string, r io.Reader) ([]*importEntry, []string, error) {
+	parser, ok := importRowParsers[source]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown import source `%s`", source)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %s", err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+	}
+
+	entries := []*importEntry{}
+	parseErrors := []string{}
+	for rowNumber := 2; ; rowNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		row := map[string]string{}
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+
+		entry, err := parser(row)
+		if err != nil {
+			parseErrors = append(parseErrors, fmt.Sprintf("row %d: %s", rowNumber, err))
+			continue
+		}
+		entry.Row = rowNumber
+		entries = append(entries, entry)
+	}
+
+	return entries, parseErrors, nil
+}
+
+func parseTogglRow(row map[string]string) (*importEntry, error) {
+	start, err := time.Parse("2006-01-02 15:04:05", row["start date"]+" "+row["start time"])
+	if err != nil {
+		return nil, err
+	}
+
+	seconds, err := parseClockDuration(row["duration"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &importEntry{
+		UserEmail: row["email"],
+		UserName:  row["user"],
+		Project:   row["project"],
+		TaskName:  firstNotBlank(row["description"], row["task"]),
+		Start:     start,
+		Seconds:   seconds,
+		Tags:      splitImportTags(row["tags"]),
+	}, nil
+}
+
+// Harvest exports have no start time, entries start in the morning like manual timers do
+func parseHarvestRow(row map[string]string) (*importEntry, error) {
+	date, err := time.Parse("2006-01-02", row["date"])
+	if err != nil {
+		return nil, err
+	}
+
+	hours, err := strconv.ParseFloat(row["hours"], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &importEntry{
+		UserName: strings.TrimSpace(row["first name"] + " " + row["last name"]),
+		Project:  row["project"],
+		TaskName: firstNotBlank(row["notes"], row["task"]),
+		Start:    date.Add(manualTimerHour * time.Hour),
+		Seconds:  int(math.Floor(hours*3600 + 0.5)),
+	}, nil
+}
+
+func parseClockifyRow(row map[string]string) (*importEntry, error) {
+	date := row["start date"] + " " + row["start time"]
+	start, err := time.Parse("01/02/2006 15:04:05", date)
+	if err != nil {
+		if start, err = time.Parse("01/02/2006 03:04:05 PM", date); err != nil {
+			return nil, err
+		}
+	}
+
+	seconds, err := parseClockDuration(row["duration (h)"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &importEntry{
+		UserEmail: row["email"],
+		UserName:  row["user"],
+		Project:   row["project"],
+		TaskName:  firstNotBlank(row["description"], row["task"]),
+		Start:     start,
+		Seconds:   seconds,
+		Tags:      splitImportTags(row["tags"]),
+	}, nil
+}
+
+// parseClockDuration converts `H:MM:SS` to seconds
+func parseClockDuration(value string) (int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("wrong duration `%s`", value)
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("wrong duration `%s`", value)
+		}
+		numbers[i] = number
+	}
+
+	return numbers[0]*3600 + numbers[1]*60 + numbers[2], nil
+}
+
+func splitImportTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func firstNotBlank(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestParseTogglCSV(t *testing.T) {
+	s := is.New(t)
+
+	export := "\ufeffUser,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()\n" +
+		"Alice,alice@example.com,,general,,Fix login,No,2016-12-05,10:00:00,2016-12-05,11:30:29,01:30:29,\"bug, urgent\",\n" +
+		"Bob,bob@example.com,,general,,Review,No,2016-12-05,broken,2016-12-05,11:30:00,01:30:00,,\n"
+
+	entries, parseErrors, err := parseImportCSV(models.TimerSourceToggl, strings.NewReader(export))
+	s.Nil(err)
+	s.Len(entries, 1)
+	s.Len(parseErrors, 1)
+	s.True(strings.HasPrefix(parseErrors[0], "row 3:"))
+
+	entry := entries[0]
+	s.Equal(entry.Row, 2)
+	s.Equal(entry.UserEmail, "alice@example.com")
+	s.Equal(entry.Project, "general")
+	s.Equal(entry.TaskName, "Fix login")
+	s.Equal(entry.Start, utils.PT("2016 Dec 05 10:00:00"))
+	s.Equal(entry.Seconds, 90*60+29)
+	s.Equal(entry.Tags, []string{"bug", "urgent"})
+}
+
+func TestParseHarvestCSV(t *testing.T) {
+	s := is.New(t)
+
+	export := "Date,Client,Project,Project Code,Task,Notes,Hours,Hours Rounded,Billable?,First Name,Last Name\n" +
+		"2016-12-05,ACME,general,,Development,,1.75,1.75,Yes,Alice,Smith\n"
+
+	entries, parseErrors, err := parseImportCSV(models.TimerSourceHarvest, strings.NewReader(export))
+	s.Nil(err)
+	s.Len(parseErrors, 0)
+	s.Len(entries, 1)
+	s.Equal(entries[0].UserName, "Alice Smith")
+	s.Equal(entries[0].TaskName, "Development")
+	s.Equal(entries[0].Start, utils.PT("2016 Dec 05 09:00:00"))
+	s.Equal(entries[0].Seconds, 105*60)
+}
+
+func TestParseClockifyCSV(t *testing.T) {
+	s := is.New(t)
+
+	export := "Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)\n" +
+		"general,,Standup,,Alice,,alice@example.com,,No,12/05/2016,02:15:00 PM,12/05/2016,02:30:00 PM,00:15:00,0.25\n"
+
+	entries, parseErrors, err := parseImportCSV(models.TimerSourceClockify, strings.NewReader(export))
+	s.Nil(err)
+	s.Len(parseErrors, 0)
+	s.Len(entries, 1)
+	s.Equal(entries[0].Start, utils.PT("2016 Dec 05 14:15:00"))
+	s.Equal(entries[0].Seconds, 15*60)
+}
+
+func TestParseImportCSVUnknownSource(t *testing.T) {
+	s := is.New(t)
+
+	_, _, err := parseImportCSV("timesheets-r-us", strings.NewReader(""))
+	s.Err(err)
+	s.Equal(err.Error(), "unknown import source `timesheets-r-us`")
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type TimeOffRepository struct {
+	session  *mgo.Session
+	timeOff  *mgo.Collection
+	holidays *mgo.Collection
+}
+
+func NewTimeOffRepository(session *mgo.Session) *TimeOffRepository {
+	return &TimeOffRepository{
+		session:  session,
+		timeOff:  session.DB("").C(utils.MongoCollectionTimeOff),
+		holidays: session.DB("").C(utils.MongoCollectionHolidays),
+	}
+}
+
+func (r *TimeOffRepository) findTimeOffByID(timeOffID string) (*models.TimeOff, error) {
+	if !bson.IsObjectIdHex(timeOffID) {
+		return nil, mgo.ErrNotFound
+	}
+
+	result := &models.TimeOff{}
+	err := r.timeOff.FindId(bson.ObjectIdHex(timeOffID)).One(result)
+	return result, err
+}
+
+// findTimeOff returns user's time off overlapping the range of dates
+func (r *TimeOffRepository) findTimeOff(userID string, startDate, endDate time.Time) ([]*models.TimeOff, error) {
+	result := []*models.TimeOff{}
+	err := r.timeOff.Find(bson.M{
+		"team_user_id": userID,
+		"start_date":   bson.M{"$lte": endDate},
+		"end_date":     bson.M{"$gte": startDate},
+	}).Sort("start_date").All(&result)
+	return result, err
+}
+
+func (r *TimeOffRepository) createTimeOff(timeOff *models.TimeOff) error {
+	return r.timeOff.Insert(timeOff)
+}
+
+func (r *TimeOffRepository) removeTimeOff(timeOff *models.TimeOff) error {
+	return r.timeOff.RemoveId(timeOff.ID)
+}
+
+func (r *TimeOffRepository) findHolidayByID(holidayID string) (*models.Holiday, error) {
+	if !bson.IsObjectIdHex(holidayID) {
+		return nil, mgo.ErrNotFound
+	}
+
+	result := &models.Holiday{}
+	err := r.holidays.FindId(bson.ObjectIdHex(holidayID)).One(result)
+	return result, err
+}
+
+func (r *TimeOffRepository) findHolidays(teamID string, startDate, endDate time.Time) ([]*models.Holiday, error) {
+	result := []*models.Holiday{}
+	err := r.holidays.Find(bson.M{
+		"team_id": teamID,
+		"date":    bson.M{"$gte": startDate, "$lte": endDate},
+	}).Sort("date").All(&result)
+	return result, err
+}
+
+// saveHoliday creates the holiday or replaces the team's holiday of the same date
+func (r *TimeOffRepository) saveHoliday(holiday *models.Holiday) error {
+	existing := &models.Holiday{}
+	err := r.holidays.Find(bson.M{"team_id": holiday.TeamID, "date": holiday.Date}).One(existing)
+	if err == nil {
+		holiday.ID = existing.ID
+		holiday.CreatedAt = existing.CreatedAt
+		return r.holidays.UpdateId(holiday.ID, holiday)
+	} else if err != mgo.ErrNotFound {
+		return err
+	}
+	holiday.ID = bson.NewObjectId()
+	return r.holidays.Insert(holiday)
+}
+
+func (r *TimeOffRepository) removeHoliday(holiday *models.Holiday) error {
+	return r.holidays.RemoveId(holiday.ID)
+}
@@ -0,0 +1,291 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxTimeOffDays limits the length of a single time off and of the holidays an event is imported as
+const maxTimeOffDays = 366
+
+var timeOffKinds = []string{models.TimeOffVacation, models.TimeOffSick, models.TimeOffOther}
+
+// DayOff tells what takes a day off: the name of a holiday or the kind of user's time off
+type DayOff struct {
+	Reason  string
+	HalfDay bool
+}
+
+// TimeOffRequest - time off asked for in Slack like `2026-10-20..2026-10-24 vacation` or `2026-10-20 sick half`
+type TimeOffRequest struct {
+	StartDate string
+	EndDate   string
+	Kind      string
+	HalfDay   bool
+	Note      string
+}
+
+// TimeOffService - manages users' time off and the team's holiday calendar
+type TimeOffService struct {
+	repository *TimeOffRepository
+}
+
+// NewTimeOffService constructs an instance of the service
+func NewTimeOffService(session *mgo.Session) *TimeOffService {
+	return &TimeOffService{
+		repository: NewTimeOffRepository(session),
+	}
+}
+
+// TimeOff returns the user's time off overlapping the range of days (like 2016-12-1)
+func (s *TimeOffService) TimeOff(user *models.TeamUser, startDate, endDate string) ([]*models.TimeOff, error) {
+	start, end, err := parseDays(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.findTimeOff(user.ID.Hex(), start, end)
+}
+
+// CreateTimeOff books time off for the user. Booking it for somebody else requires a permission to review their time
+func (s *TimeOffService) CreateTimeOff(creator, user *models.TeamUser, request *TimeOffRequest) (*models.TimeOff, error) {
+	if !canManageTimeOff(creator, user) {
+		return nil, ErrForbidden
+	}
+
+	if request.Kind == "" {
+		request.Kind = models.TimeOffVacation
+	}
+	if !isTimeOffKind(request.Kind) {
+		return nil, fmt.Errorf("unknown kind of time off `%s`", request.Kind)
+	}
+
+	start, end, err := parseDays(request.StartDate, request.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	timeOff := &models.TimeOff{
+		ID:           bson.NewObjectId(),
+		TeamID:       user.TeamID,
+		TeamUserID:   user.ID.Hex(),
+		Kind:         request.Kind,
+		StartDate:    start,
+		EndDate:      end,
+		HalfDay:      request.HalfDay,
+		Note:         request.Note,
+		CreatedBy:    creator.ID.Hex(),
+		CreatedAt:    time.Now(),
+		ModelVersion: models.ModelVersionTimeOff,
+	}
+	return timeOff, s.repository.createTimeOff(timeOff)
+}
+
+// DeleteTimeOff cancels the time off
+func (s *TimeOffService) DeleteTimeOff(user *models.TeamUser, timeOffID string) error {
+	timeOff, err := s.repository.findTimeOffByID(timeOffID)
+	if err == mgo.ErrNotFound {
+		return errors.New("time off not found")
+	} else if err != nil {
+		return err
+	}
+
+	owner := &models.TeamUser{ID: bson.ObjectIdHex(timeOff.TeamUserID), TeamID: timeOff.TeamID}
+	if !canManageTimeOff(user, owner) {
+		return errors.New("time off not found")
+	}
+	return s.repository.removeTimeOff(timeOff)
+}
+
+// Holidays returns the holidays of the user's team within the range of days
+func (s *TimeOffService) Holidays(user *models.TeamUser, startDate, endDate string) ([]*models.Holiday, error) {
+	start, end, err := parseDays(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.findHolidays(user.TeamID, start, end)
+}
+
+// CreateHoliday adds a day off to the team's calendar replacing the holiday of the same date if there is one
+func (s *TimeOffService) CreateHoliday(user *models.TeamUser, date, name string, halfDay bool) (*models.Holiday, error) {
+	if err := Authorize(user, PermissionManageTeam); err != nil {
+		return nil, err
+	}
+
+	day, err := time.Parse("2006-1-2", date)
+	if err != nil {
+		return nil, err
+	}
+
+	holiday := &models.Holiday{
+		TeamID:       user.TeamID,
+		Date:         day,
+		Name:         name,
+		HalfDay:      halfDay,
+		CreatedAt:    time.Now(),
+		ModelVersion: models.ModelVersionHoliday,
+	}
+	return holiday, s.repository.saveHoliday(holiday)
+}
+
+// DeleteHoliday removes the day off from the team's calendar
+func (s *TimeOffService) DeleteHoliday(user *models.TeamUser, holidayID string) error {
+	if err := Authorize(user, PermissionManageTeam); err != nil {
+		return err
+	}
+
+	holiday, err := s.repository.findHolidayByID(holidayID)
+	if err == mgo.ErrNotFound || (err == nil && holiday.TeamID != user.TeamID) {
+		return errors.New("holiday not found")
+	} else if err != nil {
+		return err
+	}
+	return s.repository.removeHoliday(holiday)
+}
+
+// ImportHolidays adds every day of the all-day events of an ICS calendar to the team's holidays,
+// importing the same calendar again updates them. Events with a time of day are ignored
+func (s *TimeOffService) ImportHolidays(user *models.TeamUser, r io.Reader) ([]*models.Holiday, error) {
+	if err := Authorize(user, PermissionManageTeam); err != nil {
+		return nil, err
+	}
+
+	events, err := utils.ParseICalendar(r)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := []*models.Holiday{}
+	for _, event := range events {
+		if !event.AllDay {
+			continue
+		}
+
+		for day, days := event.Start, 0; day.Before(event.End) && days < maxTimeOffDays; day, days = day.AddDate(0, 0, 1), days+1 {
+			holiday := &models.Holiday{
+				TeamID:       user.TeamID,
+				Date:         day,
+				Name:         event.Summary,
+				SourceID:     event.UID,
+				CreatedAt:    now,
+				ModelVersion: models.ModelVersionHoliday,
+			}
+			if err = s.repository.saveHoliday(holiday); err != nil {
+				return nil, err
+			}
+			result = append(result, holiday)
+		}
+	}
+	return result, nil
+}
+
+// DaysOff returns the user's days within the range which are taken off by a holiday or time off, keyed by date.
+// A whole day off wins over a half one
+func (s *TimeOffService) DaysOff(user *models.TeamUser, start, end time.Time) (map[string]*DayOff, error) {
+	result := map[string]*DayOff{}
+	add := func(day time.Time, reason string, halfDay bool) {
+		date := day.Format(capacityDateLayout)
+		if existing := result[date]; existing == nil || (existing.HalfDay && !halfDay) {
+			result[date] = &DayOff{Reason: reason, HalfDay: halfDay}
+		}
+	}
+
+	holidays, err := s.repository.findHolidays(user.TeamID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, holiday := range holidays {
+		add(holiday.Date, holiday.Name, holiday.HalfDay)
+	}
+
+	timeOffs, err := s.repository.findTimeOff(user.ID.Hex(), start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, timeOff := range timeOffs {
+		for day := timeOff.StartDate; !day.After(timeOff.EndDate); day = day.AddDate(0, 0, 1) {
+			if !day.Before(start) && !day.After(end) {
+				add(day, timeOff.Kind, timeOff.HalfDay)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// ParseTimeOffRequest parses `<date>[..<date>] [vacation|sick|other] [half] [note]` text of the Slack command
+func ParseTimeOffRequest(text string) (*TimeOffRequest, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil, errors.New("dates of the time off not provided")
+	}
+
+	request := &TimeOffRequest{}
+	dates := strings.SplitN(words[0], "..", 2)
+	request.StartDate, request.EndDate = dates[0], dates[0]
+	if len(dates) == 2 {
+		request.EndDate = dates[1]
+	}
+	if _, _, err := parseDays(request.StartDate, request.EndDate); err != nil {
+		return nil, err
+	}
+
+	note := []string{}
+	for _, word := range words[1:] {
+		switch {
+		case request.Kind == "" && len(note) == 0 && isTimeOffKind(strings.ToLower(word)):
+			request.Kind = strings.ToLower(word)
+		case !request.HalfDay && len(note) == 0 && (strings.ToLower(word) == "half" || strings.ToLower(word) == "half-day"):
+			request.HalfDay = true
+		default:
+			note = append(note, word)
+		}
+	}
+	request.Note = strings.Join(note, " ")
+
+	return request, nil
+}
+
+// parseDays parses an inclusive range of days like 2016-12-1
+func parseDays(startDate, endDate string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-1-2", startDate)
+	if err != nil {
+		return start, start, fmt.Errorf("wrong date `%s`, it should look like 2016-12-31", startDate)
+	}
+	end, err := time.Parse("2006-1-2", endDate)
+	if err != nil {
+		return start, end, fmt.Errorf("wrong date `%s`, it should look like 2016-12-31", endDate)
+	}
+	if end.Before(start) {
+		return start, end, errors.New("the end date is before the start date")
+	}
+	if end.Sub(start).Hours() >= maxTimeOffDays*24 {
+		return start, end, errors.New("Too much days in range")
+	}
+	return start, end, nil
+}
+
+// canManageTimeOff - users book their own time off, reviewers book it for their team members
+func canManageTimeOff(user, owner *models.TeamUser) bool {
+	if user.ID == owner.ID {
+		return Can(user, PermissionTrackTime)
+	}
+	return user.TeamID == owner.TeamID && Can(user, PermissionReviewTimesheets)
+}
+
+func isTimeOffKind(kind string) bool {
+	for _, k := range timeOffKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
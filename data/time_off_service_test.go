@@ -0,0 +1,189 @@
+package data
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+const holidaysCalendar = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:christmas\r\n" +
+	"SUMMARY:Christmas\r\n" +
+	"DTSTART;VALUE=DATE:20161226\r\n" +
+	"DTEND;VALUE=DATE:20161228\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"DTSTART:20161227T090000Z\r\n" +
+	"DTEND:20161227T091500Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseTimeOffRequest(t *testing.T) {
+	s := is.New(t)
+
+	request, err := ParseTimeOffRequest("2026-10-20..2026-10-24 vacation")
+	s.Nil(err)
+	s.Equal(request.StartDate, "2026-10-20")
+	s.Equal(request.EndDate, "2026-10-24")
+	s.Equal(request.Kind, models.TimeOffVacation)
+	s.False(request.HalfDay)
+	s.Equal(request.Note, "")
+
+	request, err = ParseTimeOffRequest("2026-10-27 Sick half caught a cold")
+	s.Nil(err)
+	s.Equal(request.StartDate, "2026-10-27")
+	s.Equal(request.EndDate, "2026-10-27")
+	s.Equal(request.Kind, models.TimeOffSick)
+	s.True(request.HalfDay)
+	s.Equal(request.Note, "caught a cold")
+
+	request, err = ParseTimeOffRequest("2026-10-27 dentist")
+	s.Nil(err)
+	s.Equal(request.Kind, "")
+	s.Equal(request.Note, "dentist")
+
+	_, err = ParseTimeOffRequest("")
+	s.Err(err)
+	_, err = ParseTimeOffRequest("tomorrow")
+	s.Err(err)
+	_, err = ParseTimeOffRequest("2026-10-24..2026-10-20")
+	s.Err(err)
+}
+
+func TestTimeOffService(t *testing.T) {
+	gosuite.Run(t, &TimeOffServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *TimeOffServiceTestSuite) TestCreateTimeOff(t *testing.T) {
+	timeOff, err := s.service.CreateTimeOff(s.user, s.user, &TimeOffRequest{StartDate: "2016-12-5", EndDate: "2016-12-7"})
+	s.Nil(err)
+	s.Equal(timeOff.Kind, models.TimeOffVacation)
+	s.Equal(timeOff.StartDate, utils.PT("2016 Dec 05 00:00:00"))
+	s.Equal(timeOff.EndDate, utils.PT("2016 Dec 07 00:00:00"))
+
+	_, err = s.service.CreateTimeOff(s.user, s.user, &TimeOffRequest{StartDate: "2016-12-5", EndDate: "2016-12-7", Kind: "party"})
+	s.Err(err)
+
+	member := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleMember}
+	_, err = s.service.CreateTimeOff(member, s.user, &TimeOffRequest{StartDate: "2016-12-8", EndDate: "2016-12-8"})
+	s.Equal(err, ErrForbidden)
+
+	manager := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleManager, ManagedProjectIDs: []string{"project"}}
+	_, err = s.service.CreateTimeOff(manager, s.user, &TimeOffRequest{StartDate: "2016-12-8", EndDate: "2016-12-8", Kind: models.TimeOffSick})
+	s.Equal(err, ErrForbidden)
+
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleAdmin}
+	_, err = s.service.CreateTimeOff(admin, s.user, &TimeOffRequest{StartDate: "2016-12-8", EndDate: "2016-12-8", Kind: models.TimeOffSick})
+	s.Nil(err)
+
+	timeOffs, err := s.service.TimeOff(s.user, "2016-12-6", "2016-12-31")
+	s.Nil(err)
+	s.Len(timeOffs, 2)
+
+	s.Err(s.service.DeleteTimeOff(member, timeOff.ID.Hex()))
+	s.Nil(s.service.DeleteTimeOff(s.user, timeOff.ID.Hex()))
+
+	timeOffs, _ = s.service.TimeOff(s.user, "2016-12-1", "2016-12-31")
+	s.Len(timeOffs, 1)
+	s.Equal(timeOffs[0].Kind, models.TimeOffSick)
+}
+
+func (s *TimeOffServiceTestSuite) TestImportHolidays(t *testing.T) {
+	_, err := s.service.ImportHolidays(s.user, bytes.NewBufferString(holidaysCalendar))
+	s.Equal(err, ErrForbidden)
+
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleAdmin}
+	holidays, err := s.service.ImportHolidays(admin, bytes.NewBufferString(holidaysCalendar))
+	s.Nil(err)
+	s.Len(holidays, 2)
+
+	// importing the calendar again updates the holidays
+	_, err = s.service.ImportHolidays(admin, bytes.NewBufferString(holidaysCalendar))
+	s.Nil(err)
+
+	holidays, err = s.service.Holidays(s.user, "2016-12-1", "2016-12-31")
+	s.Nil(err)
+	s.Len(holidays, 2)
+	s.Equal(holidays[0].Name, "Christmas")
+	s.Equal(holidays[0].Date, utils.PT("2016 Dec 26 00:00:00"))
+	s.Equal(holidays[1].Date, utils.PT("2016 Dec 27 00:00:00"))
+
+	s.Err(s.service.DeleteHoliday(s.user, holidays[0].ID.Hex()))
+	s.Nil(s.service.DeleteHoliday(admin, holidays[0].ID.Hex()))
+
+	holidays, _ = s.service.Holidays(s.user, "2016-12-1", "2016-12-31")
+	s.Len(holidays, 1)
+}
+
+func (s *TimeOffServiceTestSuite) TestDaysOff(t *testing.T) {
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: s.team.ID.Hex(), Role: models.RoleAdmin}
+	_, err := s.service.CreateHoliday(admin, "2016-12-6", "St. Nicholas Day", true)
+	s.Nil(err)
+
+	s.service.CreateTimeOff(s.user, s.user, &TimeOffRequest{StartDate: "2016-12-6", EndDate: "2016-12-7", Kind: models.TimeOffVacation})
+
+	daysOff, err := s.service.DaysOff(s.user, utils.PT("2016 Dec 05 00:00:00"), utils.PT("2016 Dec 09 00:00:00"))
+	s.Nil(err)
+	s.Len(daysOff, 2)
+	// a whole day of vacation wins over a half day holiday
+	s.Equal(daysOff["2016-12-06"].Reason, models.TimeOffVacation)
+	s.False(daysOff["2016-12-06"].HalfDay)
+	s.Equal(daysOff["2016-12-07"].Reason, models.TimeOffVacation)
+
+	report, err := NewCapacityService(s.session).Report(s.user, s.user, s.team, "2016-12-5", "2016-12-9")
+	s.Nil(err)
+	s.Equal(report.ExpectedSeconds, 3*8*60*60)
+	s.Equal(report.Days[1].Off, models.TimeOffVacation)
+}
+
+type TimeOffServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *TimeOffService
+	team    *models.Team
+	user    *models.TeamUser
+}
+
+func (s *TimeOffServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.service = NewTimeOffService(s.session)
+}
+
+func (s *TimeOffServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *TimeOffServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.team, _ = NewTeamRepository(s.session).CreateTeam("team-id", "team-name")
+	s.user, _ = NewUserRepository(s.session).Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "user",
+		SlackUserInfo:  &slack.User{TZOffset: 7200},
+	})
+}
+
+func (s *TimeOffServiceTestSuite) TearDown() {}
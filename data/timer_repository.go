@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"log"
+	"regexp"
 	"time"
 
 	"github.com/cleverua/tuna-timer-api/models"
@@ -13,6 +14,30 @@ import (
 
 const timersCollectionName = "timers"
 
+// Keys the team report can be grouped by, any combination is allowed but only one of day, week or month
+const (
+	ReportGroupByUser    = "user"
+	ReportGroupByProject = "project"
+	ReportGroupByTask    = "task"
+	ReportGroupByIssue   = "issue"
+	ReportGroupByDay     = "day"
+	ReportGroupByWeek    = "week"
+	ReportGroupByMonth   = "month"
+)
+
+// TeamReportFilter describes what timers the team report is built of and how they are grouped
+type TeamReportFilter struct {
+	TeamID     string
+	UserIDs    []string
+	ProjectIDs []string
+	Tags       []string
+	StartDate  time.Time
+	EndDate    time.Time
+	GroupBy    []string
+	// TZOffset (in seconds) decides which day, week or month a timer belongs to
+	TZOffset int
+}
+
 // TimerRepository todo
 type TimerRepository struct {
 	session    *mgo.Session
@@ -77,7 +102,7 @@ func (r *TimerRepository) findActiveByUser(userID string) (*models.Timer, error)
 	return result, err
 }
 
-func (r *TimerRepository) create(teamID string, project *models.Project, user *models.TeamUser, taskName string) (*models.Timer, error) {
+func (r *TimerRepository) create(teamID string, project *models.Project, user *models.TeamUser, taskName string, issues []*models.IssueReference) (*models.Timer, error) {
 
 	timer := &models.Timer{
 		ID:                  bson.NewObjectId(),
@@ -90,7 +115,8 @@ func (r *TimerRepository) create(teamID string, project *models.Project, user *m
 		CreatedAt:           time.Now(),
 		TaskName:            taskName,
 		TaskHash:            taskSHA256(teamID, project.ID.Hex(), taskName),
-		Minutes:             0,
+		Issues:              issues,
+		Seconds:             0,
 		ModelVersion:        models.ModelVersionTimer,
 	}
 
@@ -113,13 +139,13 @@ db.getCollection('data').aggregate([
 {
 	$group: {
 		_id: { task_hash: '$task_hash' },
-		minutes: { $sum: '$minutes' },
+		seconds: { $sum: '$seconds' },
 		total_timers: { $sum: 1 },
 	}
 }])
 
 */
-func (r *TimerRepository) totalMinutesForTaskAndUser(taskHash, userID string, startDate, endDate time.Time) int {
+func (r *TimerRepository) totalSecondsForTaskAndUser(taskHash, userID string, startDate, endDate time.Time) int {
 	pipeConfig := []map[string]interface{}{
 		{
 			"$match": bson.M{
@@ -135,7 +161,7 @@ func (r *TimerRepository) totalMinutesForTaskAndUser(taskHash, userID string, st
 		{
 			"$group": bson.M{
 				"_id":          bson.M{"task_hash": "$task_hash"},
-				"minutes":      bson.M{"$sum": "$minutes"},
+				"seconds":      bson.M{"$sum": "$seconds"},
 				"total_timers": bson.M{"$sum": 1},
 			},
 		},
@@ -151,10 +177,10 @@ func (r *TimerRepository) totalMinutesForTaskAndUser(taskHash, userID string, st
 		return 0
 	}
 
-	return result["minutes"].(int)
+	return result["seconds"].(int)
 }
 
-func (r *TimerRepository) totalMinutesForUser(userID string, startDate, endDate time.Time) int {
+func (r *TimerRepository) totalSecondsForUser(userID string, startDate, endDate time.Time) int {
 	pipeConfig := []map[string]interface{}{
 		{
 			"$match": bson.M{
@@ -169,7 +195,7 @@ func (r *TimerRepository) totalMinutesForUser(userID string, startDate, endDate
 		{
 			"$group": bson.M{
 				"_id":          bson.M{"user_id": "$team_user_id"},
-				"minutes":      bson.M{"$sum": "$minutes"},
+				"seconds":      bson.M{"$sum": "$seconds"},
 				"total_timers": bson.M{"$sum": 1},
 			},
 		},
@@ -185,7 +211,7 @@ func (r *TimerRepository) totalMinutesForUser(userID string, startDate, endDate
 		return 0
 	}
 
-	return result["minutes"].(int)
+	return result["seconds"].(int)
 }
 
 func (r *TimerRepository) completedTasksForUser(userID string, startDate, endDate time.Time) ([]*models.TaskAggregation, error) {
@@ -208,17 +234,21 @@ func (r *TimerRepository) completedTasksForUser(userID string, startDate, endDat
 		{
 			"$group": bson.M{
 				"_id":     bson.M{"task_name": "$task_name", "task_hash": "$task_hash", "project_ext_name": "$project_ext_name", "project_ext_id": "$project_ext_id"},
-				"minutes": bson.M{"$sum": "$minutes"},
+				"seconds": bson.M{"$sum": "$seconds"},
+				"issues":  bson.M{"$first": "$issues"},
+				"pomodoros": bson.M{"$sum": "$pomodoros"},
 			},
 		},
 		{
 			"$project": bson.M{
 				"_id":              0,
 				"task_name":        "$_id.task_name",
-				"minutes":          "$minutes",
+				"seconds":          "$seconds",
 				"task_hash":        "$_id.task_hash",
 				"project_ext_name": "$_id.project_ext_name",
 				"project_ext_id":   "$_id.project_ext_id",
+				"issues":           "$issues",
+				"pomodoros":        "$pomodoros",
 			},
 		},
 	}
@@ -241,6 +271,46 @@ func (r *TimerRepository) update(timer *models.Timer) error {
 	return r.collection.UpdateId(timer.ID, timer)
 }
 
+// pushEdit adds the edit to the timer without writing the rest of it, so that a change made to the timer
+// in the meantime is kept. A finished timer gets its seconds changed right away, a running one when it stops
+func (r *TimerRepository) pushEdit(timerID bson.ObjectId, edit *models.TimeEdit) (*models.Timer, error) {
+	result := &models.Timer{}
+	_, err := r.collection.Find(bson.M{"_id": timerID, "finished_at": nil}).Apply(mgo.Change{
+		Update:    bson.M{"$push": bson.M{"edits": edit}},
+		ReturnNew: true,
+	}, result)
+
+	if err == mgo.ErrNotFound {
+		_, err = r.collection.Find(bson.M{"_id": timerID, "finished_at": bson.M{"$ne": nil}}).Apply(mgo.Change{
+			Update:    bson.M{"$push": bson.M{"edits": edit}, "$inc": bson.M{"seconds": edit.Seconds}},
+			ReturnNew: true,
+		}, result)
+	}
+	return result, err
+}
+
+func (r *TimerRepository) remove(timerID bson.ObjectId) error {
+	return r.collection.RemoveId(timerID)
+}
+
+// touchHeartbeat moves the heartbeat of the running timer to the moment and returns the timer as it was before,
+// so of concurrent heartbeats only one sees the gap. Nil is returned if the timer is not running anymore
+func (r *TimerRepository) touchHeartbeat(timerID bson.ObjectId, now time.Time) (*models.Timer, error) {
+	result := &models.Timer{}
+	_, err := r.collection.Find(bson.M{
+		"_id":         timerID,
+		"finished_at": nil,
+		"deleted_at":  nil,
+	}).Apply(mgo.Change{
+		Update: bson.M{"$set": bson.M{"last_heartbeat_at": now}},
+	}, result)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
 // split into two - hash and trim?
 func taskSHA256(teamID, projectID, taskName string) string {
 	hashSeed := fmt.Sprintf("%s%s%s", teamID, projectID, taskName)
@@ -262,7 +332,161 @@ func (r *TimerRepository) findUserTasksByRange(userID string, startDate, endDate
 	return results, err
 }
 
-func (r *TimerRepository) userStatistics(user *models.TeamUser, startDate, endDate time.Time) ([]*models.UserStatisticsAggregation, error) {
+// findPage returns up to filter.Limit+1 timers after the cursor, the extra one tells there is a next page,
+// and the total number of timers matching the filter
+func (r *TimerRepository) findPage(filter *TimersFilter, cursor *timersCursor) ([]*models.Timer, int, error) {
+	query := timersQuery(filter)
+	total, err := r.collection.Find(query).Count()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if cursor != nil {
+		query = bson.M{"$and": []bson.M{query, afterCursor(filter.Sort, cursor)}}
+	}
+
+	idSort := "_id"
+	if filter.Sort[0] == '-' {
+		idSort = "-_id"
+	}
+
+	results := []*models.Timer{}
+	err = r.collection.Find(query).Sort(filter.Sort, idSort).Limit(filter.Limit + 1).All(&results)
+	return results, total, err
+}
+
+// searchTasks groups the timers matching the filter by task, ranking tasks by the text score of their names
+func (r *TimerRepository) searchTasks(filter *SearchFilter) ([]*models.TaskSearchResult, error) {
+	match := bson.M{
+		"$text":        bson.M{"$search": filter.Text},
+		"team_id":      filter.TeamID,
+		"team_user_id": bson.M{"$in": filter.UserIDs},
+		"deleted_at":   nil,
+	}
+
+	if len(filter.ProjectIDs) > 0 {
+		match["project_id"] = bson.M{"$in": filter.ProjectIDs}
+	}
+
+	createdAt := bson.M{}
+	if !filter.StartDate.IsZero() {
+		createdAt["$gte"] = filter.StartDate
+	}
+	if !filter.EndDate.IsZero() {
+		createdAt["$lte"] = filter.EndDate
+	}
+	if len(createdAt) > 0 {
+		match["created_at"] = createdAt
+	}
+
+	results := []*models.TaskSearchResult{}
+	err := r.collection.Pipe([]bson.M{
+		{"$match": match},
+		{"$project": bson.M{
+			"task_hash":        1,
+			"task_name":        1,
+			"project_id":       1,
+			"project_ext_id":   1,
+			"project_ext_name": 1,
+			"issues":           1,
+			"team_user_id":     1,
+			"seconds":          1,
+			"created_at":       1,
+			"score":            bson.M{"$meta": "textScore"},
+		}},
+		{"$sort": bson.M{"created_at": -1}},
+		{"$group": bson.M{
+			"_id":              "$task_hash",
+			"task_name":        bson.M{"$first": "$task_name"},
+			"project_id":       bson.M{"$first": "$project_id"},
+			"project_ext_id":   bson.M{"$first": "$project_ext_id"},
+			"project_ext_name": bson.M{"$first": "$project_ext_name"},
+			"issues":           bson.M{"$first": "$issues"},
+			"team_user_ids":    bson.M{"$addToSet": "$team_user_id"},
+			"score":            bson.M{"$max": "$score"},
+			"seconds":          bson.M{"$sum": "$seconds"},
+			"timers_count":     bson.M{"$sum": 1},
+			"first_started_at": bson.M{"$min": "$created_at"},
+			"last_started_at":  bson.M{"$max": "$created_at"},
+		}},
+		{"$sort": bson.D{{Name: "score", Value: -1}, {Name: "last_started_at", Value: -1}}},
+		{"$limit": filter.Limit},
+	}).All(&results)
+
+	return results, err
+}
+
+// taskSuggestions groups the team's timers started since the moment by task, for the tasks whose name
+// has a word starting with the prefix
+func (r *TimerRepository) taskSuggestions(teamID, userID, projectID, prefix string, since time.Time) ([]*models.TaskSuggestion, error) {
+	match := bson.M{
+		"team_id":    teamID,
+		"created_at": bson.M{"$gte": since},
+		"deleted_at": nil,
+	}
+	if projectID != "" {
+		match["project_id"] = projectID
+	}
+	if prefix != "" {
+		match["task_name"] = bson.RegEx{Pattern: `(^|\s)` + regexp.QuoteMeta(prefix), Options: "i"}
+	}
+
+	results := []*models.TaskSuggestion{}
+	err := r.collection.Pipe([]bson.M{
+		{"$match": match},
+		{"$sort": bson.M{"created_at": -1}},
+		{"$group": bson.M{
+			"_id":              "$task_hash",
+			"task_name":        bson.M{"$first": "$task_name"},
+			"project_id":       bson.M{"$first": "$project_id"},
+			"project_ext_id":   bson.M{"$first": "$project_ext_id"},
+			"project_ext_name": bson.M{"$first": "$project_ext_name"},
+			"timers_count":     bson.M{"$sum": 1},
+			"own_timers_count": bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []string{"$team_user_id", userID}}, 1, 0}}},
+			"last_started_at":  bson.M{"$max": "$created_at"},
+		}},
+	}).All(&results)
+
+	return results, err
+}
+
+func (r *TimerRepository) findUserTaskTimersByRange(userID, taskHash string, startDate, endDate time.Time) ([]*models.Timer, error) {
+	var results []*models.Timer
+
+	err := r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"task_hash":    taskHash,
+		"created_at": bson.M{
+			"$gte": startDate,
+			"$lt":  endDate,
+		},
+		"deleted_at": nil,
+	}).Sort("created_at").All(&results)
+
+	return results, err
+}
+
+// findSourceIDs tells which of the source IDs the team's timers are imported from already
+func (r *TimerRepository) findSourceIDs(teamID string, sourceIDs []string) (map[string]bool, error) {
+	var timers []*models.Timer
+	result := map[string]bool{}
+
+	err := r.collection.Find(bson.M{
+		"team_id":    teamID,
+		"source_id":  bson.M{"$in": sourceIDs},
+		"deleted_at": nil,
+	}).Select(bson.M{"source_id": 1}).All(&timers)
+
+	for _, timer := range timers {
+		result[timer.SourceID] = true
+	}
+	return result, err
+}
+
+// userStatistics aggregates user's completed timers by days, ISO weeks or months of user's timezone and by projects
+func (r *TimerRepository) userStatistics(user *models.TeamUser, startDate, endDate time.Time, bucket string) ([]*models.UserStatisticsAggregation, error) {
+	localCreatedAt := bson.M{"$add": []interface{}{"$created_at", user.SlackUserInfo.TZOffset * 1000}}
+
 	pipeConfig := []bson.M{
 		{
 			"$match": bson.M{
@@ -277,31 +501,184 @@ func (r *TimerRepository) userStatistics(user *models.TeamUser, startDate, endDa
 		},
 		{
 			"$group": bson.M{
-				// Converts created_at timestamp to user's timezone and gets it's day
+				// Groups by the bucket of created_at in user's timezone (the offset is in seconds, dates are in milliseconds)
 				"_id": bson.M{
-					"$dayOfMonth": bson.M{
-						"$add": []interface{}{"$created_at", user.SlackUserInfo.TZOffset},
-					},
+					"date": bson.M{"$dateToString": bson.M{"format": periodFormats[bucket], "date": localCreatedAt}},
+					"project_id": "$project_id",
+					"project_ext_name": "$project_ext_name",
 				},
-				"minutes": bson.M{"$sum": "$minutes"},
-				"projects_names": bson.M{"$addToSet": "$project_ext_name"},
+				"seconds": bson.M{"$sum": "$seconds"},
+			},
+		},
+		{
+			"$sort": bson.M{"seconds": -1},
+		},
+		{
+			"$group": bson.M{
+				"_id": "$_id.date",
+				"seconds": bson.M{"$sum": "$seconds"},
+				"projects_names": bson.M{"$addToSet": "$_id.project_ext_name"},
+				"projects": bson.M{"$push": bson.M{
+					"project_id": "$_id.project_id",
+					"project_ext_name": "$_id.project_ext_name",
+					"seconds": "$seconds",
+				}},
 			},
 		},
 		{
 			"$project": bson.M{
 				"_id":      0,
-				"day":      "$_id",
-				"minutes":  "$minutes",
+				"date":     "$_id",
+				"seconds":  "$seconds",
 				"projects_names": "$projects_names",
+				"projects": "$projects",
 			},
 		},
 		{
-			"$sort": bson.M{"day": 1},
+			"$sort": bson.M{"date": 1},
 		},
 	}
 
 	var results []*models.UserStatisticsAggregation
 	err := r.collection.Pipe(pipeConfig).All(&results)
 
+	if bucket == ReportGroupByDay {
+		for _, result := range results {
+			if day, err := time.Parse("2006-01-02", result.Date); err == nil {
+				result.Day = day.Day()
+			}
+		}
+	}
 	return results, err
 }
+
+// teamReportUnits aggregates the time of the timers by users, projects and their days along with the keys
+// the report is grouped by. Rounding rules apply to these units, the report rows are built of them
+func (r *TimerRepository) teamReportUnits(filter *TeamReportFilter) ([]*teamReportUnit, error) {
+	match := bson.M{
+		"team_id": filter.TeamID,
+		"created_at": bson.M{
+			"$gte": filter.StartDate,
+			"$lte": filter.EndDate,
+		},
+		"finished_at": bson.M{"$ne": nil},
+		"deleted_at":  nil,
+	}
+
+	if len(filter.UserIDs) > 0 {
+		match["team_user_id"] = bson.M{"$in": filter.UserIDs}
+	}
+
+	if len(filter.ProjectIDs) > 0 {
+		match["project_id"] = bson.M{"$in": filter.ProjectIDs}
+	}
+
+	if len(filter.Tags) > 0 {
+		match["tags"] = bson.M{"$all": filter.Tags}
+	}
+
+	// Converts created_at timestamp to requester's timezone (the offset is in seconds, dates are in milliseconds)
+	localCreatedAt := bson.M{"$add": []interface{}{"$created_at", filter.TZOffset * 1000}}
+
+	groupID := bson.M{
+		"team_user_id": "$team_user_id",
+		"project_id":   "$project_id",
+		"day":          bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": timerLocalCreatedAt}},
+	}
+	group := bson.M{
+		"_id":              groupID,
+		"seconds":          bson.M{"$sum": "$seconds"},
+		"entries":          bson.M{"$push": "$seconds"},
+		"timers_count":     bson.M{"$sum": 1},
+		"pomodoros":        bson.M{"$sum": "$pomodoros"},
+		"project_ext_name": bson.M{"$first": "$project_ext_name"},
+		"task_name":        bson.M{"$first": "$task_name"},
+	}
+
+	for _, key := range filter.GroupBy {
+		switch key {
+		case ReportGroupByTask:
+			groupID["task_hash"] = "$task_hash"
+		case ReportGroupByIssue:
+			groupID["issue"] = "$issues.key"
+			group["issue_url"] = bson.M{"$first": "$issues.url"}
+		case ReportGroupByDay, ReportGroupByWeek, ReportGroupByMonth:
+			groupID["period"] = bson.M{"$dateToString": bson.M{"format": periodFormats[key], "date": localCreatedAt}}
+		}
+	}
+
+	pipeConfig := []bson.M{
+		{"$match": match},
+		{"$sort": bson.M{"created_at": -1}},
+	}
+
+	// A timer referring to several issues counts for each of them, timers without issues make a blank issue row
+	for _, key := range filter.GroupBy {
+		if key == ReportGroupByIssue {
+			pipeConfig = append(pipeConfig, bson.M{"$unwind": bson.M{"path": "$issues", "preserveNullAndEmptyArrays": true}})
+		}
+	}
+
+	pipeConfig = append(pipeConfig, bson.M{"$group": group})
+
+	var results []*teamReportUnit
+	err := r.collection.Pipe(pipeConfig).All(&results)
+	return results, err
+}
+
+// projectUnits aggregates the time of the project's timers by users and their days
+func (r *TimerRepository) projectUnits(projectID string, startDate, endDate time.Time) ([]*teamReportUnit, error) {
+	pipeConfig := []bson.M{
+		{"$match": bson.M{
+			"project_id": projectID,
+			"created_at": bson.M{
+				"$gte": startDate,
+				"$lte": endDate,
+			},
+			"deleted_at": nil,
+		}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"team_user_id": "$team_user_id",
+				"project_id":   "$project_id",
+				"day":          bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": timerLocalCreatedAt}},
+			},
+			"seconds":      bson.M{"$sum": "$seconds"},
+			"entries":      bson.M{"$push": "$seconds"},
+			"timers_count": bson.M{"$sum": 1},
+		}},
+	}
+
+	var results []*teamReportUnit
+	err := r.collection.Pipe(pipeConfig).All(&results)
+	return results, err
+}
+
+// teamReportUnit is the time a user tracked for a project during a day of the user,
+// split by the keys the team report is grouped by
+type teamReportUnit struct {
+	Key struct {
+		TeamUserID string `bson:"team_user_id"`
+		ProjectID  string `bson:"project_id"`
+		Day        string `bson:"day"`
+		TaskHash   string `bson:"task_hash"`
+		Issue      string `bson:"issue"`
+		Period     string `bson:"period"`
+	} `bson:"_id"`
+	ProjectExternalName string `bson:"project_ext_name"`
+	TaskName            string `bson:"task_name"`
+	IssueURL            string `bson:"issue_url"`
+	Seconds             int    `bson:"seconds"`
+	Entries             []int  `bson:"entries"`
+	TimersCount         int    `bson:"timers_count"`
+	Pomodoros           int    `bson:"pomodoros"`
+}
+
+// timerLocalCreatedAt converts created_at timestamp to the timezone of timer's owner
+var timerLocalCreatedAt = bson.M{"$add": []interface{}{"$created_at", bson.M{"$multiply": []interface{}{"$tz_offset", 1000}}}}
+
+var periodFormats = map[string]string{
+	ReportGroupByDay:   "%Y-%m-%d",
+	ReportGroupByWeek:  "%G-W%V",
+	ReportGroupByMonth: "%Y-%m",
+}
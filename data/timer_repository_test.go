@@ -36,20 +36,20 @@ func (s *TimerRepositoryTestSuite) TestUpdate(t *testing.T) {
 		},
 	}
 
-	timer, err := s.repo.create("team", project, user, "task")
+	timer, err := s.repo.create("team", project, user, "task", nil)
 	s.Nil(err)
 	s.NotNil(timer)
-	s.Equal(timer.Minutes, 0)
+	s.Equal(timer.Seconds, 0)
 	s.Equal(timer.ModelVersion, models.ModelVersionTimer)
 
-	timer.Minutes = 50
+	timer.Seconds = 50 * 60
 
 	err = s.repo.update(timer)
 	s.Nil(err)
 
 	loadedTimer, err := s.repo.findByID(timer.ID.Hex())
 	s.Nil(err)
-	s.Equal(loadedTimer.Minutes, 50)
+	s.Equal(loadedTimer.Seconds, 50*60)
 }
 
 func (s *TimerRepositoryTestSuite) TestCreateTimer(t *testing.T) {
@@ -68,7 +68,7 @@ func (s *TimerRepositoryTestSuite) TestCreateTimer(t *testing.T) {
 		},
 	}
 
-	timer, err := s.repo.create("team", project, user, "task")
+	timer, err := s.repo.create("team", project, user, "task", nil)
 	s.Nil(err)
 	s.NotNil(timer)
 
@@ -78,7 +78,7 @@ func (s *TimerRepositoryTestSuite) TestCreateTimer(t *testing.T) {
 	s.NotNil(timerFromDB.CreatedAt) //todo check this
 	s.Nil(timerFromDB.DeletedAt) //todo check this
 	s.Nil(timerFromDB.FinishedAt) //todo check this
-	s.Equal(timerFromDB.Minutes, 0)
+	s.Equal(timerFromDB.Seconds, 0)
 	s.Equal(timerFromDB.TeamID, "team")
 	s.Equal(timerFromDB.ProjectID, project.ID.Hex())
 	s.Equal(timerFromDB.ProjectExternalID, "0987654321")
@@ -104,7 +104,7 @@ func (s *TimerRepositoryTestSuite) TestFindActiveTimerByTeamAndUserExists(t *tes
 		TeamUserID: "user",
 		CreatedAt:  time.Now(),
 		TaskName:   "task",
-		Minutes:    0,
+		Seconds:    0,
 	}
 	s.repo.CreateTimer(timer)
 
@@ -126,7 +126,7 @@ func (s *TimerRepositoryTestSuite) TestFindActiveTimerByTeamAndUserButAlreadyFin
 		CreatedAt:  finishedAt,
 		FinishedAt: &finishedAt,
 		TaskName:   "task",
-		Minutes:    0,
+		Seconds:    0,
 	}
 	s.repo.CreateTimer(timer)
 
@@ -147,7 +147,7 @@ func (s *TimerRepositoryTestSuite) TestFindActiveTimerByTeamAndUserButAlreadyDel
 		CreatedAt:  deletedAt,
 		DeletedAt:  &deletedAt,
 		TaskName:   "task",
-		Minutes:    0,
+		Seconds:    0,
 	}
 	s.repo.CreateTimer(timer)
 
@@ -156,7 +156,7 @@ func (s *TimerRepositoryTestSuite) TestFindActiveTimerByTeamAndUserButAlreadyDel
 	s.Nil(timerFromDB)
 }
 
-func (s *TimerRepositoryTestSuite) TestTotalMinutesMethods(t *testing.T) {
+func (s *TimerRepositoryTestSuite) TestTotalSecondsMethods(t *testing.T) {
 
 	now := time.Now()
 	// creates 10 timers one minute each
@@ -170,7 +170,7 @@ func (s *TimerRepositoryTestSuite) TestTotalMinutesMethods(t *testing.T) {
 			TaskHash:   "task",
 			CreatedAt:  createdAt,
 			FinishedAt: &now,
-			Minutes:    1,
+			Seconds:    1 * 60,
 		})
 	}
 
@@ -183,7 +183,7 @@ func (s *TimerRepositoryTestSuite) TestTotalMinutesMethods(t *testing.T) {
 		TaskHash:   "another task",
 		CreatedAt:  utils.PT("2016 Sep 12 10:35:00"),
 		FinishedAt: &now,
-		Minutes:    1,
+		Seconds:    1 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -194,7 +194,7 @@ func (s *TimerRepositoryTestSuite) TestTotalMinutesMethods(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 13 19:35:00"),
 		FinishedAt: &now,
-		Minutes:    1,
+		Seconds:    1 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -205,7 +205,7 @@ func (s *TimerRepositoryTestSuite) TestTotalMinutesMethods(t *testing.T) {
 		TaskHash:   "another task",
 		CreatedAt:  utils.PT("2016 Sep 14 19:35:00"),
 		FinishedAt: &now,
-		Minutes:    1,
+		Seconds:    1 * 60,
 	})
 
 	// Deleted task should not to be in results
@@ -217,30 +217,30 @@ func (s *TimerRepositoryTestSuite) TestTotalMinutesMethods(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 19:35:00"),
 		FinishedAt: &now,
-		Minutes:    10,
+		Seconds:    10 * 60,
 		DeletedAt:  &now,
 	})
 
 	// all tasks
-	m := s.repo.totalMinutesForTaskAndUser("task", "user", utils.PT("2016 Sep 09 12:35:00"), utils.PT("2016 Sep 21 12:35:00"))
-	s.Equal(m, 10)
+	m := s.repo.totalSecondsForTaskAndUser("task", "user", utils.PT("2016 Sep 09 12:35:00"), utils.PT("2016 Sep 21 12:35:00"))
+	s.Equal(m, 10*60)
 
 	// one year later than any of the tasks
-	m = s.repo.totalMinutesForTaskAndUser("task", "user", utils.PT("2017 Sep 09 12:35:00"), utils.PT("2017 Sep 21 12:35:00"))
+	m = s.repo.totalSecondsForTaskAndUser("task", "user", utils.PT("2017 Sep 09 12:35:00"), utils.PT("2017 Sep 21 12:35:00"))
 	s.Equal(m, 0)
 
 	// should get one for 10th, one for 11th and one for 12th because the endDate is one minute after the third time
-	m = s.repo.totalMinutesForTaskAndUser("task", "user", utils.PT("2016 Sep 10 10:00:00"), utils.PT("2016 Sep 12 12:36:00"))
-	s.Equal(m, 3)
+	m = s.repo.totalSecondsForTaskAndUser("task", "user", utils.PT("2016 Sep 10 10:00:00"), utils.PT("2016 Sep 12 12:36:00"))
+	s.Equal(m, 3*60)
 
-	m = s.repo.totalMinutesForUser("user", utils.PT("2016 Sep 09 12:35:00"), utils.PT("2016 Sep 21 12:35:00"))
-	s.Equal(m, 11) // 10 regular and one outstanding timer
+	m = s.repo.totalSecondsForUser("user", utils.PT("2016 Sep 09 12:35:00"), utils.PT("2016 Sep 21 12:35:00"))
+	s.Equal(m, 11*60) // 10 regular and one outstanding timer
 
-	m = s.repo.totalMinutesForUser("user", utils.PT("2017 Sep 09 12:35:00"), utils.PT("2017 Sep 21 12:35:00"))
+	m = s.repo.totalSecondsForUser("user", utils.PT("2017 Sep 09 12:35:00"), utils.PT("2017 Sep 21 12:35:00"))
 	s.Equal(m, 0)
 
-	m = s.repo.totalMinutesForUser("user", utils.PT("2016 Sep 12 00:00:00"), utils.PT("2016 Sep 12 23:59:59"))
-	s.Equal(m, 2)
+	m = s.repo.totalSecondsForUser("user", utils.PT("2016 Sep 12 00:00:00"), utils.PT("2016 Sep 12 23:59:59"))
+	s.Equal(m, 2*60)
 }
 
 func (s *TimerRepositoryTestSuite) TestCompletedTasksForUser(t *testing.T) {
@@ -256,7 +256,7 @@ func (s *TimerRepositoryTestSuite) TestCompletedTasksForUser(t *testing.T) {
 		TaskName:   "task-name1",
 		CreatedAt:  utils.PT("2016 Sep 25 12:35:00"),
 		FinishedAt: &now,
-		Minutes:    5,
+		Seconds:    5 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -268,7 +268,7 @@ func (s *TimerRepositoryTestSuite) TestCompletedTasksForUser(t *testing.T) {
 		TaskName:   "task-name1",
 		CreatedAt:  utils.PT("2016 Sep 25 12:40:00"),
 		FinishedAt: &now,
-		Minutes:    10,
+		Seconds:    10 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -280,7 +280,7 @@ func (s *TimerRepositoryTestSuite) TestCompletedTasksForUser(t *testing.T) {
 		TaskName:   "task-name2",
 		CreatedAt:  utils.PT("2016 Sep 25 12:50:00"),
 		FinishedAt: &now,
-		Minutes:    20,
+		Seconds:    20 * 60,
 	})
 
 	// Deleted task should not to be in results
@@ -292,7 +292,7 @@ func (s *TimerRepositoryTestSuite) TestCompletedTasksForUser(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 25 12:37:00"),
 		FinishedAt: &now,
-		Minutes:    2,
+		Seconds:    2 * 60,
 		DeletedAt:  &now,
 	})
 
@@ -300,16 +300,16 @@ func (s *TimerRepositoryTestSuite) TestCompletedTasksForUser(t *testing.T) {
 	s.Nil(err)
 
 	s.Equal(len(m), 1) // only the `task-hash1` one given the time frame
-	s.Equal(m[0].Minutes, 15)
+	s.Equal(m[0].Seconds, 15*60)
 	s.Equal(m[0].Name, "task-name1")
 
 	m, err = s.repo.completedTasksForUser("user", utils.PT("2016 Sep 25 12:35:00"), utils.PT("2016 Sep 25 15:00:00"))
 	s.Nil(err)
 
 	s.Equal(len(m), 2)
-	s.Equal(m[0].Minutes, 15)
+	s.Equal(m[0].Seconds, 15*60)
 	s.Equal(m[0].Name, "task-name1")
-	s.Equal(m[1].Minutes, 20)
+	s.Equal(m[1].Seconds, 20*60)
 	s.Equal(m[1].Name, "task-name2")
 }
 
@@ -368,7 +368,7 @@ func (s *TimerRepositoryTestSuite) TestFindUserTasksByRange(t *testing.T) {
 		ProjectID:  "project",
 		TeamUserID: firstUserID,
 		CreatedAt:  startDate.Add(time.Second * 3600 * 8),
-		Minutes:    20,
+		Seconds:    20 * 60,
 	})
 	s.repo.CreateTimer(&models.Timer{
 		ID:         bson.NewObjectId(),
@@ -376,7 +376,7 @@ func (s *TimerRepositoryTestSuite) TestFindUserTasksByRange(t *testing.T) {
 		ProjectID:  "project",
 		TeamUserID: firstUserID,
 		CreatedAt:  startDate.Add(time.Second * 3600 * 24),
-		Minutes:    20,
+		Seconds:    20 * 60,
 	})
 
 	// Deleted task should not to be in results
@@ -387,7 +387,7 @@ func (s *TimerRepositoryTestSuite) TestFindUserTasksByRange(t *testing.T) {
 		ProjectID:  "project",
 		TeamUserID: firstUserID,
 		CreatedAt:  startDate.Add(time.Second * 3600 * 8),
-		Minutes:    2,
+		Seconds:    2 * 60,
 		DeletedAt:  &deleted,
 	})
 
@@ -398,7 +398,7 @@ func (s *TimerRepositoryTestSuite) TestFindUserTasksByRange(t *testing.T) {
 		ProjectID:  "project",
 		TeamUserID: secondUserID,
 		CreatedAt:  startDate.Add(time.Second * 3600 * 8),
-		Minutes:    1,
+		Seconds:    1 * 60,
 	})
 
 	//Check first user timers
@@ -458,8 +458,8 @@ func (s *TimerRepositoryTestSuite) TestUserStatistics(t *testing.T) {
 			TeamUserID:		user.ID.Hex(),
 			CreatedAt:		startDate.AddDate(0, 0, i),
 			FinishedAt:		&finished,
-			Minutes:		m,
-			ActualMinutes:		m,
+			Seconds:		m * 60,
+			ActualSeconds:		m * 60,
 		})
 
 		s.repo.CreateTimer(&models.Timer{
@@ -470,8 +470,8 @@ func (s *TimerRepositoryTestSuite) TestUserStatistics(t *testing.T) {
 			TeamUserID:		user.ID.Hex(),
 			CreatedAt:		startDate.AddDate(0, 0, i).Add(4 * time.Hour),
 			FinishedAt:		&finished,
-			Minutes:		m,
-			ActualMinutes:		m,
+			Seconds:		m * 60,
+			ActualSeconds:		m * 60,
 		})
 	}
 
@@ -484,20 +484,31 @@ func (s *TimerRepositoryTestSuite) TestUserStatistics(t *testing.T) {
 		ProjectExternalName:	"project_name",
 		TeamUserID:		user.ID.Hex(),
 		CreatedAt:		startDate.Add(time.Hour * 30),
-		Minutes:		2,
+		Seconds:		2 * 60,
 		FinishedAt:		&deleted,
 		DeletedAt:		&deleted,
 	})
 
-	data, err := s.repo.userStatistics(user, startDate, endDate)
+	data, err := s.repo.userStatistics(user, startDate, endDate, ReportGroupByDay)
 	s.Nil(err)
 	s.Len(data, days)
 
 	for i, stat := range data {
+		s.Equal(stat.Date, startDate.AddDate(0, 0, i).Format("2006-01-02"))
 		s.Equal(stat.Day, 1 + i)
-		s.Equal(stat.Minutes, (minutes + i) * 2)
+		s.Equal(stat.Seconds, (minutes + i) * 60 * 2)
 		s.Len(stat.ProjectsNames, 2)
+		s.Len(stat.Projects, 2)
+		s.Equal(stat.Projects[0].ProjectID, "project")
+		s.Equal(stat.Projects[0].Seconds, (minutes + i) * 60)
 	}
+
+	data, err = s.repo.userStatistics(user, startDate, endDate, ReportGroupByMonth)
+	s.Nil(err)
+	s.Len(data, 1)
+	s.Equal(data[0].Date, startDate.Format("2006-01"))
+	s.Equal(data[0].Day, 0)
+	s.Len(data[0].Projects, 2)
 }
 
 func (s *TimerRepositoryTestSuite) SetUpSuite() {
@@ -4,22 +4,30 @@ import (
 	"github.com/cleverua/tuna-timer-api/models"
 	"github.com/cleverua/tuna-timer-api/utils"
 	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 	"log"
 	"time"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 )
 
-const maxDaysCount  = 31
-
 // TimerService - the structure of the service
 type TimerService struct {
-	repository *TimerRepository
+	repository          *TimerRepository
+	timesheetRepository *TimesheetRepository
+	teamRepository      *TeamRepository
+	events              TimerEventPublisher
 }
 
 // NewTimerService constructs an instance of the service
 func NewTimerService(session *mgo.Session) *TimerService {
 	return &TimerService{
-		repository: NewTimerRepository(session),
+		repository:          NewTimerRepository(session),
+		timesheetRepository: NewTimesheetRepository(session),
+		teamRepository:      NewTeamRepository(session),
+		events:              timerEventPublishers{NewWebhookService(session), DefaultEventBus},
 	}
 }
 
@@ -33,37 +41,84 @@ func (s *TimerService) FindByID(id string)  (*models.Timer, error) {
 	return s.repository.findByID(id)
 }
 
-// StopTimer stops the timer and updates its Minutes field
+// StopTimer stops the timer and updates its Seconds field
 func (s *TimerService) StopTimer(timer *models.Timer) error {
-	now := time.Now()
-	timer.ActualMinutes = s.CalculateMinutesForActiveTimer(timer)
-	timer.Minutes = timer.ActualMinutes
-	timer.FinishedAt = &now
-	return s.repository.update(timer)
+	return s.StopTimerAt(timer, time.Now())
+}
+
+// StopTimerAt stops the timer as if it was stopped at the moment, e.g. when a pomodoro was over
+func (s *TimerService) StopTimerAt(timer *models.Timer, at time.Time) error {
+	s.finishAt(timer, at)
+	return s.update(models.WebhookEventTimerStopped, timer)
+}
+
+func (s *TimerService) finish(timer *models.Timer) {
+	s.finishAt(timer, time.Now())
+}
+
+func (s *TimerService) finishAt(timer *models.Timer, at time.Time) {
+	timer.ActualSeconds = int(at.Sub(timer.CreatedAt).Seconds())
+	timer.Seconds = timer.ActualSeconds + editedSeconds(timer)
+	timer.FinishedAt = &at
+}
+
+// editedSeconds sums the edits of the timer, e.g. idle time discarded while it was running
+func editedSeconds(timer *models.Timer) int {
+	result := 0
+	for _, edit := range timer.Edits {
+		result += edit.Seconds
+	}
+	return result
 }
 
 // StartTimer creates a new timer
 func (s *TimerService) StartTimer(teamID string, project *models.Project, user *models.TeamUser, taskName string) (*models.Timer, error) {
-	return s.repository.create(teamID, project, user, taskName)
+	timer, err := s.repository.create(teamID, project, user, taskName, s.issuesFor(teamID, taskName))
+	if err == nil {
+		s.events.Fire(models.WebhookEventTimerStarted, timer)
+	}
+	return timer, err
+}
+
+// issuesFor extracts the issue references from the task name using the patterns of the team
+func (s *TimerService) issuesFor(teamID, taskName string) []*models.IssueReference {
+	if !bson.IsObjectIdHex(teamID) {
+		return []*models.IssueReference{}
+	}
+
+	team, err := s.teamRepository.FindByID(teamID)
+	if err != nil {
+		return []*models.IssueReference{}
+	}
+	return ExtractIssues(team.IssuePatterns, taskName)
+}
+
+// update saves the timer and lets the subscribers know about the change
+func (s *TimerService) update(event string, timer *models.Timer) error {
+	if err := s.repository.update(timer); err != nil {
+		return err
+	}
+	s.events.Fire(event, timer)
+	return nil
 }
 
-// TotalMinutesForTaskToday calculates the total number of minutes the user was/is working on particular task today
-func (s *TimerService) TotalMinutesForTaskToday(timer *models.Timer) int {
+// TotalSecondsForTaskToday calculates the total number of seconds the user was/is working on particular task today
+func (s *TimerService) TotalSecondsForTaskToday(timer *models.Timer) int {
 	endDate := time.Now()
 	startDate := time.Now().Truncate(24 * time.Hour)
 
-	result := s.repository.totalMinutesForTaskAndUser(
+	result := s.repository.totalSecondsForTaskAndUser(
 		timer.TaskHash, timer.TeamUserID, startDate, endDate)
 
 	if timer.FinishedAt == nil {
-		result += s.CalculateMinutesForActiveTimer(timer)
+		result += s.CalculateSecondsForActiveTimer(timer)
 	}
 
 	return result
 }
 
-// UserTotalMinutesForToday calculates the total number of minute this user contributed to any project today
-func (s *TimerService) TotalCompletedMinutesForDay(year int, month time.Month, day int, user *models.TeamUser) int {
+// TotalCompletedSecondsForDay calculates the total number of seconds this user contributed to any project today
+func (s *TimerService) TotalCompletedSecondsForDay(year int, month time.Month, day int, user *models.TeamUser) int {
 
 	//log.Printf("TotalUserMinutesForDay, Year: %d, Month: %d, Day: %d", year, month, day)
 
@@ -73,7 +128,7 @@ func (s *TimerService) TotalCompletedMinutesForDay(year int, month time.Month, d
 	startDate := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Add(time.Duration(tzOffset) * time.Second * -1)
 	endDate := time.Date(year, month, day, 23, 59, 59, 0, time.UTC).Add(time.Duration(tzOffset) * time.Second * -1)
 
-	return s.repository.totalMinutesForUser(user.ID.Hex(), startDate, endDate)
+	return s.repository.totalSecondsForUser(user.ID.Hex(), startDate, endDate)
 }
 
 // GetCompletedTasksForDay - returns the list of tasks the user had completed during given work day by his/her timezone
@@ -115,8 +170,9 @@ func (s *TimerService) CompleteActiveTimersAtMidnight(utcNow *time.Time) error {
 		endDate := time.Date(timer.CreatedAt.Year(), timer.CreatedAt.Month(), timer.CreatedAt.Day(), utcNow.Hour()-1, 59, 59, 0, time.UTC)
 		timer.FinishedAt = &endDate
 
-		timer.Minutes = int(endDate.Sub(timer.CreatedAt).Minutes())
-		err = s.repository.update(timer)
+		timer.ActualSeconds = int(endDate.Sub(timer.CreatedAt).Seconds())
+		timer.Seconds = timer.ActualSeconds + editedSeconds(timer)
+		err = s.update(models.WebhookEventTimerStopped, timer)
 		if err != nil {
 			return err
 		}
@@ -125,83 +181,344 @@ func (s *TimerService) CompleteActiveTimersAtMidnight(utcNow *time.Time) error {
 	return nil
 }
 
-func (s *TimerService) CalculateMinutesForActiveTimer(timer *models.Timer) int {
+// CalculateSecondsForActiveTimer - the exact time the timer is running for, themes round it when formatting
+func (s *TimerService) CalculateSecondsForActiveTimer(timer *models.Timer) int {
 	duration := time.Since(timer.CreatedAt)
-	return int(duration.Minutes())
+	return int(duration.Seconds())
 }
 
-// Returns all user tasks for range(startDate...endDate).
-// Range couldn't be more than 31 day
+// Returns all user tasks for range(startDate...endDate)
 func (s *TimerService) GetUserTimersByRange(startDate, endDate string, user *models.TeamUser) ([]*models.Timer, error) {
 	// Decide what timezone to use: user or tz from frontend request? todo
-	tzOffset := user.SlackUserInfo.TZOffset
-	layout := "2006-1-2 15:04:05"
+	startTime, endTime, err := ParseDateRange(startDate, endDate, user.SlackUserInfo.TZOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.findUserTasksByRange(user.ID.Hex(), startTime, endTime)
+}
 
-	startDateParse, err := time.Parse(layout, startDate + " 00:00:00")
+// UserTimers returns a page of user's timers matching the filter
+func (s *TimerService) UserTimers(user *models.TeamUser, filter *TimersFilter) ([]*models.Timer, *models.Pagination, error) {
+	if err := normalizeTimersFilter(filter); err != nil {
+		return nil, nil, err
+	}
+	filter.UserID = user.ID.Hex()
+
+	var cursor *timersCursor
+	if filter.Cursor != "" {
+		var err error
+		if cursor, err = decodeTimersCursor(filter.Sort, filter.Cursor); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	timers, total, err := s.repository.findPage(filter, cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pagination := &models.Pagination{Total: total, Limit: filter.Limit}
+	if len(timers) > filter.Limit {
+		timers = timers[:filter.Limit]
+		pagination.NextCursor = encodeTimersCursor(filter.Sort, timers[len(timers)-1])
+	}
+	return timers, pagination, nil
+}
+
+// ParseDateRange converts a range of days (like 2016-12-1) given in a timezone to UTC times,
+// the end date is inclusive so the range ends at its last second
+func ParseDateRange(startDate, endDate string, tzOffset int) (time.Time, time.Time, error) {
+	startTime, err := ParseDay(startDate, tzOffset)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	endTime, err := ParseDay(endDate, tzOffset)
 	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return startTime, endTime.Add(24*time.Hour - time.Second), nil
+}
+
+// ParseDay converts a day (like 2016-12-1) given in a timezone to the UTC time it starts at
+func ParseDay(date string, tzOffset int) (time.Time, error) {
+	day, err := time.Parse("2006-1-2", date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(time.Duration(tzOffset) * time.Second * -1), nil
+}
+
+// TeamReport aggregates the time tracked by the viewer's team members.
+// Managers and viewers only get the projects they are scoped to
+func (s *TimerService) TeamReport(viewer *models.TeamUser, filter *TeamReportFilter) ([]*models.TeamReportAggregation, error) {
+	if err := Authorize(viewer, PermissionViewTeamReports); err != nil {
 		return nil, err
 	}
 
-	endDateTParse, err := time.Parse(layout, endDate + " 23:59:59")
+	periods := 0
+	for _, key := range filter.GroupBy {
+		switch key {
+		case ReportGroupByUser, ReportGroupByProject, ReportGroupByTask, ReportGroupByIssue:
+		case ReportGroupByDay, ReportGroupByWeek, ReportGroupByMonth:
+			periods++
+		default:
+			return nil, fmt.Errorf("unknown grouping `%s`", key)
+		}
+	}
+
+	if periods > 1 {
+		return nil, errors.New("report can be grouped by only one of day, week or month")
+	}
+
+	allProjects, scope := ProjectScope(viewer)
+	if !allProjects {
+		if len(filter.ProjectIDs) == 0 {
+			if len(scope) == 0 {
+				return []*models.TeamReportAggregation{}, nil
+			}
+			filter.ProjectIDs = scope
+		}
+
+		for _, projectID := range filter.ProjectIDs {
+			if AuthorizeForProject(viewer, PermissionViewTeamReports, projectID) != nil {
+				return nil, ErrForbidden
+			}
+		}
+	}
+
+	// the time of teams which are not saved (like in tests) is not rounded
+	team := &models.Team{}
+	if bson.IsObjectIdHex(viewer.TeamID) {
+		var err error
+		if team, err = s.teamRepository.FindByID(viewer.TeamID); err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	filter.TeamID = viewer.TeamID
+	units, err := s.repository.teamReportUnits(filter)
 	if err != nil {
 		return nil, err
 	}
+	return buildTeamReport(team, filter.GroupBy, units), nil
+}
 
-	startTime := startDateParse.Add(time.Duration(tzOffset) * time.Second * -1)
-	endTime := endDateTParse.Add(time.Duration(tzOffset) * time.Second * -1)
+// buildTeamReport sums the units up into the rows of the report. The units are rounded by the rules
+// of their projects. Rounding per day applies once to the total a user tracked for a project during the day,
+// which is shared out to the rows by their time, so the rounded total does not depend on the grouping
+func buildTeamReport(team *models.Team, groupBy []string, units []*teamReportUnit) []*models.TeamReportAggregation {
+	rules := teamRoundingRules(team)
+	rows := map[string]*models.TeamReportAggregation{}
+	result := []*models.TeamReportAggregation{}
+
+	ruleOf := func(unit *teamReportUnit) *models.RoundingRule {
+		if rule, known := rules[unit.Key.ProjectID]; known {
+			return rule
+		}
+		return team.Rounding
+	}
 
-	if endTime.Sub(startTime).Hours() > maxDaysCount * 24 {
-		return nil, errors.New("Too much days in range")
+	days := map[string]*roundedDay{}
+	dayKey := func(unit *teamReportUnit) string {
+		return strings.Join([]string{unit.Key.TeamUserID, unit.Key.ProjectID, unit.Key.Day}, "|")
+	}
+	for _, unit := range units {
+		day := days[dayKey(unit)]
+		if day == nil {
+			day = &roundedDay{rule: ruleOf(unit)}
+			days[dayKey(unit)] = day
+		}
+		day.seconds += unit.Seconds
+	}
+	for _, day := range days {
+		day.rounded = RoundSeconds(day.rule, day.seconds)
 	}
 
-	return s.repository.findUserTasksByRange(user.ID.Hex(), startTime, endTime)
+	for _, unit := range units {
+		row := &models.TeamReportAggregation{}
+		for _, key := range groupBy {
+			switch key {
+			case ReportGroupByUser:
+				row.TeamUserID = unit.Key.TeamUserID
+			case ReportGroupByProject:
+				row.ProjectID = unit.Key.ProjectID
+				row.ProjectExternalName = unit.ProjectExternalName
+			case ReportGroupByTask:
+				row.TaskHash = unit.Key.TaskHash
+				row.TaskName = unit.TaskName
+			case ReportGroupByIssue:
+				row.Issue = unit.Key.Issue
+				row.IssueURL = unit.IssueURL
+			case ReportGroupByDay, ReportGroupByWeek, ReportGroupByMonth:
+				row.Period = unit.Key.Period
+			}
+		}
+
+		rowKey := strings.Join([]string{row.Period, row.TeamUserID, row.ProjectID, row.TaskHash, row.Issue}, "|")
+		if existing := rows[rowKey]; existing != nil {
+			row = existing
+		} else {
+			rows[rowKey] = row
+			result = append(result, row)
+		}
+
+		rule := ruleOf(unit)
+		row.Seconds += unit.Seconds
+		if rule != nil && rule.Scope == models.RoundingPerDay {
+			row.RoundedSeconds += days[dayKey(unit)].share(unit.Seconds)
+		} else {
+			row.RoundedSeconds += roundEntries(rule, unit.Entries)
+		}
+		row.TimersCount += unit.TimersCount
+		row.Pomodoros += unit.Pomodoros
+	}
+
+	sort.Sort(teamReportRows(result))
+	return result
+}
+
+// roundedDay is the time a user tracked for a project during a day, its rounded total is shared out
+// to the units of the day in proportion to their seconds, the last one gets the remainder
+type roundedDay struct {
+	rule    *models.RoundingRule
+	seconds int
+	rounded int
+	shared  int
+	given   int
+}
+
+func (d *roundedDay) share(seconds int) int {
+	d.shared += seconds
+	total := d.rounded
+	if d.shared < d.seconds {
+		total = d.rounded * d.shared / d.seconds
+	}
+
+	result := total - d.given
+	d.given = total
+	return result
+}
+
+// teamReportRows sorts the rows by period, user, project, task and issue
+type teamReportRows []*models.TeamReportAggregation
+
+func (r teamReportRows) Len() int      { return len(r) }
+func (r teamReportRows) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r teamReportRows) Less(i, j int) bool {
+	a, b := r[i], r[j]
+	for _, pair := range [][2]string{
+		{a.Period, b.Period},
+		{a.TeamUserID, b.TeamUserID},
+		{a.ProjectExternalName, b.ProjectExternalName},
+		{a.TaskName, b.TaskName},
+		{a.Issue, b.Issue},
+	} {
+		if pair[0] != pair[1] {
+			return pair[0] < pair[1]
+		}
+	}
+	return false
 }
 
 func (s *TimerService) UpdateUserTimer(user *models.TeamUser, timer *models.Timer, newData *models.Timer) error {
-	if user.ID.Hex() != timer.TeamUserID && !(user.SlackUserInfo.IsOwner && user.TeamID == timer.TeamID) {
+	if !canModifyTimer(user, timer) {
 		//TODO move all errors into separate package
 		return errors.New("update forbidden")
 	}
 
-	// Allowed parameters: TaskName, ProjectID, ProjectExternalID, ProjectExternalName, Edits
+	if err := s.ensureNotLocked(timer.TeamUserID, timer.CreatedAt); err != nil {
+		return err
+	}
+
+	if newData.ProjectID != "" && newData.ProjectID != timer.ProjectID {
+		project, err := s.destinationProject(user, timer, newData.ProjectID)
+		if err != nil {
+			return err
+		}
+		timer.ProjectID = project.ID.Hex()
+		timer.ProjectExternalID = project.ExternalProjectID
+		timer.ProjectExternalName = project.ExternalProjectName
+	}
+
+	// Allowed parameters: TaskName, ProjectID, Edits, Tags. The external ID and name come from the team's project
 	timer.TaskName = newData.TaskName
-	timer.ProjectID = newData.ProjectID
-	timer.ProjectExternalID = newData.ProjectExternalID
-	timer.ProjectExternalName = newData.ProjectExternalName
 	timer.Edits = newData.Edits
+	timer.Tags = newData.Tags
+	timer.Issues = s.issuesFor(timer.TeamID, timer.TaskName)
 
-	var count int = 0
-	for _, te := range newData.Edits {
-		count += te.Minutes
-	}
-	timer.Minutes = timer.ActualMinutes + count
+	timer.Seconds = timer.ActualSeconds + editedSeconds(timer)
 
-	return s.repository.update(timer)
+	return s.update(models.WebhookEventTimerUpdated, timer)
 }
 
 func (s *TimerService) DeleteUserTimer(user *models.TeamUser, timer *models.Timer) error {
-	if user.ID.Hex() != timer.TeamUserID {
+	if !canModifyTimer(user, timer) {
 		//TODO move all errors into separate package
 		return errors.New("delete forbidden")
 	}
 
+	if err := s.ensureNotLocked(timer.TeamUserID, timer.CreatedAt); err != nil {
+		return err
+	}
+
 	now := time.Now()
 	timer.DeletedAt = &now
 
 	if timer.FinishedAt == nil {
-		return s.StopTimer(timer)
+		s.finish(timer)
 	}
-	return s.repository.update(timer)
+	return s.update(models.WebhookEventTimerDeleted, timer)
 }
 
-func (s *TimerService) UserMonthStatistics(user *models.TeamUser, date string) ([]*models.UserStatisticsAggregation, error) {
-	layout := "2006-1-2 15:04:05"
-
-	startOfMonth, err := time.Parse(layout, date + " 00:00:00")
+// destinationProject finds the project a timer is moved to among the projects of the timer's team,
+// moving timers of other team members requires the permission for the destination project as well
+func (s *TimerService) destinationProject(user *models.TeamUser, timer *models.Timer, projectID string) (*models.Project, error) {
+	team, err := s.teamRepository.FindByID(timer.TeamID)
 	if err != nil {
 		return nil, err
 	}
-	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-1 * time.Second)
 
-	return s.repository.userStatistics(user, startOfMonth, endOfMonth)
+	project := findProjectByID(team, projectID)
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	if user.ID.Hex() != timer.TeamUserID && AuthorizeForProject(user, PermissionEditTeamTimers, projectID) != nil {
+		return nil, errors.New("update forbidden")
+	}
+	return project, nil
+}
+
+// canModifyTimer - users may change their own timers as long as they track time,
+// timers of the other team members require a permission for the timer's project
+func canModifyTimer(user *models.TeamUser, timer *models.Timer) bool {
+	if user.ID.Hex() == timer.TeamUserID {
+		return Can(user, PermissionTrackTime)
+	}
+	return user.TeamID == timer.TeamID && AuthorizeForProject(user, PermissionEditTeamTimers, timer.ProjectID) == nil
+}
+
+// IsLocked tells whether the user's time at the given moment is covered by an approved timesheet.
+// Any path that creates or modifies timers in the past should refuse to do it for locked time
+func (s *TimerService) IsLocked(userID string, moment time.Time) (bool, error) {
+	timesheet, err := s.timesheetRepository.findApprovedCovering(userID, moment)
+	return timesheet != nil, err
+}
+
+func (s *TimerService) ensureNotLocked(userID string, moment time.Time) error {
+	locked, err := s.IsLocked(userID, moment)
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		return errors.New("timer is locked by an approved timesheet")
+	}
+	return nil
+}
+
+func (s *TimerService) UserMonthStatistics(user *models.TeamUser, date string) ([]*models.UserStatisticsAggregation, error) {
+	return s.UserStatistics(user, StatisticsPeriodMonth, date)
 }
@@ -32,7 +32,7 @@ func (s *TimerServiceTestSuite) TestgetActiveTimer(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  now,
 		FinishedAt: &now,
-		Minutes:    10,
+		Seconds:    10 * 60,
 	})
 
 	// not completed
@@ -43,14 +43,14 @@ func (s *TimerServiceTestSuite) TestgetActiveTimer(t *testing.T) {
 		TeamUserID: "user",
 		TaskHash:   "task",
 		CreatedAt:  now,
-		Minutes:    20,
+		Seconds:    20 * 60,
 	})
 
 	timer, err := s.service.GetActiveTimer("team", "user")
 	s.Nil(err)
 	s.NotNil(timer)
 
-	s.Equal(timer.Minutes, 20)
+	s.Equal(timer.Seconds, 20*60)
 }
 
 func (s *TimerServiceTestSuite) TeststopTimer(t *testing.T) {
@@ -67,7 +67,7 @@ func (s *TimerServiceTestSuite) TeststopTimer(t *testing.T) {
 		TeamUserID: "user",
 		TaskHash:   "task",
 		CreatedAt:  timerStartedAt,
-		Minutes:    0,
+		Seconds:    0,
 	})
 
 	s.Nil(err)
@@ -78,8 +78,8 @@ func (s *TimerServiceTestSuite) TeststopTimer(t *testing.T) {
 	loadedTimer, err := s.repo.findByID(id.Hex())
 	s.Nil(err)
 
-	s.Equal(loadedTimer.Minutes, 20)
-	s.Equal(loadedTimer.ActualMinutes, 20)
+	s.Equal(loadedTimer.Seconds, 20*60)
+	s.Equal(loadedTimer.ActualSeconds, 20*60)
 	s.NotNil(loadedTimer.FinishedAt)
 }
 
@@ -117,10 +117,10 @@ func (s *TimerServiceTestSuite) TeststartTimer(t *testing.T) {
 	s.NotNil(loadedTimer.CreatedAt)
 	s.Nil(loadedTimer.FinishedAt)
 	s.Nil(loadedTimer.DeletedAt)
-	s.Equal(loadedTimer.Minutes, 0)
+	s.Equal(loadedTimer.Seconds, 0)
 }
 
-func (s *TimerServiceTestSuite) TesttotalMinutesForTodayAddsTimeForUnfinishedTask(t *testing.T) {
+func (s *TimerServiceTestSuite) TesttotalSecondsForTodayAddsTimeForUnfinishedTask(t *testing.T) {
 	now := time.Now()
 
 	offsetDuration1, _ := time.ParseDuration("20m")
@@ -137,7 +137,7 @@ func (s *TimerServiceTestSuite) TesttotalMinutesForTodayAddsTimeForUnfinishedTas
 		TaskHash:   "task",
 		CreatedAt:  now.Add(offsetDuration1 * -1),
 		FinishedAt: &firstTimerStartedAt,
-		Minutes:    10,
+		Seconds:    10 * 60,
 	})
 
 	timer, _ := s.repo.CreateTimer(&models.Timer{
@@ -148,14 +148,14 @@ func (s *TimerServiceTestSuite) TesttotalMinutesForTodayAddsTimeForUnfinishedTas
 		TaskHash:   "task",
 		CreatedAt:  secondTimerStartedAt,
 		FinishedAt: nil,
-		Minutes:    0,
+		Seconds:    0,
 	})
 
-	//c.Assert(s.service.TotalMinutesForTaskToday(timer), Equals, 15)
-	s.Equal(s.service.TotalMinutesForTaskToday(timer), 15)
+	//c.Assert(s.service.TotalSecondsForTaskToday(timer), Equals, 15)
+	s.Equal(s.service.TotalSecondsForTaskToday(timer), 15*60)
 }
 
-func (s *TimerServiceTestSuite) TesttotalCompletedMinutesForDay(t *testing.T) {
+func (s *TimerServiceTestSuite) TesttotalCompletedSecondsForDay(t *testing.T) {
 	now := time.Now()
 
 	user := &models.TeamUser{
@@ -173,7 +173,7 @@ func (s *TimerServiceTestSuite) TesttotalCompletedMinutesForDay(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 8:00:00"), // which is 11:00 in Kiev
 		FinishedAt: &now,
-		Minutes:    2,
+		Seconds:    2 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -184,7 +184,7 @@ func (s *TimerServiceTestSuite) TesttotalCompletedMinutesForDay(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 19:30:00"), // which is 22:30 in Kiev
 		FinishedAt: &now,
-		Minutes:    3,
+		Seconds:    3 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -195,14 +195,14 @@ func (s *TimerServiceTestSuite) TesttotalCompletedMinutesForDay(t *testing.T) {
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 22:00:00"), // which is 1am of the next day in Kiev
 		FinishedAt: &now,
-		Minutes:    7,
+		Seconds:    7 * 60,
 	})
 
 	targetDate := utils.PT("2016 Sep 12 00:00:00")
-	s.Equal(s.service.TotalCompletedMinutesForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user), 5)
+	s.Equal(s.service.TotalCompletedSecondsForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user), 5*60)
 
 	targetDate = utils.PT("2016 Sep 13 00:00:00")
-	s.Equal(s.service.TotalCompletedMinutesForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user), 7)
+	s.Equal(s.service.TotalCompletedSecondsForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user), 7*60)
 }
 
 func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayPositiveTZOffset(t *testing.T) {
@@ -224,7 +224,7 @@ func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayPositiveTZOffset(t *t
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 8:00:00"), // which is 11:00 in Kiev
 		FinishedAt: &now,
-		Minutes:    2,
+		Seconds:    2 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -235,7 +235,7 @@ func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayPositiveTZOffset(t *t
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 19:30:00"), // which is 22:30 in Kiev
 		FinishedAt: &now,
-		Minutes:    3,
+		Seconds:    3 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -246,20 +246,20 @@ func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayPositiveTZOffset(t *t
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 22:00:00"), // which is 1am of the next day in Kiev
 		FinishedAt: &now,
-		Minutes:    7,
+		Seconds:    7 * 60,
 	})
 
 	targetDate := utils.PT("2016 Sep 12 00:00:00")
 	v, err := s.service.GetCompletedTasksForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user)
 	s.Nil(err)
 	s.Equal(len(v), 1)
-	s.Equal(v[0].Minutes, 5)
+	s.Equal(v[0].Seconds, 5*60)
 
 	targetDate = utils.PT("2016 Sep 13 00:00:00")
 	v, err = s.service.GetCompletedTasksForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user)
 	s.Nil(err)
 	s.Equal(len(v), 1)
-	s.Equal(v[0].Minutes, 7)
+	s.Equal(v[0].Seconds, 7*60)
 }
 
 func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayNegativeTZOffset(t *testing.T) {
@@ -281,7 +281,7 @@ func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayNegativeTZOffset(t *t
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 12 15:00:00"), // which is 10:00 in Nashville
 		FinishedAt: &now,
-		Minutes:    2,
+		Seconds:    2 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -292,7 +292,7 @@ func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayNegativeTZOffset(t *t
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 13 03:30:00"), // which is 22:30 in Nashville
 		FinishedAt: &now,
-		Minutes:    3,
+		Seconds:    3 * 60,
 	})
 
 	s.repo.CreateTimer(&models.Timer{
@@ -303,21 +303,21 @@ func (s *TimerServiceTestSuite) TestgetCompletedTasksForDayNegativeTZOffset(t *t
 		TaskHash:   "task",
 		CreatedAt:  utils.PT("2016 Sep 13 06:00:00"), // which is 1am of the next day in Nashville
 		FinishedAt: &now,
-		Minutes:    7,
+		Seconds:    7 * 60,
 	})
 
 	targetDate := utils.PT("2016 Sep 12 00:00:00")
 	v, err := s.service.GetCompletedTasksForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user)
 	s.Nil(err)
 	s.Equal(len(v), 1)
-	s.Equal(v[0].Minutes, 5)
+	s.Equal(v[0].Seconds, 5*60)
 
 	targetDate = utils.PT("2016 Sep 13 00:00:00")
 
 	v, err = s.service.GetCompletedTasksForDay(targetDate.Year(), targetDate.Month(), targetDate.Day(), user)
 	s.Nil(err)
 	s.Equal(len(v), 1)
-	s.Equal(v[0].Minutes, 7)
+	s.Equal(v[0].Seconds, 7*60)
 }
 
 // CompleteActiveTimersAtMidnight
@@ -360,12 +360,12 @@ func (s *TimerServiceTestSuite) TestcompleteActiveTimersAtMidnight(t *testing.T)
 	timer, err := s.repo.findByID(t1ID.Hex())
 	s.Nil(err)
 	s.NotNil(timer.FinishedAt)
-	s.Equal(timer.Minutes, 20-1)
+	s.Equal(timer.Seconds, (20-1)*60)
 
 	timer, err = s.repo.findByID(t2ID.Hex())
 	s.Nil(err)
 	s.Nil(timer.FinishedAt)
-	s.Equal(timer.Minutes, 0)
+	s.Equal(timer.Seconds, 0)
 }
 
 func (s *TimerServiceTestSuite) TestGetUserTasksByRange(t *testing.T) {
@@ -383,7 +383,7 @@ func (s *TimerServiceTestSuite) TestGetUserTasksByRange(t *testing.T) {
 		ProjectID:  "project",
 		TeamUserID: user.ID.Hex(),
 		CreatedAt:  utils.PT("2016 Dec 20 10:35:00"),
-		Minutes:    20,
+		Seconds:    20 * 60,
 	})
 	s.repo.CreateTimer(&models.Timer{
 		ID:         bson.NewObjectId(),
@@ -391,7 +391,7 @@ func (s *TimerServiceTestSuite) TestGetUserTasksByRange(t *testing.T) {
 		ProjectID:  "project",
 		TeamUserID: user.ID.Hex(),
 		CreatedAt:  utils.PT("2016 Dec 21 10:35:00"),
-		Minutes:    20,
+		Seconds:    20 * 60,
 	})
 
 	timers, err := s.service.GetUserTimersByRange("2016-12-20", "2016-12-21", user)
@@ -427,21 +427,174 @@ func (s *TimerServiceTestSuite) TestGetUserTasksByRangeFail(t *testing.T) {
 	s.Err(err)
 	s.Len(timers, 0)
 
-	//Should return an error, with range more than 31 day
-	startDate = "2016-11-30"
+	//Ranges of any length are allowed
+	startDate = "2016-01-01"
 	endDate = "2016-12-31"
 
 	timers, err = s.service.GetUserTimersByRange(startDate, endDate, user)
-	s.Err(err)
-	s.Equal(err.Error(), "Too much days in range")
+	s.Nil(err)
 	s.Len(timers, 0)
 }
 
+func (s *TimerServiceTestSuite) TestUserTimers(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{}}
+	finishedAt := utils.PT("2016 Dec 20 18:00:00")
+
+	for i, taskName := range []string{"Fix login", "Review", "fix signup", "Deploy"} {
+		timer := &models.Timer{
+			ID:         bson.NewObjectId(),
+			TeamID:     "team",
+			ProjectID:  "project",
+			TeamUserID: user.ID.Hex(),
+			TaskName:   taskName,
+			TaskHash:   taskName,
+			CreatedAt:  utils.PT("2016 Dec 20 10:00:00").Add(time.Duration(i) * time.Hour),
+			FinishedAt: &finishedAt,
+			Seconds:    (i + 1) * 600,
+		}
+		if i == 3 {
+			timer.FinishedAt = nil
+			timer.Tags = []string{"ops"}
+		}
+		s.repo.CreateTimer(timer)
+	}
+
+	timers, pagination, err := s.service.UserTimers(user, &TimersFilter{Limit: 3})
+	s.Nil(err)
+	s.Len(timers, 3)
+	s.Equal(timers[0].TaskName, "Fix login")
+	s.Equal(pagination.Total, 4)
+	s.NotEqual(pagination.NextCursor, "")
+
+	// a timer inserted meanwhile before the cursor does not shift the next page
+	s.repo.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamUserID: user.ID.Hex(),
+		TaskName:   "Standup",
+		CreatedAt:  utils.PT("2016 Dec 20 09:00:00"),
+	})
+	timers, pagination, err = s.service.UserTimers(user, &TimersFilter{Limit: 3, Cursor: pagination.NextCursor})
+	s.Nil(err)
+	s.Len(timers, 1)
+	s.Equal(timers[0].TaskName, "Deploy")
+	s.Equal(pagination.Total, 5)
+	s.Equal(pagination.NextCursor, "")
+
+	timers, _, _ = s.service.UserTimers(user, &TimersFilter{Text: "FIX", Sort: "-seconds"})
+	s.Len(timers, 2)
+	s.Equal(timers[0].TaskName, "fix signup")
+
+	timers, _, _ = s.service.UserTimers(user, &TimersFilter{State: TimerStateRunning})
+	s.Len(timers, 2)
+
+	timers, _, _ = s.service.UserTimers(user, &TimersFilter{Tag: "ops"})
+	s.Len(timers, 1)
+
+	timers, _, _ = s.service.UserTimers(user, &TimersFilter{TaskHash: "Review", StartDate: utils.PT("2016 Dec 20 00:00:00")})
+	s.Len(timers, 1)
+
+	_, _, err = s.service.UserTimers(user, &TimersFilter{Sort: "-seconds", Cursor: encodeTimersCursor("created_at", timers[0])})
+	s.Err(err)
+	s.Equal(err.Error(), "the cursor belongs to a list sorted differently")
+}
+
+func (s *TimerServiceTestSuite) TestSearchTasks(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleMember, SlackUserInfo: &slack.User{}}
+	other := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleMember, SlackUserInfo: &slack.User{}}
+
+	timers := []*models.Timer{
+		{TeamUserID: user.ID.Hex(), TaskName: "Fix login bug", TaskHash: "fix", CreatedAt: utils.PT("2016 Mar 03 10:00:00"), Seconds: 600},
+		{TeamUserID: user.ID.Hex(), TaskName: "Fix login bug", TaskHash: "fix", CreatedAt: utils.PT("2016 Mar 20 10:00:00"), Seconds: 1200},
+		{TeamUserID: user.ID.Hex(), TaskName: "Login page design", TaskHash: "design", CreatedAt: utils.PT("2016 Apr 01 10:00:00"), Seconds: 300},
+		{TeamUserID: user.ID.Hex(), TaskName: "Deploy", TaskHash: "deploy", CreatedAt: utils.PT("2016 Apr 02 10:00:00"), Seconds: 300},
+		{TeamUserID: other.ID.Hex(), TaskName: "Login bug", TaskHash: "other", CreatedAt: utils.PT("2016 Apr 02 10:00:00"), Seconds: 300},
+	}
+	for _, timer := range timers {
+		timer.ID = bson.NewObjectId()
+		timer.TeamID = "team"
+		timer.ProjectID = "project"
+		s.repo.CreateTimer(timer)
+	}
+
+	results, err := s.service.SearchTasks(user, &SearchFilter{Text: "login"})
+	s.Nil(err)
+	s.Len(results, 2)
+
+	results, err = s.service.SearchTasks(user, &SearchFilter{Text: "\"login bug\""})
+	s.Nil(err)
+	s.Len(results, 1)
+	s.Equal(results[0].TaskHash, "fix")
+	s.Equal(results[0].TaskName, "Fix login bug")
+	s.Equal(results[0].Seconds, 1800)
+	s.Equal(results[0].TimersCount, 2)
+	s.Equal(results[0].FirstStartedAt, utils.PT("2016 Mar 03 10:00:00"))
+	s.Equal(results[0].LastStartedAt, utils.PT("2016 Mar 20 10:00:00"))
+
+	results, _ = s.service.SearchTasks(user, &SearchFilter{Text: "login", StartDate: utils.PT("2016 Mar 25 00:00:00")})
+	s.Len(results, 1)
+	s.Equal(results[0].TaskHash, "design")
+
+	_, err = s.service.SearchTasks(user, &SearchFilter{Text: "  "})
+	s.Err(err)
+
+	// the time of the others is searched by those who see team reports only
+	_, err = s.service.SearchTasks(user, &SearchFilter{Text: "login", UserIDs: []string{other.ID.Hex()}})
+	s.Equal(err, ErrForbidden)
+
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleAdmin, SlackUserInfo: &slack.User{}}
+	results, err = s.service.SearchTasks(admin, &SearchFilter{Text: "bug", UserIDs: []string{user.ID.Hex(), other.ID.Hex()}})
+	s.Nil(err)
+	s.Len(results, 2)
+}
+
+func (s *TimerServiceTestSuite) TestSuggestTasks(t *testing.T) {
+	now := utils.PT("2016 Apr 30 10:00:00")
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleMember, SlackUserInfo: &slack.User{}}
+	other := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleMember, SlackUserInfo: &slack.User{}}
+
+	timers := []*models.Timer{
+		{TeamUserID: user.ID.Hex(), ProjectID: "project", TaskName: "Fix login bug", TaskHash: "fix", CreatedAt: utils.PT("2016 Apr 28 10:00:00")},
+		{TeamUserID: user.ID.Hex(), ProjectID: "project", TaskName: "fix Login bug", TaskHash: "fix", CreatedAt: utils.PT("2016 Apr 29 10:00:00")},
+		{TeamUserID: other.ID.Hex(), ProjectID: "project", TaskName: "Login page design", TaskHash: "design", CreatedAt: utils.PT("2016 Apr 29 12:00:00")},
+		{TeamUserID: other.ID.Hex(), ProjectID: "other", TaskName: "Logging", TaskHash: "logging", CreatedAt: utils.PT("2016 Apr 29 12:00:00")},
+		{TeamUserID: user.ID.Hex(), ProjectID: "project", TaskName: "Blog post", TaskHash: "blog", CreatedAt: utils.PT("2016 Apr 29 12:00:00")},
+		{TeamUserID: user.ID.Hex(), ProjectID: "project", TaskName: "Login form", TaskHash: "form", CreatedAt: utils.PT("2015 Dec 01 10:00:00")},
+	}
+	for _, timer := range timers {
+		timer.ID = bson.NewObjectId()
+		timer.TeamID = "team"
+		s.repo.CreateTimer(timer)
+	}
+
+	suggestions, err := s.service.SuggestTasks(user, "project", "log", 0, now)
+	s.Nil(err)
+	s.Len(suggestions, 2)
+	s.Equal(suggestions[0].TaskHash, "fix")
+	s.Equal(suggestions[0].TaskName, "fix Login bug")
+	s.Equal(suggestions[0].ProjectID, "project")
+	s.Equal(suggestions[0].TimersCount, 2)
+	s.Equal(suggestions[0].OwnTimersCount, 2)
+	s.Equal(suggestions[0].LastStartedAt, utils.PT("2016 Apr 29 10:00:00"))
+	s.Equal(suggestions[1].TaskHash, "design")
+
+	suggestions, _ = s.service.SuggestTasks(user, "", "log", 0, now)
+	s.Len(suggestions, 3)
+
+	suggestions, _ = s.service.SuggestTasks(user, "", "", 1, now)
+	s.Len(suggestions, 1)
+	s.Equal(suggestions[0].TaskHash, "fix")
+
+	suggestions, _ = s.service.SuggestTasks(user, "", "log.*", 0, now)
+	s.Len(suggestions, 0)
+}
+
 func (s *TimerServiceTestSuite) TestUpdateUserTimer(t *testing.T) {
+	team, project := s.createTeamWithProject()
+
 	user := &models.TeamUser{
 		ID:             bson.NewObjectId(),
 		ExternalUserID: "user",
-		TeamID:		"team",
+		TeamID:		team.ID.Hex(),
 		SlackUserInfo: &slack.User{
 			TZOffset: 10800,
 		},
@@ -450,14 +603,14 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimer(t *testing.T) {
 	timer := &models.Timer{
 		ID:			bson.NewObjectId(),
 		TaskName:		"task-name",
-		TeamID:			"team",
+		TeamID:			team.ID.Hex(),
 		ProjectID:		"project-id",
 		ProjectExternalID:	"project-external-id",
 		ProjectExternalName:	"project-external-name",
 		TeamUserID:		user.ID.Hex(),
 		CreatedAt:		utils.PT("2016 Dec 20 10:35:00"),
-		Minutes:		20,
-		ActualMinutes:		20,
+		Seconds:		20 * 60,
+		ActualSeconds:		20 * 60,
 	}
 	s.repo.CreateTimer(timer)
 
@@ -465,15 +618,15 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimer(t *testing.T) {
 		ID:			bson.NewObjectId(),
 		TaskName:		"new-task-name",
 		TeamID:			"new-team-id",
-		ProjectID:		"new-project-id",
+		ProjectID:		project.ID.Hex(),
 		ProjectExternalID:	"new-project-external-id",
 		ProjectExternalName:	"new-project-external-name",
 		TeamUserID:		bson.NewObjectId().Hex(),
 		CreatedAt:		utils.PT("2016 Dec 20 12:50:00"),
-		Minutes:		40,
-		ActualMinutes:		40,
+		Seconds:		40 * 60,
+		ActualSeconds:		40 * 60,
 		Edits:	[]*models.TimeEdit{
-			{TeamUserID: user.ID.Hex(), CreatedAt: time.Now(), Minutes: 10},
+			{TeamUserID: user.ID.Hex(), CreatedAt: time.Now(), Seconds: 10 * 60},
 		},
 	}
 
@@ -484,18 +637,19 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimer(t *testing.T) {
 	s.Equal(timer.Edits, newTimerData.Edits)
 	s.Equal(timer.TaskName, newTimerData.TaskName)
 	s.Equal(timer.ProjectID, newTimerData.ProjectID)
-	s.Equal(timer.ProjectExternalID, newTimerData.ProjectExternalID)
-	s.Equal(timer.ProjectExternalName, newTimerData.ProjectExternalName)
+	// the external ID and name come from the team, not from the request
+	s.Equal(timer.ProjectExternalID, "other-channel-id")
+	s.Equal(timer.ProjectExternalName, "other-channel-name")
 	// Check calculated params
-	s.Equal(timer.Minutes, 30)
-	s.Equal(timer.ActualMinutes, 20)
+	s.Equal(timer.Seconds, 30*60)
+	s.Equal(timer.ActualSeconds, 20*60)
 	// Check for other parameters didn't change
 	s.NotEqual(timer.ID, newTimerData.ID)
 	s.NotEqual(timer.TeamID, newTimerData.TeamID)
 	s.NotEqual(timer.TeamUserID, newTimerData.TeamUserID)
-	s.NotEqual(timer.Minutes, newTimerData.Minutes)
-	s.NotEqual(timer.Minutes, newTimerData.Minutes)
-	s.NotEqual(timer.ActualMinutes, newTimerData.ActualMinutes)
+	s.NotEqual(timer.Seconds, newTimerData.Seconds)
+	s.NotEqual(timer.Seconds, newTimerData.Seconds)
+	s.NotEqual(timer.ActualSeconds, newTimerData.ActualSeconds)
 	s.NotEqual(timer.CreatedAt, newTimerData.CreatedAt)
 }
 
@@ -536,10 +690,12 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimerWithWrongUserID(t *testing.T)
 }
 
 func (s *TimerServiceTestSuite) TestUpdateUserTimerForSlackOwner(t *testing.T) {
+	team, project := s.createTeamWithProject()
+
 	user := &models.TeamUser{
 		ID:             bson.NewObjectId(),
 		ExternalUserID: "user",
-		TeamID:		"team",
+		TeamID:		team.ID.Hex(),
 		SlackUserInfo: &slack.User{
 			TZOffset: 10800,
 			IsOwner:  true,
@@ -549,14 +705,14 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimerForSlackOwner(t *testing.T) {
 	timer := &models.Timer{
 		ID:			bson.NewObjectId(),
 		TaskName:		"task-name",
-		TeamID:			"team",
+		TeamID:			team.ID.Hex(),
 		ProjectID:		"project-id",
 		ProjectExternalID:	"project-external-id",
 		ProjectExternalName:	"project-external-name",
 		TeamUserID:		bson.NewObjectId().Hex(),
 		CreatedAt:		utils.PT("2016 Dec 20 10:35:00"),
-		Minutes:		20,
-		ActualMinutes:		20,
+		Seconds:		20 * 60,
+		ActualSeconds:		20 * 60,
 	}
 	s.repo.CreateTimer(timer)
 
@@ -564,15 +720,15 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimerForSlackOwner(t *testing.T) {
 		ID:			bson.NewObjectId(),
 		TaskName:		"new-task-name",
 		TeamID:			"new-team-id",
-		ProjectID:		"new-project-id",
+		ProjectID:		project.ID.Hex(),
 		ProjectExternalID:	"new-project-external-id",
 		ProjectExternalName:	"new-project-external-name",
 		TeamUserID:		bson.NewObjectId().Hex(),
 		CreatedAt:		utils.PT("2016 Dec 20 12:50:00"),
-		Minutes:		40,
-		ActualMinutes:		40,
+		Seconds:		40 * 60,
+		ActualSeconds:		40 * 60,
 		Edits:	[]*models.TimeEdit{
-			{TeamUserID: user.ID.Hex(), CreatedAt: time.Now(), Minutes: 10},
+			{TeamUserID: user.ID.Hex(), CreatedAt: time.Now(), Seconds: 10 * 60},
 		},
 	}
 
@@ -580,8 +736,67 @@ func (s *TimerServiceTestSuite) TestUpdateUserTimerForSlackOwner(t *testing.T) {
 	s.Nil(err)
 	s.Equal(timer.TaskName, newTimerData.TaskName)
 	s.Equal(timer.ProjectID, newTimerData.ProjectID)
-	s.Equal(timer.ProjectExternalID, newTimerData.ProjectExternalID)
-	s.Equal(timer.ProjectExternalName, newTimerData.ProjectExternalName)
+	// the external ID and name come from the team, not from the request
+	s.Equal(timer.ProjectExternalID, "other-channel-id")
+	s.Equal(timer.ProjectExternalName, "other-channel-name")
+}
+
+func (s *TimerServiceTestSuite) TestUpdateUserTimerForManager(t *testing.T) {
+	manager := &models.TeamUser{
+		ID:                bson.NewObjectId(),
+		TeamID:            "team",
+		Role:              models.RoleManager,
+		ManagedProjectIDs: []string{"managed-project"},
+		SlackUserInfo:     &slack.User{},
+	}
+
+	managedTimer := &models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     "team",
+		ProjectID:  "managed-project",
+		TeamUserID: bson.NewObjectId().Hex(),
+		CreatedAt:  utils.PT("2016 Dec 20 10:35:00"),
+	}
+	s.repo.CreateTimer(managedTimer)
+
+	otherTimer := &models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     "team",
+		ProjectID:  "other-project",
+		TeamUserID: bson.NewObjectId().Hex(),
+		CreatedAt:  utils.PT("2016 Dec 20 10:35:00"),
+	}
+	s.repo.CreateTimer(otherTimer)
+
+	err := s.service.UpdateUserTimer(manager, managedTimer, &models.Timer{TaskName: "new-task-name", ProjectID: "managed-project"})
+	s.Nil(err)
+
+	err = s.service.UpdateUserTimer(manager, otherTimer, &models.Timer{TaskName: "new-task-name"})
+	s.Err(err)
+	s.Equal(err.Error(), "update forbidden")
+
+	// moving a managed timer to a project out of the scope or to a project of another team is not allowed
+	team, project := s.createTeamWithProject()
+	movedTimer := &models.Timer{ID: bson.NewObjectId(), TeamID: team.ID.Hex(), ProjectID: "managed-project", TeamUserID: bson.NewObjectId().Hex()}
+	s.repo.CreateTimer(movedTimer)
+	teamManager := &models.TeamUser{ID: bson.NewObjectId(), TeamID: team.ID.Hex(), Role: models.RoleManager, ManagedProjectIDs: []string{"managed-project"}, SlackUserInfo: &slack.User{}}
+
+	err = s.service.UpdateUserTimer(teamManager, movedTimer, &models.Timer{TaskName: "moved", ProjectID: project.ID.Hex()})
+	s.Err(err)
+	s.Equal(err.Error(), "update forbidden")
+
+	err = s.service.UpdateUserTimer(teamManager, movedTimer, &models.Timer{TaskName: "moved", ProjectID: bson.NewObjectId().Hex()})
+	s.Err(err)
+	s.Equal(err.Error(), "project not found")
+	s.Equal(movedTimer.ProjectID, "managed-project")
+
+	viewer := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleViewer, SlackUserInfo: &slack.User{}}
+	ownTimer := &models.Timer{ID: bson.NewObjectId(), TeamID: "team", TeamUserID: viewer.ID.Hex()}
+	s.repo.CreateTimer(ownTimer)
+
+	err = s.service.DeleteUserTimer(viewer, ownTimer)
+	s.Err(err)
+	s.Equal(err.Error(), "delete forbidden")
 }
 
 func (s *TimerServiceTestSuite) TestDeleteUserTimer(t *testing.T) {
@@ -604,7 +819,7 @@ func (s *TimerServiceTestSuite) TestDeleteUserTimer(t *testing.T) {
 		ProjectExternalName:	"project-external-name",
 		TeamUserID:		user.ID.Hex(),
 		CreatedAt:		time.Now().Add(time.Duration(-timerMinutes) * time.Minute),
-		Minutes:		timerMinutes,
+		Seconds:		timerMinutes * 60,
 	}
 	s.repo.CreateTimer(timer)
 
@@ -641,8 +856,8 @@ func (s *TimerServiceTestSuite) TestDeleteUserActiveTimer(t *testing.T) {
 	s.Nil(err)
 
 	s.NotNil(timer.DeletedAt)
-	s.Equal(timer.Minutes, timerMinutes)
-	s.Equal(timer.ActualMinutes, timerMinutes)
+	s.Equal(timer.Seconds, timerMinutes*60)
+	s.Equal(timer.ActualSeconds, timerMinutes*60)
 }
 
 func (s *TimerServiceTestSuite) TestDeleteUserTimerWithWrongUserID(t *testing.T) {
@@ -697,16 +912,45 @@ func (s *TimerServiceTestSuite) TestUserMonthStatistics(t *testing.T) {
 			CreatedAt:		startDate.AddDate(0, 0, i),
 			FinishedAt:		&finished,
 			TeamUserTZOffset:	user.SlackUserInfo.TZOffset,
-			Minutes:		m,
-			ActualMinutes:		m,
+			Seconds:		m * 60,
+			ActualSeconds:		m * 60,
 		})
 	}
 
 	result, err := s.service.UserMonthStatistics(user, date)
 	s.Nil(err)
 	s.Len(result, days)
-	s.Equal(result[0].Minutes, 10)
-	s.Equal(result[days - 1].Minutes, 40)
+	s.Equal(result[0].Seconds, 10*60)
+	s.Equal(result[days - 1].Seconds, 40*60)
+}
+
+func (s *TimerServiceTestSuite) TestUserMonthStatisticsMidMonth(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{TZOffset: 3600}}
+
+	createdAt := []string{"2016 Nov 30 22:30:00", "2016 Nov 30 23:30:00", "2016 Dec 20 10:00:00", "2016 Dec 31 23:30:00"}
+	for _, value := range createdAt {
+		created := utils.PT(value)
+		finished := created.Add(10 * time.Minute)
+		s.repo.CreateTimer(&models.Timer{
+			ID:                  bson.NewObjectId(),
+			TeamID:              "team",
+			ProjectID:           "project",
+			ProjectExternalName: "project_name",
+			TeamUserID:          user.ID.Hex(),
+			CreatedAt:           created,
+			FinishedAt:          &finished,
+			Seconds:             600,
+			ActualSeconds:       600,
+		})
+	}
+
+	// a date in the middle of the month stands for the whole calendar month of user's timezone,
+	// not for the month which starts at the date
+	result, err := s.service.UserMonthStatistics(user, "2016-12-15")
+	s.Nil(err)
+	s.Len(result, 2)
+	s.Equal(result[0].Date, "2016-12-01")
+	s.Equal(result[1].Date, "2016-12-20")
 }
 
 func (s *TimerServiceTestSuite) TestUserMonthStatisticsWithWrongDate(t *testing.T) {
@@ -725,6 +969,347 @@ func (s *TimerServiceTestSuite) TestUserMonthStatisticsWithWrongDate(t *testing.
 	}
 }
 
+func (s *TimerServiceTestSuite) TestUserStatistics(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{TZOffset: 3600}}
+
+	createdAt := []string{"2016 Nov 13 23:30:00", "2016 Nov 14 10:00:00", "2016 Dec 31 23:30:00", "2017 Jan 02 10:00:00"}
+	for _, value := range createdAt {
+		created := utils.PT(value)
+		finished := created.Add(10 * time.Minute)
+		s.repo.CreateTimer(&models.Timer{
+			ID:                  bson.NewObjectId(),
+			TeamID:              "team",
+			ProjectID:           "project",
+			ProjectExternalName: "project_name",
+			TeamUserID:          user.ID.Hex(),
+			CreatedAt:           created,
+			FinishedAt:          &finished,
+			Seconds:             600,
+			ActualSeconds:       600,
+		})
+	}
+
+	// 23:30 UTC is past midnight in user's timezone
+	result, err := s.service.UserStatistics(user, StatisticsPeriodWeek, "2016-11-16")
+	s.Nil(err)
+	s.Len(result, 1)
+	s.Equal(result[0].Date, "2016-11-14")
+	s.Equal(result[0].Day, 14)
+	s.Equal(result[0].Seconds, 1200)
+
+	result, _ = s.service.UserStatistics(user, StatisticsPeriodQuarter, "2016-12-1")
+	s.Len(result, 1)
+	s.Equal(result[0].Date, "2016-W46")
+	s.Equal(result[0].Seconds, 1200)
+	s.Equal(result[0].Projects[0].ProjectExternalName, "project_name")
+
+	result, _ = s.service.UserStatistics(user, StatisticsPeriodYear, "2017-3-1")
+	s.Len(result, 1)
+	s.Equal(result[0].Date, "2017-01")
+	s.Equal(result[0].Seconds, 1200)
+
+	_, err = s.service.UserStatistics(user, "decade", "2017-3-1")
+	s.Err(err)
+
+	heatmap, err := s.service.UserHeatmap(user, "2017-1-2")
+	s.Nil(err)
+	s.Equal(heatmap[0].Date, "2016-01-04")
+	s.Equal(heatmap[len(heatmap)-1].Date, "2017-01-02")
+	s.Equal(heatmap[len(heatmap)-1].Level, 2)
+	seconds := 0
+	for _, day := range heatmap {
+		seconds += day.Seconds
+	}
+	s.Equal(seconds, 4*600)
+}
+
+func (s *TimerServiceTestSuite) TestTeamReport(t *testing.T) {
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleAdmin, SlackUserInfo: &slack.User{}}
+	s.createReportTimers()
+
+	filter := &TeamReportFilter{
+		StartDate: utils.PT("2016 Dec 01 00:00:00"),
+		EndDate:   utils.PT("2016 Dec 31 23:59:59"),
+		GroupBy:   []string{ReportGroupByUser, ReportGroupByProject},
+	}
+
+	result, err := s.service.TeamReport(admin, filter)
+	s.Nil(err)
+	s.Len(result, 3)
+
+	total := 0
+	for _, row := range result {
+		s.NotZero(row.TeamUserID)
+		s.NotZero(row.ProjectID)
+		s.Zero(row.Period)
+		total += row.Seconds
+	}
+	s.Equal(total, 100)
+
+	filter.GroupBy = []string{ReportGroupByDay}
+	filter.Tags = []string{"billable"}
+	result, err = s.service.TeamReport(admin, filter)
+	s.Nil(err)
+	s.Len(result, 2)
+	s.Equal(result[0].Period, "2016-12-01")
+	s.Equal(result[0].Seconds, 10*60)
+	s.Equal(result[1].Period, "2016-12-02")
+	s.Equal(result[1].Seconds, 20*60)
+}
+
+func (s *TimerServiceTestSuite) TestTeamReportForManager(t *testing.T) {
+	manager := &models.TeamUser{
+		ID:                bson.NewObjectId(),
+		TeamID:            "team",
+		Role:              models.RoleManager,
+		ManagedProjectIDs: []string{"project-1"},
+		SlackUserInfo:     &slack.User{},
+	}
+	s.createReportTimers()
+
+	filter := &TeamReportFilter{
+		StartDate: utils.PT("2016 Dec 01 00:00:00"),
+		EndDate:   utils.PT("2016 Dec 31 23:59:59"),
+		GroupBy:   []string{ReportGroupByProject},
+	}
+
+	result, err := s.service.TeamReport(manager, filter)
+	s.Nil(err)
+	s.Len(result, 1)
+	s.Equal(result[0].ProjectID, "project-1")
+	s.Equal(result[0].ProjectExternalName, "project-1-name")
+	s.Equal(result[0].Seconds, 80*60)
+
+	filter.ProjectIDs = []string{"project-2"}
+	_, err = s.service.TeamReport(manager, filter)
+	s.Equal(err, ErrForbidden)
+}
+
+func (s *TimerServiceTestSuite) TestTeamReportFailures(t *testing.T) {
+	member := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleMember, SlackUserInfo: &slack.User{}}
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleAdmin, SlackUserInfo: &slack.User{}}
+
+	_, err := s.service.TeamReport(member, &TeamReportFilter{})
+	s.Equal(err, ErrForbidden)
+
+	_, err = s.service.TeamReport(admin, &TeamReportFilter{GroupBy: []string{"year"}})
+	s.Equal(err.Error(), "unknown grouping `year`")
+
+	_, err = s.service.TeamReport(admin, &TeamReportFilter{GroupBy: []string{ReportGroupByDay, ReportGroupByMonth}})
+	s.Err(err)
+}
+
+func (s *TimerServiceTestSuite) TestTimesheetGrid(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{}}
+	finishedAt := utils.PT("2016 Dec 05 11:00:00")
+
+	s.repo.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     "team",
+		ProjectID:  "project",
+		TeamUserID: user.ID.Hex(),
+		TaskHash:   "task",
+		TaskName:   "task",
+		CreatedAt:  utils.PT("2016 Dec 05 10:00:00"),
+		FinishedAt: &finishedAt,
+		Seconds:    60 * 60,
+	})
+
+	s.repo.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     "team",
+		ProjectID:  "project",
+		TeamUserID: user.ID.Hex(),
+		TaskHash:   "task",
+		TaskName:   "task",
+		CreatedAt:  utils.PT("2016 Dec 11 23:00:00"),
+		FinishedAt: &finishedAt,
+		Seconds:    30 * 60,
+	})
+
+	// belongs to the next week
+	s.repo.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     "team",
+		ProjectID:  "project",
+		TeamUserID: user.ID.Hex(),
+		TaskHash:   "task",
+		CreatedAt:  utils.PT("2016 Dec 12 00:10:00"),
+		FinishedAt: &finishedAt,
+		Seconds:    15 * 60,
+	})
+
+	grid, err := s.service.TimesheetGrid(user, "2016-12-7")
+	s.Nil(err)
+	s.Equal(grid.Days[0], "2016-12-05")
+	s.Equal(grid.Days[6], "2016-12-11")
+	s.Len(grid.Rows, 1)
+	s.Equal(grid.Rows[0].Seconds, []int{60 * 60, 0, 0, 0, 0, 0, 30 * 60})
+	s.Equal(grid.Rows[0].Total, 90*60)
+	s.Equal(grid.DayTotals, []int{60 * 60, 0, 0, 0, 0, 0, 30 * 60})
+	s.Equal(grid.Total, 90*60)
+}
+
+func (s *TimerServiceTestSuite) TestSaveTimesheetGrid(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{}}
+	project := &models.Project{ID: bson.NewObjectId(), ExternalProjectID: "ext-project", ExternalProjectName: "general"}
+	team := &models.Team{ID: bson.NewObjectId(), Projects: []*models.Project{project}}
+	finishedAt := utils.PT("2016 Dec 05 11:00:00")
+
+	timer := &models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     team.ID.Hex(),
+		ProjectID:  project.ID.Hex(),
+		TeamUserID: user.ID.Hex(),
+		TaskHash:   taskSHA256(team.ID.Hex(), project.ID.Hex(), "task"),
+		TaskName:   "task",
+		CreatedAt:  utils.PT("2016 Dec 05 10:00:00"),
+		FinishedAt: &finishedAt,
+		Seconds:    60 * 60,
+	}
+	s.repo.CreateTimer(timer)
+
+	unapplied, err := s.service.SaveTimesheetGrid(user, team, "2016-12-5", []*models.TimesheetGridCell{
+		{ProjectID: project.ID.Hex(), TaskName: "task", Date: "2016-12-5", Seconds: 90 * 60},
+		{ProjectID: project.ID.Hex(), TaskName: "new task", Date: "2016-12-6", Seconds: 45 * 60},
+	})
+	s.Nil(err)
+	s.Len(unapplied, 0)
+
+	updated, err := s.repo.findByID(timer.ID.Hex())
+	s.Nil(err)
+	s.Equal(updated.Seconds, 90*60)
+	s.Len(updated.Edits, 1)
+	s.Equal(updated.Edits[0].Seconds, 30*60)
+
+	grid, err := s.service.TimesheetGrid(user, "2016-12-5")
+	s.Nil(err)
+	s.Len(grid.Rows, 2)
+	s.Equal(grid.Rows[1].TaskName, "new task")
+	s.Equal(grid.Rows[1].Seconds, []int{0, 45 * 60, 0, 0, 0, 0, 0})
+	s.Equal(grid.Total, 135*60)
+
+	_, err = s.service.SaveTimesheetGrid(user, team, "2016-12-5", []*models.TimesheetGridCell{
+		{ProjectID: project.ID.Hex(), TaskName: "task", Date: "2016-12-5", Seconds: 0},
+	})
+	s.Nil(err)
+
+	updated, _ = s.repo.findByID(timer.ID.Hex())
+	s.Equal(updated.Seconds, 0)
+	s.Len(updated.Edits, 2)
+
+	unapplied, err = s.service.SaveTimesheetGrid(user, team, "2016-12-5", []*models.TimesheetGridCell{
+		{ProjectID: "unknown", TaskName: "task", Date: "2016-12-5", Seconds: 10 * 60},
+	})
+	s.Err(err)
+	s.Len(unapplied, 1)
+	s.Equal(unapplied[0].Error, "project not found")
+}
+
+func (s *TimerServiceTestSuite) TestSaveTimesheetGridValidatesEveryCellFirst(t *testing.T) {
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{}}
+	project := &models.Project{ID: bson.NewObjectId(), ExternalProjectID: "ext-project", ExternalProjectName: "general"}
+	team := &models.Team{ID: bson.NewObjectId(), Projects: []*models.Project{project}}
+
+	unapplied, err := s.service.SaveTimesheetGrid(user, team, "2016-12-5", []*models.TimesheetGridCell{
+		{ProjectID: project.ID.Hex(), TaskName: "task", Date: "2016-12-5", Seconds: 60 * 60},
+		{ProjectID: project.ID.Hex(), TaskName: "task", Date: "2016-12-12", Seconds: 60 * 60},
+		{ProjectID: project.ID.Hex(), TaskName: "other task", Date: "2016-12-6", Seconds: 60 * 60},
+		{ProjectID: project.ID.Hex(), TaskName: "other task", Date: "2016-12-6", Seconds: 30 * 60},
+	})
+	s.Err(err)
+	s.Equal(err.Error(), "2 of 4 cells are invalid, nothing is saved")
+	s.Len(unapplied, 2)
+	s.Equal(unapplied[0].Error, "2016-12-12 is not a day of the week")
+	s.Equal(unapplied[1].Error, "`other task` is edited twice on 2016-12-6")
+
+	grid, err := s.service.TimesheetGrid(user, "2016-12-5")
+	s.Nil(err)
+	s.Len(grid.Rows, 0)
+}
+
+func (s *TimerServiceTestSuite) TestTeamReportByIssue(t *testing.T) {
+	admin := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleAdmin, SlackUserInfo: &slack.User{}}
+
+	issues := [][]*models.IssueReference{
+		{{Key: "PROJ-1", URL: "https://acme.atlassian.net/browse/PROJ-1"}},
+		{{Key: "PROJ-1", URL: "https://acme.atlassian.net/browse/PROJ-1"}, {Key: "PROJ-2", URL: "https://acme.atlassian.net/browse/PROJ-2"}},
+		nil,
+	}
+	for i, timerIssues := range issues {
+		createdAt := utils.PT("2016 Dec 01 10:00:00").Add(time.Duration(i) * time.Hour)
+		finishedAt := createdAt.Add(10 * time.Minute)
+		s.repo.CreateTimer(&models.Timer{
+			ID:         bson.NewObjectId(),
+			TeamID:     "team",
+			ProjectID:  "project",
+			TeamUserID: "user",
+			CreatedAt:  createdAt,
+			FinishedAt: &finishedAt,
+			Seconds:    10 * 60,
+			Issues:     timerIssues,
+		})
+	}
+
+	result, err := s.service.TeamReport(admin, &TeamReportFilter{
+		StartDate: utils.PT("2016 Dec 01 00:00:00"),
+		EndDate:   utils.PT("2016 Dec 31 23:59:59"),
+		GroupBy:   []string{ReportGroupByIssue},
+	})
+	s.Nil(err)
+	s.Len(result, 3)
+	s.Equal(result[0].Issue, "")
+	s.Equal(result[0].Seconds, 10*60)
+	s.Equal(result[1].Issue, "PROJ-1")
+	s.Equal(result[1].IssueURL, "https://acme.atlassian.net/browse/PROJ-1")
+	s.Equal(result[1].Seconds, 20*60)
+	s.Equal(result[2].Issue, "PROJ-2")
+	s.Equal(result[2].Seconds, 10*60)
+}
+
+func (s *TimerServiceTestSuite) createReportTimers() {
+	timers := []struct {
+		user, project string
+		createdAt     string
+		minutes       int
+		tags          []string
+	}{
+		{"user-1", "project-1", "2016 Dec 01 10:00:00", 10, []string{"billable"}},
+		{"user-1", "project-2", "2016 Dec 02 10:00:00", 20, []string{"billable", "meeting"}},
+		{"user-2", "project-1", "2016 Dec 02 11:00:00", 20, nil},
+		{"user-1", "project-1", "2016 Dec 03 11:00:00", 50, nil},
+	}
+
+	for _, row := range timers {
+		createdAt := utils.PT(row.createdAt)
+		finishedAt := createdAt.Add(time.Duration(row.minutes) * time.Minute)
+
+		s.repo.CreateTimer(&models.Timer{
+			ID:                  bson.NewObjectId(),
+			TeamID:              "team",
+			ProjectID:           row.project,
+			ProjectExternalName: row.project + "-name",
+			TeamUserID:          row.user,
+			CreatedAt:           createdAt,
+			FinishedAt:          &finishedAt,
+			Seconds:             row.minutes * 60,
+			Tags:                row.tags,
+		})
+	}
+
+	// timers of other teams never get into the report
+	finishedAt := utils.PT("2016 Dec 05 11:00:00")
+	s.repo.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     "another-team",
+		ProjectID:  "project-1",
+		TeamUserID: "user-3",
+		CreatedAt:  utils.PT("2016 Dec 05 10:00:00"),
+		FinishedAt: &finishedAt,
+		Seconds:    60 * 60,
+	})
+}
+
 func (s *TimerServiceTestSuite) SetUpSuite() {
 	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
 
@@ -745,6 +1330,19 @@ func (s *TimerServiceTestSuite) TearDownSuite() {
 	s.session.Close()
 }
 
+// createTeamWithProject creates a team with two projects and returns the second one
+func (s *TimerServiceTestSuite) createTeamWithProject() (*models.Team, *models.Project) {
+	teamRepository := NewTeamRepository(s.session)
+	team, err := teamRepository.CreateTeam("team-id", "team-name")
+	s.Nil(err)
+	s.Nil(teamRepository.AddProject(team, "channel-id", "channel-name"))
+	s.Nil(teamRepository.AddProject(team, "other-channel-id", "other-channel-name"))
+
+	team, err = teamRepository.FindByID(team.ID.Hex())
+	s.Nil(err)
+	return team, team.Projects[1]
+}
+
 func (s *TimerServiceTestSuite) SetUp() {
 	utils.TruncateTables(s.session)
 }
@@ -0,0 +1,169 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	defaultTimersPageLimit = 100
+	maxTimersPageLimit     = 1000
+)
+
+// Timer states a list of timers can be filtered by
+const (
+	TimerStateRunning  = "running"
+	TimerStateFinished = "finished"
+)
+
+// Orders of a list of timers, a leading minus reverses the order
+var timersSortFields = map[string]string{
+	"created_at":  "created_at",
+	"-created_at": "created_at",
+	"seconds":     "seconds",
+	"-seconds":    "seconds",
+}
+
+// TimersFilter describes a page of user's timers. Zero dates leave the range open, blank fields do not filter
+type TimersFilter struct {
+	UserID     string
+	ProjectIDs []string
+	TaskHash   string
+	Tag        string
+	// Text is looked for in task names regardless of the case
+	Text      string
+	State     string
+	StartDate time.Time
+	EndDate   time.Time
+	Sort      string
+	// Cursor is the NextCursor of the previous page, blank for the first page
+	Cursor string
+	Limit  int
+}
+
+// timersCursor - the sort key and the ID of the last timer of a page. The next page starts right after
+// that timer whatever is inserted meanwhile, so pages neither repeat nor skip timers
+type timersCursor struct {
+	Sort      string    `json:"s"`
+	CreatedAt time.Time `json:"t"`
+	Seconds   int       `json:"n"`
+	ID        string    `json:"id"`
+}
+
+func encodeTimersCursor(sort string, timer *models.Timer) string {
+	cursor := &timersCursor{Sort: sort, ID: timer.ID.Hex()}
+	if timersSortFields[sort] == "seconds" {
+		cursor.Seconds = timer.Seconds
+	} else {
+		cursor.CreatedAt = timer.CreatedAt
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTimersCursor(sort, value string) (*timersCursor, error) {
+	cursor := &timersCursor{}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(raw, cursor)
+	}
+	if err != nil || !bson.IsObjectIdHex(cursor.ID) {
+		return nil, errors.New("malformed cursor")
+	}
+
+	if cursor.Sort != sort {
+		return nil, errors.New("the cursor belongs to a list sorted differently")
+	}
+	return cursor, nil
+}
+
+// normalizeTimersFilter validates the filter and fills the defaults in
+func normalizeTimersFilter(filter *TimersFilter) error {
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+	}
+	if _, ok := timersSortFields[filter.Sort]; !ok {
+		return fmt.Errorf("unknown sort `%s`", filter.Sort)
+	}
+
+	switch filter.State {
+	case "", TimerStateRunning, TimerStateFinished:
+	default:
+		return fmt.Errorf("unknown timer state `%s`", filter.State)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTimersPageLimit
+	}
+	if filter.Limit > maxTimersPageLimit {
+		filter.Limit = maxTimersPageLimit
+	}
+	return nil
+}
+
+// timersQuery - the conditions of the filter except the cursor
+func timersQuery(filter *TimersFilter) bson.M {
+	query := bson.M{
+		"team_user_id": filter.UserID,
+		"deleted_at":   nil,
+	}
+
+	if len(filter.ProjectIDs) > 0 {
+		query["project_id"] = bson.M{"$in": filter.ProjectIDs}
+	}
+	if filter.TaskHash != "" {
+		query["task_hash"] = filter.TaskHash
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+	if filter.Text != "" {
+		query["task_name"] = bson.RegEx{Pattern: regexp.QuoteMeta(filter.Text), Options: "i"}
+	}
+
+	switch filter.State {
+	case TimerStateRunning:
+		query["finished_at"] = nil
+	case TimerStateFinished:
+		query["finished_at"] = bson.M{"$ne": nil}
+	}
+
+	createdAt := bson.M{}
+	if !filter.StartDate.IsZero() {
+		createdAt["$gte"] = filter.StartDate
+	}
+	if !filter.EndDate.IsZero() {
+		createdAt["$lte"] = filter.EndDate
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+	return query
+}
+
+// afterCursor - the condition of timers that follow the cursor in the order of the sort
+func afterCursor(sort string, cursor *timersCursor) bson.M {
+	field := timersSortFields[sort]
+	operator := "$gt"
+	if sort[0] == '-' {
+		operator = "$lt"
+	}
+
+	var value interface{} = cursor.CreatedAt
+	if field == "seconds" {
+		value = cursor.Seconds
+	}
+
+	return bson.M{"$or": []bson.M{
+		{field: bson.M{operator: value}},
+		{field: value, "_id": bson.M{operator: bson.ObjectIdHex(cursor.ID)}},
+	}}
+}
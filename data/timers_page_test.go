@@ -0,0 +1,48 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestTimersCursor(t *testing.T) {
+	s := is.New(t)
+
+	timer := &models.Timer{ID: bson.NewObjectId(), CreatedAt: utils.PT("2016 Dec 20 10:35:00"), Seconds: 1200}
+
+	cursor, err := decodeTimersCursor("-created_at", encodeTimersCursor("-created_at", timer))
+	s.Nil(err)
+	s.Equal(cursor.ID, timer.ID.Hex())
+	s.True(cursor.CreatedAt.Equal(timer.CreatedAt))
+
+	cursor, err = decodeTimersCursor("seconds", encodeTimersCursor("seconds", timer))
+	s.Nil(err)
+	s.Equal(cursor.Seconds, 1200)
+
+	_, err = decodeTimersCursor("created_at", encodeTimersCursor("seconds", timer))
+	s.Err(err)
+
+	_, err = decodeTimersCursor("created_at", "not a cursor")
+	s.Err(err)
+	s.Equal(err.Error(), "malformed cursor")
+}
+
+func TestNormalizeTimersFilter(t *testing.T) {
+	s := is.New(t)
+
+	filter := &TimersFilter{}
+	s.Nil(normalizeTimersFilter(filter))
+	s.Equal(filter.Sort, "created_at")
+	s.Equal(filter.Limit, defaultTimersPageLimit)
+
+	filter = &TimersFilter{Limit: 5000}
+	s.Nil(normalizeTimersFilter(filter))
+	s.Equal(filter.Limit, maxTimersPageLimit)
+
+	s.Err(normalizeTimersFilter(&TimersFilter{Sort: "task_name"}))
+	s.Err(normalizeTimersFilter(&TimersFilter{State: "paused"}))
+}
@@ -0,0 +1,251 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	daysInWeek      = 7
+	secondsInDay    = 24 * 60 * 60
+	manualTimerHour = 9
+)
+
+// TimesheetGrid builds the project/task by day matrix of the user's week the date belongs to
+func (s *TimerService) TimesheetGrid(user *models.TeamUser, date string) (*models.TimesheetGrid, error) {
+	weekStart, startsAt, endsAt, err := timesheetWeek(date, user)
+	if err != nil {
+		return nil, err
+	}
+
+	timers, err := s.repository.findUserTasksByRange(user.ID.Hex(), startsAt, endsAt.Add(-time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	grid := &models.TimesheetGrid{
+		WeekStart: weekStart,
+		Days:      make([]string, daysInWeek),
+		Rows:      []*models.TimesheetGridRow{},
+		DayTotals: make([]int, daysInWeek),
+	}
+
+	for i := 0; i < daysInWeek; i++ {
+		grid.Days[i] = weekStart.AddDate(0, 0, i).Format("2006-01-02")
+	}
+
+	rows := map[string]*models.TimesheetGridRow{}
+	for _, timer := range timers {
+		row, ok := rows[timer.TaskHash]
+		if !ok {
+			row = &models.TimesheetGridRow{
+				ProjectID:           timer.ProjectID,
+				ProjectExternalID:   timer.ProjectExternalID,
+				ProjectExternalName: timer.ProjectExternalName,
+				TaskHash:            timer.TaskHash,
+				TaskName:            timer.TaskName,
+				Seconds:             make([]int, daysInWeek),
+			}
+			rows[timer.TaskHash] = row
+			grid.Rows = append(grid.Rows, row)
+		}
+
+		seconds := timer.Seconds
+		if timer.FinishedAt == nil {
+			seconds = s.CalculateSecondsForActiveTimer(timer)
+		}
+
+		day := int(timer.CreatedAt.Sub(startsAt).Hours() / 24)
+		row.Seconds[day] += seconds
+		row.Total += seconds
+		grid.DayTotals[day] += seconds
+		grid.Total += seconds
+	}
+
+	return grid, nil
+}
+
+// SaveTimesheetGrid applies the cells edited in the grid of the week the date belongs to: the difference with what
+// is tracked already becomes a TimeEdit of the cell's latest timer, cells with no timers get a manual timer.
+// Every cell is validated before any of them is applied, so an invalid cell leaves the week untouched.
+// The cells which are not applied are returned with their errors
+func (s *TimerService) SaveTimesheetGrid(user *models.TeamUser, team *models.Team, week string, cells []*models.TimesheetGridCell) ([]*models.TimesheetGridCell, error) {
+	_, startsAt, endsAt, err := timesheetWeek(week, user)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []*timesheetGridPlan{}
+	unapplied := []*models.TimesheetGridCell{}
+	seen := map[string]bool{}
+	for _, cell := range cells {
+		plan, err := s.planTimesheetGridCell(user, team, startsAt, endsAt, cell)
+		if err == nil && seen[plan.taskHash+cell.Date] {
+			err = fmt.Errorf("`%s` is edited twice on %s", cell.TaskName, cell.Date)
+		}
+
+		if err != nil {
+			cell.Error = err.Error()
+			unapplied = append(unapplied, cell)
+			continue
+		}
+		seen[plan.taskHash+cell.Date] = true
+		plans = append(plans, plan)
+	}
+
+	if len(unapplied) > 0 {
+		return unapplied, fmt.Errorf("%d of %d cells are invalid, nothing is saved", len(unapplied), len(cells))
+	}
+
+	for _, plan := range plans {
+		if err := s.applyTimesheetGridPlan(user, team, plan); err != nil {
+			plan.cell.Error = err.Error()
+			unapplied = append(unapplied, plan.cell)
+		}
+	}
+
+	if len(unapplied) > 0 {
+		return unapplied, fmt.Errorf("%d of %d cells are not saved", len(unapplied), len(cells))
+	}
+	return unapplied, nil
+}
+
+// timesheetGridPlan is a validated cell along with the timers it changes
+type timesheetGridPlan struct {
+	cell     *models.TimesheetGridCell
+	project  *models.Project
+	taskHash string
+	dayStart time.Time
+	timers   []*models.Timer
+}
+
+func (s *TimerService) planTimesheetGridCell(user *models.TeamUser, team *models.Team, startsAt, endsAt time.Time, cell *models.TimesheetGridCell) (*timesheetGridPlan, error) {
+	if cell.Seconds < 0 || cell.Seconds > secondsInDay {
+		return nil, fmt.Errorf("seconds of %s must be between 0 and %d", cell.Date, secondsInDay)
+	}
+
+	project := findProjectByID(team, cell.ProjectID)
+	if project == nil {
+		return nil, errors.New("project not found")
+	}
+
+	day, err := time.Parse("2006-1-2", cell.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	dayStart := day.Add(time.Duration(user.SlackUserInfo.TZOffset) * time.Second * -1)
+	if dayStart.Before(startsAt) || !dayStart.Before(endsAt) {
+		return nil, fmt.Errorf("%s is not a day of the week", cell.Date)
+	}
+
+	if err = s.ensureNotLocked(user.ID.Hex(), dayStart); err != nil {
+		return nil, err
+	}
+
+	taskHash := taskSHA256(team.ID.Hex(), project.ID.Hex(), cell.TaskName)
+	timers, err := s.repository.findUserTaskTimersByRange(user.ID.Hex(), taskHash, dayStart, dayStart.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, timer := range timers {
+		if timer.FinishedAt == nil {
+			return nil, fmt.Errorf("`%s` has a running timer on %s, stop it first", cell.TaskName, cell.Date)
+		}
+	}
+
+	return &timesheetGridPlan{cell: cell, project: project, taskHash: taskHash, dayStart: dayStart, timers: timers}, nil
+}
+
+func (s *TimerService) applyTimesheetGridPlan(user *models.TeamUser, team *models.Team, plan *timesheetGridPlan) error {
+	tracked := 0
+	for _, timer := range plan.timers {
+		tracked += timer.Seconds
+	}
+
+	difference := plan.cell.Seconds - tracked
+	if difference == 0 {
+		return nil
+	}
+
+	if len(plan.timers) == 0 {
+		return s.createManualTimer(user, team, plan.project, plan.cell.TaskName, plan.dayStart, plan.cell.Seconds)
+	}
+
+	// the latest timers absorb the difference first, no timer goes below zero seconds
+	now := time.Now()
+	for i := len(plan.timers) - 1; i >= 0 && difference != 0; i-- {
+		timer := plan.timers[i]
+
+		adjustment := difference
+		if timer.Seconds+adjustment < 0 {
+			adjustment = -timer.Seconds
+		}
+
+		if adjustment == 0 {
+			continue
+		}
+
+		timer.Edits = append(timer.Edits, &models.TimeEdit{
+			TeamUserID: user.ID.Hex(),
+			CreatedAt:  now,
+			Seconds:    adjustment,
+		})
+		timer.Seconds += adjustment
+		difference -= adjustment
+
+		if err := s.update(models.WebhookEventTimerUpdated, timer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TimerService) createManualTimer(user *models.TeamUser, team *models.Team, project *models.Project, taskName string, dayStart time.Time, seconds int) error {
+	// manual timers start in the morning unless they would spill over the midnight
+	createdAt := dayStart.Add(manualTimerHour * time.Hour)
+	if manualTimerHour*60*60+seconds > secondsInDay {
+		createdAt = dayStart.Add(time.Duration(secondsInDay-seconds) * time.Second)
+	}
+
+	_, err := s.createFinishedTimer(user, team, project, &models.Timer{
+		TaskName:      taskName,
+		CreatedAt:     createdAt,
+		Seconds:       seconds,
+		ActualSeconds: 0,
+		Edits: []*models.TimeEdit{
+			{TeamUserID: user.ID.Hex(), CreatedAt: time.Now(), Seconds: seconds},
+		},
+	})
+	return err
+}
+
+// createFinishedTimer saves a timer tracked outside of Slack filling its team, project and user fields in.
+// The timer finishes when its seconds run out
+func (s *TimerService) createFinishedTimer(user *models.TeamUser, team *models.Team, project *models.Project, timer *models.Timer) (*models.Timer, error) {
+	finishedAt := timer.CreatedAt.Add(time.Duration(timer.Seconds) * time.Second)
+
+	timer.ID = bson.NewObjectId()
+	timer.TeamID = team.ID.Hex()
+	timer.ProjectID = project.ID.Hex()
+	timer.ProjectExternalName = project.ExternalProjectName
+	timer.ProjectExternalID = project.ExternalProjectID
+	timer.TeamUserID = user.ID.Hex()
+	timer.TeamUserTZOffset = tzOffsetOf(user)
+	timer.TaskHash = taskSHA256(team.ID.Hex(), project.ID.Hex(), timer.TaskName)
+	timer.Issues = ExtractIssues(team.IssuePatterns, timer.TaskName)
+	timer.FinishedAt = &finishedAt
+	timer.ModelVersion = models.ModelVersionTimer
+
+	timer, err := s.repository.CreateTimer(timer)
+	if err == nil {
+		s.events.Fire(models.WebhookEventTimerCreated, timer)
+	}
+	return timer, err
+}
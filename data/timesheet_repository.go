@@ -0,0 +1,82 @@
+package data
+
+import (
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type TimesheetRepository struct {
+	session    *mgo.Session
+	collection *mgo.Collection
+}
+
+func NewTimesheetRepository(session *mgo.Session) *TimesheetRepository {
+	return &TimesheetRepository{
+		session:    session,
+		collection: session.DB("").C(utils.MongoCollectionTimesheets),
+	}
+}
+
+func (r *TimesheetRepository) findByID(timesheetID string) (*models.Timesheet, error) {
+	if !bson.IsObjectIdHex(timesheetID) {
+		return nil, mgo.ErrNotFound
+	}
+
+	result := &models.Timesheet{}
+	err := r.collection.FindId(bson.ObjectIdHex(timesheetID)).One(result)
+	return result, err
+}
+
+func (r *TimesheetRepository) findByUserAndWeek(userID string, weekStart time.Time) (*models.Timesheet, error) {
+	result := &models.Timesheet{}
+	err := r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"week_start":   weekStart,
+	}).One(result)
+
+	if err != nil && err == mgo.ErrNotFound {
+		result = nil
+		err = nil
+	}
+	return result, err
+}
+
+func (r *TimesheetRepository) findByTeamAndStatus(teamID, status string) ([]*models.Timesheet, error) {
+	result := []*models.Timesheet{}
+	err := r.collection.Find(bson.M{
+		"team_id": teamID,
+		"status":  status,
+	}).Sort("week_start").All(&result)
+	return result, err
+}
+
+// findApprovedCovering looks up an approved timesheet of the user the given moment belongs to
+func (r *TimesheetRepository) findApprovedCovering(userID string, moment time.Time) (*models.Timesheet, error) {
+	result := &models.Timesheet{}
+	err := r.collection.Find(bson.M{
+		"team_user_id": userID,
+		"status":       models.TimesheetStatusApproved,
+		"starts_at":    bson.M{"$lte": moment},
+		"ends_at":      bson.M{"$gt": moment},
+	}).One(result)
+
+	if err != nil && err == mgo.ErrNotFound {
+		result = nil
+		err = nil
+	}
+	return result, err
+}
+
+func (r *TimesheetRepository) save(timesheet *models.Timesheet) error {
+	if timesheet.ID == "" {
+		timesheet.ID = bson.NewObjectId()
+		timesheet.CreatedAt = time.Now()
+		timesheet.ModelVersion = models.ModelVersionTimesheet
+		return r.collection.Insert(timesheet)
+	}
+	return r.collection.UpdateId(timesheet.ID, timesheet)
+}
@@ -0,0 +1,212 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+)
+
+// TimesheetService - handles weekly timesheets submission and their review by approvers
+type TimesheetService struct {
+	repository      *TimesheetRepository
+	timerRepository *TimerRepository
+	userRepository  *UserRepository
+	teamRepository  *TeamRepository
+	messenger       SlackMessenger
+}
+
+// NewTimesheetService constructs an instance of the service
+func NewTimesheetService(session *mgo.Session) *TimesheetService {
+	return &TimesheetService{
+		repository:      NewTimesheetRepository(session),
+		timerRepository: NewTimerRepository(session),
+		userRepository:  NewUserRepository(session),
+		teamRepository:  NewTeamRepository(session),
+		messenger:       NewSlackMessenger(),
+	}
+}
+
+// GetTimesheet returns the user's timesheet for the week the date belongs to.
+// If the timesheet was never submitted a draft that is not saved yet is returned
+func (s *TimesheetService) GetTimesheet(user *models.TeamUser, date string) (*models.Timesheet, error) {
+	weekStart, startsAt, endsAt, err := timesheetWeek(date, user)
+	if err != nil {
+		return nil, err
+	}
+
+	timesheet, err := s.repository.findByUserAndWeek(user.ID.Hex(), weekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	if timesheet == nil {
+		timesheet = &models.Timesheet{
+			TeamID:     user.TeamID,
+			TeamUserID: user.ID.Hex(),
+			WeekStart:  weekStart,
+			StartsAt:   startsAt,
+			EndsAt:     endsAt,
+			Status:     models.TimesheetStatusDraft,
+		}
+	}
+
+	if timesheet.Status == models.TimesheetStatusDraft || timesheet.Status == models.TimesheetStatusRejected {
+		timesheet.Seconds = s.timerRepository.totalSecondsForUser(user.ID.Hex(), timesheet.StartsAt, timesheet.EndsAt.Add(-time.Second))
+	}
+
+	return timesheet, nil
+}
+
+// Submit sends the user's timesheet for the week the date belongs to for an approval and notifies the approvers
+func (s *TimesheetService) Submit(user *models.TeamUser, date string) (*models.Timesheet, error) {
+	timesheet, err := s.GetTimesheet(user, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if timesheet.Status != models.TimesheetStatusDraft && timesheet.Status != models.TimesheetStatusRejected {
+		return nil, fmt.Errorf("timesheet is already %s", timesheet.Status)
+	}
+
+	now := time.Now()
+	if timesheet.StartsAt.After(now) {
+		return nil, errors.New("the week has not started yet")
+	}
+
+	timesheet.Status = models.TimesheetStatusSubmitted
+	timesheet.SubmittedAt = &now
+	timesheet.ReviewerID = ""
+	timesheet.ReviewedAt = nil
+	timesheet.Comment = ""
+
+	if err = s.repository.save(timesheet); err != nil {
+		return nil, err
+	}
+
+	s.notifyApprovers(user, timesheet)
+	return timesheet, nil
+}
+
+// Approve approves the submitted timesheet, the timers it covers become locked
+func (s *TimesheetService) Approve(reviewer *models.TeamUser, timesheetID, comment string) (*models.Timesheet, error) {
+	return s.review(reviewer, timesheetID, models.TimesheetStatusApproved, comment)
+}
+
+// Reject sends the submitted timesheet back to the user with a comment, the user may submit it again
+func (s *TimesheetService) Reject(reviewer *models.TeamUser, timesheetID, comment string) (*models.Timesheet, error) {
+	return s.review(reviewer, timesheetID, models.TimesheetStatusRejected, comment)
+}
+
+// PendingTimesheets returns the timesheets of the reviewer's team that wait for an approval
+func (s *TimesheetService) PendingTimesheets(reviewer *models.TeamUser) ([]*models.Timesheet, error) {
+	if !Can(reviewer, PermissionReviewTimesheets) {
+		return nil, errors.New("review forbidden")
+	}
+	return s.repository.findByTeamAndStatus(reviewer.TeamID, models.TimesheetStatusSubmitted)
+}
+
+func (s *TimesheetService) review(reviewer *models.TeamUser, timesheetID, status, comment string) (*models.Timesheet, error) {
+	timesheet, err := s.repository.findByID(timesheetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if timesheet.TeamID != reviewer.TeamID || !Can(reviewer, PermissionReviewTimesheets) {
+		return nil, errors.New("review forbidden")
+	}
+
+	// approving locks the time, so nobody approves their own
+	if timesheet.TeamUserID == reviewer.ID.Hex() {
+		return nil, errors.New("reviewing own timesheet is forbidden")
+	}
+
+	if timesheet.Status != models.TimesheetStatusSubmitted {
+		return nil, fmt.Errorf("timesheet is %s, only submitted timesheets can be reviewed", timesheet.Status)
+	}
+
+	now := time.Now()
+	timesheet.Status = status
+	timesheet.ReviewerID = reviewer.ID.Hex()
+	timesheet.ReviewedAt = &now
+	timesheet.Comment = comment
+
+	if err = s.repository.save(timesheet); err != nil {
+		return nil, err
+	}
+
+	s.notifySubmitter(reviewer, timesheet)
+	return timesheet, nil
+}
+
+func (s *TimesheetService) notifyApprovers(user *models.TeamUser, timesheet *models.Timesheet) {
+	team, err := s.teamRepository.FindByID(user.TeamID)
+	if err != nil {
+		log.Printf("Failed to load team to notify timesheet approvers: %s", err)
+		return
+	}
+
+	users, err := s.userRepository.FindByTeamID(user.TeamID)
+	if err != nil {
+		log.Printf("Failed to load timesheet approvers: %s", err)
+		return
+	}
+
+	text := fmt.Sprintf("<@%s> submitted a timesheet for the week of %s (%s) for your approval",
+		user.ExternalUserID,
+		timesheet.WeekStart.Format("Jan 2, 2006"),
+		utils.FormatDuration(time.Duration(timesheet.Seconds)*time.Second))
+
+	for _, approver := range users {
+		if approver.ID == user.ID || !Can(approver, PermissionReviewTimesheets) {
+			continue
+		}
+
+		if err = s.messenger.PostMessage(team, approver.ExternalUserID, text); err != nil {
+			log.Printf("Failed to notify %s about a submitted timesheet: %s", approver.ExternalUserName, err)
+		}
+	}
+}
+
+func (s *TimesheetService) notifySubmitter(reviewer *models.TeamUser, timesheet *models.Timesheet) {
+	team, err := s.teamRepository.FindByID(timesheet.TeamID)
+	if err != nil {
+		log.Printf("Failed to load team to notify timesheet submitter: %s", err)
+		return
+	}
+
+	user, err := s.userRepository.FindByID(timesheet.TeamUserID)
+	if err != nil {
+		log.Printf("Failed to load timesheet submitter: %s", err)
+		return
+	}
+
+	text := fmt.Sprintf("Your timesheet for the week of %s was %s by <@%s>",
+		timesheet.WeekStart.Format("Jan 2, 2006"), timesheet.Status, reviewer.ExternalUserID)
+	if timesheet.Comment != "" {
+		text = fmt.Sprintf("%s: %s", text, timesheet.Comment)
+	}
+
+	if err = s.messenger.PostMessage(team, user.ExternalUserID, text); err != nil {
+		log.Printf("Failed to notify %s about a reviewed timesheet: %s", user.ExternalUserName, err)
+	}
+}
+
+// timesheetWeek returns the Monday of the week the date belongs to
+// along with the week boundaries converted to UTC by user's timezone
+func timesheetWeek(date string, user *models.TeamUser) (time.Time, time.Time, time.Time, error) {
+	day, err := time.Parse("2006-1-2", date)
+	if err != nil {
+		return time.Time{}, time.Time{}, time.Time{}, err
+	}
+
+	daysSinceMonday := (int(day.Weekday()) + 6) % 7
+	weekStart := day.AddDate(0, 0, -daysSinceMonday)
+	startsAt := weekStart.Add(time.Duration(user.SlackUserInfo.TZOffset) * time.Second * -1)
+
+	return weekStart, startsAt, startsAt.AddDate(0, 0, 7), nil
+}
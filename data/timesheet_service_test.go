@@ -0,0 +1,247 @@
+package data
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestTimesheetService(t *testing.T) {
+	gosuite.Run(t, &TimesheetServiceTestSuite{Is: is.New(t)})
+}
+
+func (s *TimesheetServiceTestSuite) TestGetTimesheetDraft(t *testing.T) {
+	s.createTimer(utils.PT("2016 Dec 05 10:00:00"), 30)
+	s.createTimer(utils.PT("2016 Dec 11 10:00:00"), 20)
+	s.createTimer(utils.PT("2016 Dec 12 10:00:00"), 40) // next week
+
+	timesheet, err := s.service.GetTimesheet(s.user, "2016-12-7")
+	s.Nil(err)
+	s.Equal(timesheet.Status, models.TimesheetStatusDraft)
+	s.Equal(timesheet.WeekStart, utils.PT("2016 Dec 05 00:00:00"))
+	s.Equal(timesheet.StartsAt, utils.PT("2016 Dec 04 22:00:00"))
+	s.Equal(timesheet.EndsAt, utils.PT("2016 Dec 11 22:00:00"))
+	s.Equal(timesheet.Seconds, 50*60)
+	s.Equal(timesheet.ID, bson.ObjectId(""))
+}
+
+func (s *TimesheetServiceTestSuite) TestGetTimesheetWrongDate(t *testing.T) {
+	_, err := s.service.GetTimesheet(s.user, "not a date")
+	s.Err(err)
+}
+
+func (s *TimesheetServiceTestSuite) TestSubmit(t *testing.T) {
+	s.createTimer(utils.PT("2016 Dec 05 10:00:00"), 30)
+
+	timesheet, err := s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+	s.Equal(timesheet.Status, models.TimesheetStatusSubmitted)
+	s.NotNil(timesheet.SubmittedAt)
+	s.Equal(timesheet.Seconds, 30*60)
+
+	// the approver is notified, the submitter is not
+	s.Len(s.messenger.messages, 1)
+	s.Equal(s.messenger.messages[0].channelID, s.approver.ExternalUserID)
+
+	_, err = s.service.Submit(s.user, "2016-12-6")
+	s.Err(err)
+	s.Equal(err.Error(), "timesheet is already submitted")
+}
+
+func (s *TimesheetServiceTestSuite) TestApprove(t *testing.T) {
+	timesheet, err := s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+
+	_, err = s.service.Approve(s.user, timesheet.ID.Hex(), "")
+	s.Err(err)
+	s.Equal(err.Error(), "review forbidden")
+
+	timesheet, err = s.service.Approve(s.approver, timesheet.ID.Hex(), "Good job")
+	s.Nil(err)
+	s.Equal(timesheet.Status, models.TimesheetStatusApproved)
+	s.Equal(timesheet.ReviewerID, s.approver.ID.Hex())
+	s.Equal(timesheet.Comment, "Good job")
+
+	s.Len(s.messenger.messages, 2)
+	s.Equal(s.messenger.messages[1].channelID, s.user.ExternalUserID)
+	s.Equal(s.messenger.messages[1].text, "Your timesheet for the week of Dec 5, 2016 was approved by <@approver>: Good job")
+
+	_, err = s.service.Reject(s.approver, timesheet.ID.Hex(), "")
+	s.Err(err)
+}
+
+func (s *TimesheetServiceTestSuite) TestSubmitFutureWeek(t *testing.T) {
+	nextWeek := time.Now().AddDate(0, 0, 7).Format("2006-1-2")
+
+	_, err := s.service.Submit(s.user, nextWeek)
+	s.Err(err)
+	s.Equal(err.Error(), "the week has not started yet")
+}
+
+func (s *TimesheetServiceTestSuite) TestApproveOwnTimesheet(t *testing.T) {
+	timesheet, err := s.service.Submit(s.approver, "2016-12-5")
+	s.Nil(err)
+
+	_, err = s.service.Approve(s.approver, timesheet.ID.Hex(), "")
+	s.Err(err)
+	s.Equal(err.Error(), "reviewing own timesheet is forbidden")
+
+	timesheet, err = s.service.GetTimesheet(s.approver, "2016-12-5")
+	s.Nil(err)
+	s.Equal(timesheet.Status, models.TimesheetStatusSubmitted)
+}
+
+func (s *TimesheetServiceTestSuite) TestRejectAndResubmit(t *testing.T) {
+	timesheet, err := s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+
+	timesheet, err = s.service.Reject(s.approver, timesheet.ID.Hex(), "Missing Friday")
+	s.Nil(err)
+	s.Equal(timesheet.Status, models.TimesheetStatusRejected)
+
+	timesheet, err = s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+	s.Equal(timesheet.Status, models.TimesheetStatusSubmitted)
+	s.Equal(timesheet.Comment, "")
+}
+
+func (s *TimesheetServiceTestSuite) TestPendingTimesheets(t *testing.T) {
+	_, err := s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+
+	_, err = s.service.PendingTimesheets(s.user)
+	s.Err(err)
+
+	timesheets, err := s.service.PendingTimesheets(s.approver)
+	s.Nil(err)
+	s.Len(timesheets, 1)
+}
+
+func (s *TimesheetServiceTestSuite) TestScopedManagerCannotReview(t *testing.T) {
+	timesheet, err := s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+
+	manager := &models.TeamUser{
+		ID:                bson.NewObjectId(),
+		TeamID:            s.team.ID.Hex(),
+		Role:              models.RoleManager,
+		ManagedProjectIDs: []string{"project"},
+	}
+
+	_, err = s.service.PendingTimesheets(manager)
+	s.Err(err)
+
+	_, err = s.service.Approve(manager, timesheet.ID.Hex(), "")
+	s.Err(err)
+	s.Equal(err.Error(), "review forbidden")
+}
+
+func (s *TimesheetServiceTestSuite) TestApprovedTimesheetLocksTimers(t *testing.T) {
+	timer := s.createTimer(utils.PT("2016 Dec 05 10:00:00"), 30)
+	timerService := NewTimerService(s.session)
+
+	timesheet, err := s.service.Submit(s.user, "2016-12-5")
+	s.Nil(err)
+	_, err = s.service.Approve(s.approver, timesheet.ID.Hex(), "")
+	s.Nil(err)
+
+	locked, err := timerService.IsLocked(s.user.ID.Hex(), timer.CreatedAt)
+	s.Nil(err)
+	s.True(locked)
+
+	err = timerService.UpdateUserTimer(s.user, timer, &models.Timer{TaskName: "changed"})
+	s.Err(err)
+	s.Equal(err.Error(), "timer is locked by an approved timesheet")
+
+	err = timerService.DeleteUserTimer(s.user, timer)
+	s.Err(err)
+	s.Equal(err.Error(), "timer is locked by an approved timesheet")
+
+	locked, err = timerService.IsLocked(s.user.ID.Hex(), utils.PT("2016 Dec 12 10:00:00"))
+	s.Nil(err)
+	s.False(locked)
+}
+
+func (s *TimesheetServiceTestSuite) createTimer(createdAt time.Time, minutes int) *models.Timer {
+	finishedAt := createdAt.Add(time.Duration(minutes) * time.Minute)
+	timer, err := s.timerRepository.CreateTimer(&models.Timer{
+		ID:         bson.NewObjectId(),
+		TeamID:     s.team.ID.Hex(),
+		ProjectID:  "project",
+		TeamUserID: s.user.ID.Hex(),
+		CreatedAt:  createdAt,
+		FinishedAt: &finishedAt,
+		Seconds:    minutes * 60,
+	})
+	s.Nil(err)
+	return timer
+}
+
+func (s *TimesheetServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.timerRepository = NewTimerRepository(s.session)
+}
+
+func (s *TimesheetServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *TimesheetServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	s.messenger = &testSlackMessenger{}
+	s.service = NewTimesheetService(s.session)
+	s.service.messenger = s.messenger
+
+	var err error
+	s.team, err = NewTeamRepository(s.session).CreateTeam("team-id", "team-name")
+	s.Nil(err)
+
+	userRepository := NewUserRepository(s.session)
+	s.user, err = userRepository.Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "user",
+		SlackUserInfo:  &slack.User{TZOffset: 2 * 60 * 60},
+	})
+	s.Nil(err)
+
+	s.approver, err = userRepository.Save(&models.TeamUser{
+		TeamID:         s.team.ID.Hex(),
+		ExternalUserID: "approver",
+		SlackUserInfo:  &slack.User{IsAdmin: true},
+	})
+	s.Nil(err)
+}
+
+func (s *TimesheetServiceTestSuite) TearDown() {
+}
+
+type TimesheetServiceTestSuite struct {
+	*is.Is
+	env             *utils.Environment
+	session         *mgo.Session
+	service         *TimesheetService
+	timerRepository *TimerRepository
+	messenger       *testSlackMessenger
+	team            *models.Team
+	user            *models.TeamUser
+	approver        *models.TeamUser
+}
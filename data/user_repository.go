@@ -44,6 +44,34 @@ func (r *UserRepository) FindByID(userID string) (*models.TeamUser, error) {
 	return teamUser, err
 }
 
+func (r *UserRepository) FindByTeamID(teamID string) ([]*models.TeamUser, error) {
+	result := []*models.TeamUser{}
+	err := r.collection.Find(bson.M{"team_id": teamID}).All(&result)
+	return result, err
+}
+
+func (r *UserRepository) findAll() ([]*models.TeamUser, error) {
+	result := []*models.TeamUser{}
+	err := r.collection.Find(nil).All(&result)
+	return result, err
+}
+
+func (r *UserRepository) findByCalendarToken(token string) (*models.TeamUser, error) {
+	teamUser := &models.TeamUser{}
+	err := r.collection.Find(bson.M{"calendar_token": token}).One(teamUser)
+	return teamUser, err
+}
+
+// incrementTokenVersion bumps the version of user's tokens and returns the new one
+func (r *UserRepository) incrementTokenVersion(userID bson.ObjectId) (int, error) {
+	result := &models.TeamUser{}
+	_, err := r.collection.FindId(userID).Apply(mgo.Change{
+		Update:    bson.M{"$inc": bson.M{"token_version": 1}},
+		ReturnNew: true,
+	}, result)
+	return result.TokenVersion, err
+}
+
 func (r *UserRepository) Save(user *models.TeamUser) (*models.TeamUser, error) {
 	if user.ID == "" {
 		user.ID = bson.NewObjectId()
@@ -1,6 +1,8 @@
 package data
 
 import (
+	"errors"
+	"fmt"
 	"github.com/nlopes/slack"
 	"github.com/cleverua/tuna-timer-api/models"
 	"gopkg.in/mgo.v2"
@@ -8,14 +10,18 @@ import (
 )
 
 type UserService struct {
-	repository *UserRepository
-	slackAPI   userServerSlackAPI
+	repository            *UserRepository
+	teamRepository        *TeamRepository
+	accessTokenRepository *AccessTokenRepository
+	slackAPI              userServerSlackAPI
 }
 
 func NewUserService(session *mgo.Session) *UserService {
 	return &UserService{
-		repository: NewUserRepository(session),
-		slackAPI:   &userServiceSlackAPIImpl{},
+		repository:            NewUserRepository(session),
+		teamRepository:        NewTeamRepository(session),
+		accessTokenRepository: NewAccessTokenRepository(session),
+		slackAPI:              &userServiceSlackAPIImpl{},
 	}
 }
 
@@ -57,6 +63,96 @@ func (s *UserService) EnsureUser(team *models.Team, externalUserID string) (*mod
 	return user, nil
 }
 
+// TeamUsers returns the members of the user's team
+func (s *UserService) TeamUsers(user *models.TeamUser) ([]*models.TeamUser, error) {
+	if err := Authorize(user, PermissionViewTeamReports); err != nil {
+		return nil, err
+	}
+	return s.repository.FindByTeamID(user.TeamID)
+}
+
+// AssignRole changes the role of a team member, managers and viewers get their project scope
+// set to projectIDs which have to belong to the team
+func (s *UserService) AssignRole(assigner *models.TeamUser, userID, role string, projectIDs []string) (*models.TeamUser, error) {
+	if err := Authorize(assigner, PermissionAssignRoles); err != nil {
+		return nil, err
+	}
+
+	if !IsValidRole(role) {
+		return nil, fmt.Errorf("unknown role `%s`", role)
+	}
+
+	user, err := s.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TeamID != assigner.TeamID {
+		return nil, ErrForbidden
+	}
+
+	if user.ID == assigner.ID {
+		return nil, errors.New("you can not change your own role")
+	}
+
+	user.Role = role
+	user.ManagedProjectIDs = []string{}
+
+	if projectScopedRoles[role] {
+		team, err := s.teamRepository.FindByID(user.TeamID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, projectID := range projectIDs {
+			if findProjectByID(team, projectID) == nil {
+				return nil, fmt.Errorf("project `%s` does not belong to the team", projectID)
+			}
+		}
+		user.ManagedProjectIDs = projectIDs
+	}
+
+	return s.repository.Save(user)
+}
+
+// tzOffsetOf returns the user's timezone offset in seconds, UTC for the users Slack has not told about
+func tzOffsetOf(user *models.TeamUser) int {
+	if user.SlackUserInfo == nil {
+		return 0
+	}
+	return user.SlackUserInfo.TZOffset
+}
+
+// RevokeTokens makes all the JWTs issued to the user so far invalid, e.g. to log out everywhere
+func (s *UserService) RevokeTokens(user *models.TeamUser) error {
+	version, err := s.repository.incrementTokenVersion(user.ID)
+	if err == nil {
+		user.TokenVersion = version
+	}
+	return err
+}
+
+// RevokeUserTokens logs a member of the team out everywhere and revokes their access tokens,
+// e.g. when they leave the team
+func (s *UserService) RevokeUserTokens(revoker *models.TeamUser, userID string) (*models.TeamUser, error) {
+	if err := Authorize(revoker, PermissionManageTeam); err != nil {
+		return nil, err
+	}
+
+	user, err := s.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TeamID != revoker.TeamID {
+		return nil, ErrForbidden
+	}
+
+	if err := s.RevokeTokens(user); err != nil {
+		return nil, err
+	}
+	return user, s.accessTokenRepository.revokeAllByUser(user.ID.Hex(), time.Now())
+}
+
 // UpdateSlackUserInfo - finds or creates TeamUser record with associated user data gathered from Slack
 //func (s *UserService) UpdateSlackUserInfo(team *models.Team, user *models.TeamUser) (*models.TeamUser, error) {
 //	info, err := s.slackAPI.GetUserInfo(team, user.ExternalUserID)
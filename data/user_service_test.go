@@ -99,6 +99,57 @@ func (s *UserServiceTestSuite) TestFindByIDNotExist(t *testing.T) {
 	s.Nil(user)
 }
 
+func (s *UserServiceTestSuite) TestAssignRole(t *testing.T) {
+	service := NewUserService(s.session)
+	team, err := NewTeamRepository(s.session).CreateTeam("team-id", "team-name")
+	s.Nil(err)
+	s.Nil(NewTeamRepository(s.session).AddProject(team, "channel-id", "channel-name"))
+	team, _ = NewTeamRepository(s.session).FindByID(team.ID.Hex())
+	projectID := team.Projects[0].ID.Hex()
+
+	owner, _ := s.repository.Save(&models.TeamUser{TeamID: team.ID.Hex(), ExternalUserID: "owner", SlackUserInfo: &slack.User{IsOwner: true}})
+	member, _ := s.repository.Save(&models.TeamUser{TeamID: team.ID.Hex(), ExternalUserID: "member", SlackUserInfo: &slack.User{}})
+
+	user, err := service.AssignRole(owner, member.ID.Hex(), models.RoleManager, []string{projectID})
+	s.Nil(err)
+	s.Equal(user.Role, models.RoleManager)
+	s.Equal(user.ManagedProjectIDs, []string{projectID})
+
+	user, err = service.FindByID(member.ID.Hex())
+	s.Nil(err)
+	s.Equal(user.Role, models.RoleManager)
+
+	// the project scope is reset for roles that are not scoped by projects
+	user, err = service.AssignRole(owner, member.ID.Hex(), models.RoleAdmin, []string{projectID})
+	s.Nil(err)
+	s.Len(user.ManagedProjectIDs, 0)
+}
+
+func (s *UserServiceTestSuite) TestAssignRoleFailures(t *testing.T) {
+	service := NewUserService(s.session)
+	team, err := NewTeamRepository(s.session).CreateTeam("team-id", "team-name")
+	s.Nil(err)
+
+	owner, _ := s.repository.Save(&models.TeamUser{TeamID: team.ID.Hex(), ExternalUserID: "owner", SlackUserInfo: &slack.User{IsOwner: true}})
+	admin, _ := s.repository.Save(&models.TeamUser{TeamID: team.ID.Hex(), ExternalUserID: "admin", SlackUserInfo: &slack.User{IsAdmin: true}})
+	stranger, _ := s.repository.Save(&models.TeamUser{TeamID: "another-team", ExternalUserID: "stranger", SlackUserInfo: &slack.User{}})
+
+	_, err = service.AssignRole(admin, owner.ID.Hex(), models.RoleMember, nil)
+	s.Equal(err, ErrForbidden)
+
+	_, err = service.AssignRole(owner, stranger.ID.Hex(), models.RoleMember, nil)
+	s.Equal(err, ErrForbidden)
+
+	_, err = service.AssignRole(owner, owner.ID.Hex(), models.RoleMember, nil)
+	s.Equal(err.Error(), "you can not change your own role")
+
+	_, err = service.AssignRole(owner, admin.ID.Hex(), "superuser", nil)
+	s.Equal(err.Error(), "unknown role `superuser`")
+
+	_, err = service.AssignRole(owner, admin.ID.Hex(), models.RoleManager, []string{"unknown-project"})
+	s.Equal(err.Error(), "project `unknown-project` does not belong to the team")
+}
+
 type UserServiceTestSuite struct {
 	*is.Is
 	env        *utils.Environment
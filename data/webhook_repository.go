@@ -0,0 +1,100 @@
+package data
+
+import (
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// webhookDeliveryLease - how long a claimed delivery is hidden from other workers
+const webhookDeliveryLease = 2 * time.Minute
+
+type WebhookRepository struct {
+	session    *mgo.Session
+	webhooks   *mgo.Collection
+	deliveries *mgo.Collection
+}
+
+func NewWebhookRepository(session *mgo.Session) *WebhookRepository {
+	return &WebhookRepository{
+		session:    session,
+		webhooks:   session.DB("").C(utils.MongoCollectionWebhooks),
+		deliveries: session.DB("").C(utils.MongoCollectionDeliveries),
+	}
+}
+
+func (r *WebhookRepository) findByID(webhookID string) (*models.Webhook, error) {
+	if !bson.IsObjectIdHex(webhookID) {
+		return nil, mgo.ErrNotFound
+	}
+
+	result := &models.Webhook{}
+	err := r.webhooks.FindId(bson.ObjectIdHex(webhookID)).One(result)
+	return result, err
+}
+
+func (r *WebhookRepository) findByTeam(teamID string) ([]*models.Webhook, error) {
+	result := []*models.Webhook{}
+	err := r.webhooks.Find(bson.M{"team_id": teamID}).Sort("created_at").All(&result)
+	return result, err
+}
+
+// findSubscribed returns the team's webhooks subscribed to the event explicitly or to all events
+func (r *WebhookRepository) findSubscribed(teamID, event string) ([]*models.Webhook, error) {
+	result := []*models.Webhook{}
+	err := r.webhooks.Find(bson.M{
+		"team_id": teamID,
+		"$or": []bson.M{
+			{"events": event},
+			{"events": bson.M{"$size": 0}},
+		},
+	}).All(&result)
+	return result, err
+}
+
+func (r *WebhookRepository) create(webhook *models.Webhook) error {
+	return r.webhooks.Insert(webhook)
+}
+
+func (r *WebhookRepository) remove(webhook *models.Webhook) error {
+	if err := r.webhooks.RemoveId(webhook.ID); err != nil {
+		return err
+	}
+	_, err := r.deliveries.RemoveAll(bson.M{"webhook_id": webhook.ID.Hex()})
+	return err
+}
+
+func (r *WebhookRepository) enqueue(delivery *models.WebhookDelivery) error {
+	return r.deliveries.Insert(delivery)
+}
+
+// claimDue takes a delivery which is due at the moment. The delivery is leased so concurrent
+// workers do not pick it up again until the lease expires
+func (r *WebhookRepository) claimDue(now time.Time) (*models.WebhookDelivery, error) {
+	result := &models.WebhookDelivery{}
+	_, err := r.deliveries.Find(bson.M{
+		"status":          models.WebhookDeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}).Sort("next_attempt_at").Apply(mgo.Change{
+		Update:    bson.M{"$set": bson.M{"next_attempt_at": now.Add(webhookDeliveryLease)}},
+		ReturnNew: true,
+	}, result)
+
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return result, err
+}
+
+func (r *WebhookRepository) findDeliveries(webhookID string, limit int) ([]*models.WebhookDelivery, error) {
+	result := []*models.WebhookDelivery{}
+	err := r.deliveries.Find(bson.M{"webhook_id": webhookID}).Sort("-created_at").Limit(limit).All(&result)
+	return result, err
+}
+
+func (r *WebhookRepository) updateDelivery(delivery *models.WebhookDelivery) error {
+	return r.deliveries.UpdateId(delivery.ID, delivery)
+}
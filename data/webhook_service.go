@@ -0,0 +1,353 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/satori/go.uuid"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// webhookMaxAttempts - a delivery is given up after that many failed attempts
+	webhookMaxAttempts = 8
+	// webhookFirstRetryDelay doubles with every failed attempt
+	webhookFirstRetryDelay = time.Minute
+	// webhookDeliveriesLogSize - how many latest deliveries the log shows
+	webhookDeliveriesLogSize = 50
+
+	WebhookSignatureHeader = "X-Tuna-Signature"
+	WebhookEventHeader     = "X-Tuna-Event"
+	WebhookDeliveryHeader  = "X-Tuna-Delivery"
+)
+
+// webhooks never reach the addresses of our own network: private, shared (carrier-grade NAT) and reserved blocks,
+// loopback and link-local addresses are checked separately
+var webhookPrivateBlocks = parseCIDRs("0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+
+var webhookEvents = []string{
+	models.WebhookEventTimerStarted,
+	models.WebhookEventTimerStopped,
+	models.WebhookEventTimerCreated,
+	models.WebhookEventTimerUpdated,
+	models.WebhookEventTimerDeleted,
+}
+
+// TimerEventPublisher is notified about every change TimerService makes to timers
+type TimerEventPublisher interface {
+	Fire(event string, timer *models.Timer)
+}
+
+// webhookPayload is the JSON body posted to webhooks
+type webhookPayload struct {
+	Event      string        `json:"event"`
+	TeamID     string        `json:"team_id"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Timer      *models.Timer `json:"timer"`
+}
+
+// WebhookService - manages team's webhooks and delivers timer events to them
+type WebhookService struct {
+	repository *WebhookRepository
+	client     *http.Client
+	lookupIP   func(host string) ([]net.IP, error)
+	allowedIP  func(ip net.IP) bool
+}
+
+// NewWebhookService constructs an instance of the service
+func NewWebhookService(session *mgo.Session) *WebhookService {
+	s := &WebhookService{
+		repository: NewWebhookRepository(session),
+		lookupIP:   net.LookupIP,
+		allowedIP:  isPublicIP,
+	}
+
+	// the host is resolved and checked again on every connection, so a webhook can not be pointed
+	// to our own network by changing its DNS records after it has been created
+	s.client = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: s.dial},
+	}
+	return s
+}
+
+// Webhooks lists the webhooks of the user's team
+func (s *WebhookService) Webhooks(user *models.TeamUser) ([]*models.Webhook, error) {
+	if err := Authorize(user, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+	return s.repository.findByTeam(user.TeamID)
+}
+
+// CreateWebhook subscribes the URL to the events of the user's team, no events means all of them
+func (s *WebhookService) CreateWebhook(user *models.TeamUser, webhookURL string, events []string) (*models.Webhook, error) {
+	if err := Authorize(user, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+
+	parsedURL, err := url.Parse(webhookURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, fmt.Errorf("wrong webhook url `%s`", webhookURL)
+	}
+	host := parsedURL.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if _, err = s.resolve(strings.Trim(host, "[]")); err != nil {
+		return nil, err
+	}
+
+	if events == nil {
+		events = []string{}
+	}
+	for _, event := range events {
+		if !isWebhookEvent(event) {
+			return nil, fmt.Errorf("unknown event `%s`", event)
+		}
+	}
+
+	webhook := &models.Webhook{
+		ID:           bson.NewObjectId(),
+		TeamID:       user.TeamID,
+		URL:          webhookURL,
+		Secret:       strings.Replace(uuid.NewV4().String(), "-", "", -1),
+		Events:       events,
+		CreatedBy:    user.ID.Hex(),
+		CreatedAt:    time.Now(),
+		ModelVersion: models.ModelVersionWebhook,
+	}
+
+	return webhook, s.repository.create(webhook)
+}
+
+// DeleteWebhook removes the webhook along with its deliveries
+func (s *WebhookService) DeleteWebhook(user *models.TeamUser, webhookID string) error {
+	webhook, err := s.findTeamWebhook(user, webhookID)
+	if err != nil {
+		return err
+	}
+	return s.repository.remove(webhook)
+}
+
+// Deliveries returns the latest deliveries of the webhook with their attempts
+func (s *WebhookService) Deliveries(user *models.TeamUser, webhookID string) ([]*models.WebhookDelivery, error) {
+	webhook, err := s.findTeamWebhook(user, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repository.findDeliveries(webhook.ID.Hex(), webhookDeliveriesLogSize)
+}
+
+func (s *WebhookService) findTeamWebhook(user *models.TeamUser, webhookID string) (*models.Webhook, error) {
+	if err := Authorize(user, PermissionManageWebhooks); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repository.findByID(webhookID)
+	if err == mgo.ErrNotFound || (err == nil && webhook.TeamID != user.TeamID) {
+		return nil, errors.New("webhook not found")
+	}
+	return webhook, err
+}
+
+// Fire queues the event for every webhook of the timer's team subscribed to it. Failures are only logged
+// so that queueing never breaks the timer change itself
+func (s *WebhookService) Fire(event string, timer *models.Timer) {
+	webhooks, err := s.repository.findSubscribed(timer.TeamID, event)
+	if err != nil {
+		log.Printf("Failed to find webhooks for %s: %s", event, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(&webhookPayload{Event: event, TeamID: timer.TeamID, OccurredAt: now, Timer: timer})
+	if err != nil {
+		log.Printf("Failed to encode %s payload: %s", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		err = s.repository.enqueue(&models.WebhookDelivery{
+			ID:            bson.NewObjectId(),
+			WebhookID:     webhook.ID.Hex(),
+			TeamID:        webhook.TeamID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			Attempts:      []*models.WebhookAttempt{},
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			log.Printf("Failed to queue %s for webhook %s: %s", event, webhook.ID.Hex(), err)
+		}
+	}
+}
+
+// DeliverDue posts every delivery which is due at the moment, failed ones are retried with exponential backoff
+func (s *WebhookService) DeliverDue(now time.Time) error {
+	for {
+		delivery, err := s.repository.claimDue(now)
+		if err != nil {
+			return err
+		}
+		if delivery == nil {
+			return nil
+		}
+
+		if err = s.deliver(delivery, now); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *WebhookService) deliver(delivery *models.WebhookDelivery, now time.Time) error {
+	webhook, err := s.repository.findByID(delivery.WebhookID)
+	if err == mgo.ErrNotFound {
+		delivery.Status = models.WebhookDeliveryFailed
+		return s.repository.updateDelivery(delivery)
+	} else if err != nil {
+		return err
+	}
+
+	attempt := s.post(webhook, delivery)
+	attempt.At = now
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+	case len(delivery.Attempts) >= webhookMaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+	default:
+		delivery.NextAttemptAt = now.Add(webhookRetryDelay(len(delivery.Attempts)))
+	}
+
+	return s.repository.updateDelivery(delivery)
+}
+
+func (s *WebhookService) post(webhook *models.Webhook, delivery *models.WebhookDelivery) *models.WebhookAttempt {
+	attempt := &models.WebhookAttempt{}
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.Hex())
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(webhook.Secret, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// dial connects to the first address of the webhook host unless the host resolves to a private one
+func (s *WebhookService) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := s.resolve(host)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+}
+
+// resolve returns the addresses of the webhook host, all of them have to be public
+func (s *WebhookService) resolve(host string) ([]net.IP, error) {
+	ips, err := s.lookupIP(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve webhook host `%s`: %s", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("failed to resolve webhook host `%s`", host)
+	}
+
+	for _, ip := range ips {
+		if !s.allowedIP(ip) {
+			return nil, fmt.Errorf("webhook host `%s` resolves to a private address", host)
+		}
+	}
+	return ips, nil
+}
+
+// isPublicIP tells whether the address is reachable from the internet rather than from our own network only
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, block := range webhookPrivateBlocks {
+		if block.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	result := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, block, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, block)
+	}
+	return result
+}
+
+// SignWebhookPayload returns hex encoded HMAC-SHA256 of the payload, receivers compare it
+// with the X-Tuna-Signature header to make sure the event comes from us
+func SignWebhookPayload(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay - 1, 2, 4, 8... minutes after the failed attempt
+func webhookRetryDelay(attempts int) time.Duration {
+	return webhookFirstRetryDelay * time.Duration(1<<uint(attempts-1))
+}
+
+func isWebhookEvent(event string) bool {
+	for _, e := range webhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package data
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/pavlo/gosuite"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestWebhookService(t *testing.T) {
+	gosuite.Run(t, &WebhookServiceTestSuite{Is: is.New(t)})
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	s := is.New(t)
+
+	s.Equal(webhookRetryDelay(1), time.Minute)
+	s.Equal(webhookRetryDelay(2), 2*time.Minute)
+	s.Equal(webhookRetryDelay(4), 8*time.Minute)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	s := is.New(t)
+
+	for _, address := range []string{"127.0.0.1", "::1", "169.254.169.254", "10.1.2.3", "172.20.0.1", "192.168.1.1", "100.64.0.1", "0.0.0.0", "fd00::1"} {
+		s.False(isPublicIP(net.ParseIP(address)))
+	}
+	for _, address := range []string{"93.184.216.34", "172.32.0.1", "2606:2800:220:1::1"} {
+		s.True(isPublicIP(net.ParseIP(address)))
+	}
+}
+
+func (s *WebhookServiceTestSuite) TestCreateWebhook(t *testing.T) {
+	webhook, err := s.service.CreateWebhook(s.admin, "https://example.com/hook", []string{models.WebhookEventTimerStopped})
+	s.Nil(err)
+	s.NotEqual(webhook.Secret, "")
+	s.Equal(webhook.TeamID, "team")
+
+	_, err = s.service.CreateWebhook(s.admin, "ftp://example.com/hook", nil)
+	s.Err(err)
+	s.Equal(err.Error(), "wrong webhook url `ftp://example.com/hook`")
+
+	_, err = s.service.CreateWebhook(s.admin, "https://example.com/hook", []string{"timer.exploded"})
+	s.Err(err)
+	s.Equal(err.Error(), "unknown event `timer.exploded`")
+
+	member := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{}}
+	_, err = s.service.CreateWebhook(member, "https://example.com/hook", nil)
+	s.Equal(err, ErrForbidden)
+
+	for _, webhookURL := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest", "https://10.0.0.5/hook", "http://intranet/hook"} {
+		_, err = s.service.CreateWebhook(s.admin, webhookURL, nil)
+		s.Err(err)
+	}
+
+	stranger := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "another-team", Role: models.RoleAdmin}
+	_, err = s.service.Deliveries(stranger, webhook.ID.Hex())
+	s.Err(err)
+	s.Equal(err.Error(), "webhook not found")
+}
+
+func (s *WebhookServiceTestSuite) TestFireAndDeliver(t *testing.T) {
+	var body, signature, event string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := ioutil.ReadAll(r.Body)
+		body = string(payload)
+		signature = r.Header.Get(WebhookSignatureHeader)
+		event = r.Header.Get(WebhookEventHeader)
+	}))
+	defer server.Close()
+	s.allowLocalServer()
+
+	webhook, _ := s.service.CreateWebhook(s.admin, server.URL, []string{models.WebhookEventTimerStopped})
+	all, _ := s.service.CreateWebhook(s.admin, server.URL, nil)
+
+	timer := &models.Timer{ID: bson.NewObjectId(), TeamID: "team", TaskName: "task"}
+	s.service.Fire(models.WebhookEventTimerStarted, timer)
+	s.service.Fire(models.WebhookEventTimerStopped, timer)
+
+	deliveries, _ := s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Len(deliveries, 1)
+	deliveries, _ = s.service.Deliveries(s.admin, all.ID.Hex())
+	s.Len(deliveries, 2)
+
+	s.Nil(s.service.DeliverDue(time.Now()))
+
+	deliveries, _ = s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Equal(deliveries[0].Status, models.WebhookDeliveryDelivered)
+	s.Len(deliveries[0].Attempts, 1)
+	s.Equal(deliveries[0].Attempts[0].StatusCode, http.StatusOK)
+	s.Equal(event, models.WebhookEventTimerStopped)
+	s.Equal(signature, "sha256="+SignWebhookPayload(all.Secret, body))
+}
+
+func (s *WebhookServiceTestSuite) TestDeliverRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	s.allowLocalServer()
+
+	webhook, _ := s.service.CreateWebhook(s.admin, server.URL, nil)
+	s.service.Fire(models.WebhookEventTimerDeleted, &models.Timer{ID: bson.NewObjectId(), TeamID: "team"})
+
+	now := time.Now()
+	s.Nil(s.service.DeliverDue(now))
+
+	deliveries, _ := s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Equal(deliveries[0].Status, models.WebhookDeliveryPending)
+	s.Equal(deliveries[0].Attempts[0].Error, "unexpected response status 503")
+	s.True(deliveries[0].NextAttemptAt.After(now.Add(59 * time.Second)))
+
+	// not due yet
+	s.Nil(s.service.DeliverDue(now.Add(30 * time.Second)))
+	deliveries, _ = s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Len(deliveries[0].Attempts, 1)
+
+	for i := 1; i < webhookMaxAttempts; i++ {
+		now = now.Add(webhookRetryDelay(i))
+		s.Nil(s.service.DeliverDue(now))
+	}
+
+	deliveries, _ = s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Equal(deliveries[0].Status, models.WebhookDeliveryFailed)
+	s.Len(deliveries[0].Attempts, webhookMaxAttempts)
+}
+
+func (s *WebhookServiceTestSuite) TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	// the host has been public when the webhook was created and resolves to our network now
+	s.allowLocalServer()
+	webhook, err := s.service.CreateWebhook(s.admin, server.URL, nil)
+	s.Nil(err)
+	s.service.allowedIP = isPublicIP
+
+	s.service.Fire(models.WebhookEventTimerDeleted, &models.Timer{ID: bson.NewObjectId(), TeamID: "team"})
+	s.Nil(s.service.DeliverDue(time.Now()))
+
+	deliveries, _ := s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Equal(deliveries[0].Status, models.WebhookDeliveryPending)
+	s.True(strings.Contains(deliveries[0].Attempts[0].Error, "resolves to a private address"))
+	s.Equal(requests, 0)
+}
+
+func (s *WebhookServiceTestSuite) TestTimerServiceFiresEvents(t *testing.T) {
+	webhook, _ := s.service.CreateWebhook(s.admin, "https://example.com/hook", nil)
+
+	timerService := NewTimerService(s.session)
+	user := &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", SlackUserInfo: &slack.User{}}
+	timer, err := timerService.StartTimer("team", &models.Project{ID: bson.NewObjectId()}, user, "task")
+	s.Nil(err)
+	s.Nil(timerService.StopTimer(timer))
+	s.Nil(timerService.DeleteUserTimer(user, timer))
+
+	deliveries, _ := s.service.Deliveries(s.admin, webhook.ID.Hex())
+	s.Len(deliveries, 3)
+
+	events := map[string]bool{}
+	for _, delivery := range deliveries {
+		events[delivery.Event] = true
+	}
+	s.True(events[models.WebhookEventTimerStarted])
+	s.True(events[models.WebhookEventTimerStopped])
+	s.True(events[models.WebhookEventTimerDeleted])
+}
+
+type WebhookServiceTestSuite struct {
+	*is.Is
+	env     *utils.Environment
+	session *mgo.Session
+	service *WebhookService
+	admin   *models.TeamUser
+}
+
+func (s *WebhookServiceTestSuite) SetUpSuite() {
+	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
+
+	session, err := utils.ConnectToDatabase(e.Config)
+	if err != nil {
+		log.Fatal("Failed to connect to DB!")
+	}
+
+	e.MigrateDatabase(session)
+
+	s.env = e
+	s.session = session.Clone()
+	s.service = NewWebhookService(s.session)
+	s.admin = &models.TeamUser{ID: bson.NewObjectId(), TeamID: "team", Role: models.RoleAdmin}
+}
+
+func (s *WebhookServiceTestSuite) TearDownSuite() {
+	s.session.Close()
+}
+
+func (s *WebhookServiceTestSuite) SetUp() {
+	utils.TruncateTables(s.session)
+
+	// example.com is public, everything else is in our network
+	s.service.lookupIP = func(host string) ([]net.IP, error) {
+		if host == "example.com" {
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IP{ip}, nil
+		}
+		return []net.IP{net.ParseIP("10.0.0.1")}, nil
+	}
+	s.service.allowedIP = isPublicIP
+}
+
+// allowLocalServer lets the webhooks reach test servers listening on the loopback
+func (s *WebhookServiceTestSuite) allowLocalServer() {
+	s.service.allowedIP = func(ip net.IP) bool { return true }
+}
+
+func (s *WebhookServiceTestSuite) TearDown() {}
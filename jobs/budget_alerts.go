@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"log"
+	"time"
+)
+
+type BudgetAlerts struct {
+	env     *utils.Environment
+	session *mgo.Session
+}
+
+func NewBudgetAlerts(env *utils.Environment, session *mgo.Session) *BudgetAlerts {
+	return &BudgetAlerts{
+		env:     env,
+		session: session,
+	}
+}
+
+func (j *BudgetAlerts) Run() {
+	log.Println("BudgetAlerts launched!")
+
+	service := data.NewBudgetService(j.session)
+	if err := service.NotifyThresholdsCrossed(time.Now()); err != nil {
+		log.Printf("BudgetAlerts failed: %s", err)
+	}
+
+	log.Println("BudgetAlerts finished!")
+}
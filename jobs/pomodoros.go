@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"log"
+	"time"
+)
+
+// Pomodoros completes pomodoros and breaks which are over and lets their users know
+type Pomodoros struct {
+	env     *utils.Environment
+	session *mgo.Session
+}
+
+func NewPomodoros(env *utils.Environment, session *mgo.Session) *Pomodoros {
+	return &Pomodoros{
+		env:     env,
+		session: session,
+	}
+}
+
+func (j *Pomodoros) Run() {
+	service := data.NewPomodoroService(j.session)
+	if err := service.CompleteDue(time.Now()); err != nil {
+		log.Printf("Pomodoros failed: %s", err)
+	}
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"log"
+	"time"
+)
+
+type TimeReminders struct {
+	env     *utils.Environment
+	session *mgo.Session
+}
+
+func NewTimeReminders(env *utils.Environment, session *mgo.Session) *TimeReminders {
+	return &TimeReminders{
+		env:     env,
+		session: session,
+	}
+}
+
+func (j *TimeReminders) Run() {
+	log.Println("TimeReminders launched!")
+
+	service := data.NewReminderService(j.session)
+	if err := service.RemindUntrackedDays(time.Now()); err != nil {
+		log.Printf("TimeReminders failed: %s", err)
+	}
+
+	log.Println("TimeReminders finished!")
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"gopkg.in/mgo.v2"
+	"log"
+	"time"
+)
+
+type WebhookDeliveries struct {
+	env     *utils.Environment
+	session *mgo.Session
+}
+
+func NewWebhookDeliveries(env *utils.Environment, session *mgo.Session) *WebhookDeliveries {
+	return &WebhookDeliveries{
+		env:     env,
+		session: session,
+	}
+}
+
+func (j *WebhookDeliveries) Run() {
+	service := data.NewWebhookService(j.session)
+	if err := service.DeliverDue(time.Now()); err != nil {
+		log.Printf("WebhookDeliveries failed: %s", err)
+	}
+}
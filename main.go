@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/justinas/alice"
+	"github.com/cleverua/tuna-timer-api/data"
 	"github.com/cleverua/tuna-timer-api/utils"
 	"github.com/cleverua/tuna-timer-api/web"
 	"time"
@@ -37,11 +38,16 @@ func main() {
 
 	environment.MigrateDatabase(session)
 	handlers := web.NewHandlers(environment, session)
-	fh := web.NewFrontendHandlers(environment, session)
+	jwtSettings, err := web.NewJWTSettings(environment.Config)
+	if err != nil {
+		log.Fatalf("Failed to read JWT settings: %s", err)
+	}
+	fh := web.NewFrontendHandlers(environment, session, jwtSettings)
 	secureCTX := web.SecureContext{
 		Origin:  environment.Config.UString("origin.url"),
 		Session: session,
 		Env: 	 environment,
+		JWT: 	 jwtSettings,
 	}
 
 	public := alice.New(web.LoggingMiddleware, web.RecoveryMiddleware, secureCTX.CorsMiddleware)
@@ -49,8 +55,31 @@ func main() {
 		web.LoggingMiddleware,
 		web.RecoveryMiddleware,
 		secureCTX.CorsMiddleware,
-		web.JWTMiddleware,
-		secureCTX.CurrentUserMiddleware)
+		secureCTX.AuthenticationMiddleware)
+
+	// Every secure route is guarded by a permission the user's role has to grant
+	viewOwnData := secure.Append(secureCTX.RequirePermission(data.PermissionViewOwnData))
+	trackTime := secure.Append(secureCTX.RequirePermission(data.PermissionTrackTime))
+	manageBudgets := secure.Append(secureCTX.RequirePermission(data.PermissionManageBudgets))
+	reviewTimesheets := secure.Append(secureCTX.RequirePermission(data.PermissionReviewTimesheets))
+	importData := secure.Append(secureCTX.RequirePermission(data.PermissionImportData))
+	manageWebhooks := secure.Append(secureCTX.RequirePermission(data.PermissionManageWebhooks))
+	manageTeam := secure.Append(secureCTX.RequirePermission(data.PermissionManageTeam))
+	viewTeamReports := secure.Append(secureCTX.RequirePermission(data.PermissionViewTeamReports))
+	assignRoles := secure.Append(secureCTX.RequirePermission(data.PermissionAssignRoles))
+	// Access tokens and the secret URL of the calendar feed are managed within a session only
+	withinSession := viewOwnData.Append(secureCTX.RequireSession)
+
+	// Event streams take the token from the query too and are not logged so that the token stays out of the logs
+	stream := alice.New(
+		web.RecoveryMiddleware,
+		secureCTX.CorsMiddleware,
+		secureCTX.StreamJWTMiddleware,
+		secureCTX.CurrentUserMiddleware,
+		secureCTX.RequirePermission(data.PermissionViewOwnData))
+
+	// Calendar feeds are authorized by the token in their path and are not logged either
+	feed := alice.New(web.RecoveryMiddleware)
 
 	router := mux.NewRouter().StrictSlash(true)
 
@@ -60,6 +89,8 @@ func main() {
 	// to check SSL certificate - so we reply with a status handler here
 	router.Handle("/api/v1/timer", public.ThenFunc(handlers.Timer)).Methods("POST", "GET")
 
+	router.Handle("/api/v1/calendar/{token}.ics", feed.ThenFunc(handlers.CalendarFeed)).Methods("GET")
+
 	// Slack  OAuth2 stuff
 	router.Handle("/api/v1/slack/oauth2redirect", public.ThenFunc(handlers.SlackOauth2Redirect)).Methods("GET")
 
@@ -69,13 +100,71 @@ func main() {
 	// ===== Routes for frontend application
 	// Activates the pass and returns back a JWT token, it essentially logs the user in
 	router.Handle("/api/v1/frontend/session", public.ThenFunc(fh.Authenticate)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/session/refresh", public.ThenFunc(fh.RefreshSession)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/session/claims", viewOwnData.ThenFunc(fh.SessionClaims)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/session/logout", secure.Append(secureCTX.RequireSession).ThenFunc(fh.Logout)).Methods("POST", "OPTIONS")
 	// Routes for user data CRUD
-	router.Handle("/api/v1/frontend/timers", secure.ThenFunc(fh.TimersData)).Methods("GET", "OPTIONS")
-	router.Handle("/api/v1/frontend/timers", secure.ThenFunc(fh.CreateTimer)).Methods("POST", "OPTIONS")
-	router.Handle("/api/v1/frontend/timers/{id}", secure.ThenFunc(fh.UpdateTimer)).Methods("PUT", "OPTIONS")
-	router.Handle("/api/v1/frontend/timers/{id}", secure.ThenFunc(fh.DeleteTimer)).Methods("DELETE", "OPTIONS")
-	router.Handle("/api/v1/frontend/projects", secure.ThenFunc(fh.ProjectsData)).Methods("GET", "OPTIONS")
-	router.Handle("/api/v1/frontend/month_statistics", secure.ThenFunc(fh.MonthStatistics)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/timers", viewOwnData.ThenFunc(fh.TimersData)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/timers", trackTime.ThenFunc(fh.CreateTimer)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/timers/{id}", trackTime.ThenFunc(fh.UpdateTimer)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/timers/{id}", trackTime.ThenFunc(fh.DeleteTimer)).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/v1/frontend/projects", viewOwnData.ThenFunc(fh.ProjectsData)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/projects/{id}/budget", viewTeamReports.ThenFunc(fh.ProjectBudget)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/projects/{id}/budget", manageBudgets.ThenFunc(fh.UpdateProjectBudget)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/projects/{id}/rounding", manageTeam.ThenFunc(fh.UpdateProjectRounding)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets", viewOwnData.ThenFunc(fh.Timesheet)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets", trackTime.ThenFunc(fh.SubmitTimesheet)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets/grid", viewOwnData.ThenFunc(fh.TimesheetGrid)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets/grid", trackTime.ThenFunc(fh.SaveTimesheetGrid)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets/pending", reviewTimesheets.ThenFunc(fh.PendingTimesheets)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets/{id}/approve", reviewTimesheets.ThenFunc(fh.ApproveTimesheet)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/timesheets/{id}/reject", reviewTimesheets.ThenFunc(fh.RejectTimesheet)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/imports", importData.ThenFunc(fh.ImportTimers)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/access_tokens", withinSession.ThenFunc(fh.AccessTokens)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/access_tokens", withinSession.ThenFunc(fh.CreateAccessToken)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/access_tokens/{id}", withinSession.ThenFunc(fh.RevokeAccessToken)).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/v1/frontend/webhooks", manageWebhooks.ThenFunc(fh.Webhooks)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/webhooks", manageWebhooks.ThenFunc(fh.CreateWebhook)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/webhooks/{id}", manageWebhooks.ThenFunc(fh.DeleteWebhook)).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/v1/frontend/webhooks/{id}/deliveries", manageWebhooks.ThenFunc(fh.WebhookDeliveries)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/calendar_feed", viewOwnData.ThenFunc(fh.CalendarFeed)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/calendar_feed", withinSession.ThenFunc(fh.RegenerateCalendarFeed)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/calendar_feed", withinSession.ThenFunc(fh.RevokeCalendarFeed)).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/v1/frontend/meetings", viewOwnData.ThenFunc(fh.MeetingProposals)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/meetings/upload", trackTime.ThenFunc(fh.UploadMeetings)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/meetings/accept", trackTime.ThenFunc(fh.AcceptMeetings)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/meetings/dismiss", trackTime.ThenFunc(fh.DismissMeetings)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/pomodoro_settings", viewOwnData.ThenFunc(fh.PomodoroSettings)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/pomodoro_settings", trackTime.ThenFunc(fh.UpdatePomodoroSettings)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/work_schedule", viewOwnData.ThenFunc(fh.WorkSchedule)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/work_schedule", trackTime.ThenFunc(fh.UpdateWorkSchedule)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/capacity", viewOwnData.ThenFunc(fh.CapacityReport)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/tasks/suggestions", viewOwnData.ThenFunc(fh.TaskSuggestions)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/events", stream.ThenFunc(fh.Events)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/search", viewOwnData.ThenFunc(fh.Search)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/month_statistics", viewOwnData.ThenFunc(fh.MonthStatistics)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/statistics", viewOwnData.ThenFunc(fh.Statistics)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/heatmap", viewOwnData.ThenFunc(fh.Heatmap)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/report", viewTeamReports.ThenFunc(fh.TeamReport)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/issue_patterns", viewOwnData.ThenFunc(fh.IssuePatterns)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/issue_patterns", manageTeam.ThenFunc(fh.UpdateIssuePatterns)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/work_schedule", viewOwnData.ThenFunc(fh.TeamWorkSchedule)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/work_schedule", manageTeam.ThenFunc(fh.UpdateTeamWorkSchedule)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/rounding", viewOwnData.ThenFunc(fh.TeamRounding)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/rounding", manageTeam.ThenFunc(fh.UpdateTeamRounding)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/time_off", viewOwnData.ThenFunc(fh.TimeOff)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/time_off", trackTime.ThenFunc(fh.CreateTimeOff)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/time_off/{id}", trackTime.ThenFunc(fh.DeleteTimeOff)).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/v1/frontend/holidays", viewOwnData.ThenFunc(fh.Holidays)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/holidays", manageTeam.ThenFunc(fh.CreateHoliday)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/holidays/import", manageTeam.ThenFunc(fh.ImportHolidays)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/holidays/{id}", manageTeam.ThenFunc(fh.DeleteHoliday)).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/v1/frontend/heartbeat", trackTime.ThenFunc(fh.Heartbeat)).Methods("POST", "OPTIONS")
+	router.Handle("/api/v1/frontend/idle", viewOwnData.ThenFunc(fh.IdleIntervals)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/idle/{id}", trackTime.ThenFunc(fh.ResolveIdleInterval)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/users", viewTeamReports.ThenFunc(fh.TeamUsers)).Methods("GET", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/users/{id}/role", assignRoles.ThenFunc(fh.AssignRole)).Methods("PUT", "OPTIONS")
+	router.Handle("/api/v1/frontend/team/users/{id}/revoke_sessions", manageTeam.ThenFunc(fh.RevokeUserSessions)).Methods("POST", "OPTIONS")
 
 	// Temporary stuff, remove eventually
 	router.Handle("/api/v1/frontend/auth/validate", secure.ThenFunc(handlers.ValidateAuthToken)).Methods("GET", "OPTIONS")
@@ -104,6 +193,26 @@ func launchBGJobEngine(env *utils.Environment, session *mgo.Session) *cron.Cron
 	bgJobEngine.AddJob("0 25 * * *", jobs.NewClearPasses(env, session.Clone()))
 	log.Println("--- Scheduled ClearPasses job")
 
+	// Runs once an hour at 45 minutes
+	// ---------------- s  m   h d m
+	bgJobEngine.AddJob("0 45 * * *", jobs.NewBudgetAlerts(env, session.Clone()))
+	log.Println("--- Scheduled BudgetAlerts job")
+
+	// Runs once an hour at 55 minutes, reminds the users whose workday ends in that hour
+	// ---------------- s  m   h d m
+	bgJobEngine.AddJob("0 55 * * *", jobs.NewTimeReminders(env, session.Clone()))
+	log.Println("--- Scheduled TimeReminders job")
+
+	// Runs every minute
+	// ---------------- s  m   h d m
+	bgJobEngine.AddJob("0 * * * *", jobs.NewWebhookDeliveries(env, session.Clone()))
+	log.Println("--- Scheduled WebhookDeliveries job")
+
+	// Runs every minute
+	// ---------------- s  m   h d m
+	bgJobEngine.AddJob("0 * * * *", jobs.NewPomodoros(env, session.Clone()))
+	log.Println("--- Scheduled Pomodoros job")
+
 	bgJobEngine.Start()
 	return bgJobEngine
 }
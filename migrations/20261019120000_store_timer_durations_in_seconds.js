@@ -0,0 +1,36 @@
+// This migration is for development DB only!
+// To run it just paste into shell next script:
+// mongo < 20261019120000_store_timer_durations_in_seconds.js
+
+conn = new Mongo();
+db = conn.getDB("tuna_timer_dev");
+
+DBQuery.shellBatchSize = db.timers.count();
+timers = db.timers.find({seconds: null}).toArray();
+
+timers.forEach(function(timer) {
+    timer.seconds = NumberInt((timer.minutes || 0) * 60);
+    timer.actual_seconds = NumberInt((timer.actual_minutes || 0) * 60);
+    (timer.edits || []).forEach(function(edit) {
+        edit.seconds = NumberInt((edit.minutes || 0) * 60);
+        delete edit.minutes;
+    });
+    delete timer.minutes;
+    delete timer.actual_minutes;
+    timer.ver = NumberInt(2);
+    db.timers.save(timer);
+});
+
+db.timesheets.find({seconds: null}).forEach(function(timesheet) {
+    db.timesheets.update({_id: timesheet._id}, {
+        $set: {seconds: NumberInt((timesheet.minutes || 0) * 60)},
+        $unset: {minutes: ""}
+    });
+});
+
+db.meeting_proposals.find({seconds: null}).forEach(function(proposal) {
+    db.meeting_proposals.update({_id: proposal._id}, {
+        $set: {seconds: NumberInt((proposal.minutes || 0) * 60)},
+        $unset: {minutes: ""}
+    });
+});
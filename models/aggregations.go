@@ -1,15 +1,117 @@
 package models
 
+import "time"
+
 type TaskAggregation struct {
 	TaskHash            string `bson:"task_hash"`
 	ProjectExternalName string `bson:"project_ext_name"`
 	ProjectExternalID   string `bson:"project_ext_id"`
 	Name                string `bson:"task_name"`
-	Minutes             int    `bson:"minutes"`
+	Seconds             int    `bson:"seconds"`
+	Issues              []*IssueReference `bson:"issues"`
+	Pomodoros           int    `bson:"pomodoros"`
 }
 
 type UserStatisticsAggregation struct {
+	// Date is the bucket in user's timezone: a day formatted as 2006-01-02, an ISO week as 2006-W01
+	// or a month as 2006-01. Day is the day of month of day buckets, zero for the others
+	Date		string	 `json:"date" bson:"date"`
 	Day		int	 `json:"day" bson:"day"`
-	Minutes		int	 `json:"minutes" bson:"minutes"`
+	Seconds		int	 `json:"seconds" bson:"seconds"`
 	ProjectsNames	[]string `json:"projects_names" bson:"projects_names"`
+	// Projects is the time of the bucket by projects, the longest first
+	Projects	[]*ProjectStatistics `json:"projects" bson:"projects"`
+}
+
+// ProjectStatistics - the time of a project in a bucket of user's statistics
+type ProjectStatistics struct {
+	ProjectID           string `json:"project_id" bson:"project_id"`
+	ProjectExternalName string `json:"project_ext_name" bson:"project_ext_name"`
+	Seconds             int    `json:"seconds" bson:"seconds"`
+}
+
+// HeatmapDay - a day of user's activity heatmap. Level grades the time of the day from 0 (none)
+// to 4 (close to the busiest day of the heatmap)
+type HeatmapDay struct {
+	Date    string `json:"date"`
+	Seconds int    `json:"seconds"`
+	Level   int    `json:"level"`
+}
+
+// TeamReportAggregation is a row of the team report. Only the fields the report is grouped by are filled in
+type TeamReportAggregation struct {
+	TeamUserID          string `json:"team_user_id,omitempty" bson:"team_user_id,omitempty"`
+	ProjectID           string `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	ProjectExternalName string `json:"project_ext_name,omitempty" bson:"project_ext_name,omitempty"`
+	TaskHash            string `json:"task_hash,omitempty" bson:"task_hash,omitempty"`
+	TaskName            string `json:"task_name,omitempty" bson:"task_name,omitempty"`
+	Issue               string `json:"issue,omitempty" bson:"issue,omitempty"`
+	IssueURL            string `json:"issue_url,omitempty" bson:"issue_url,omitempty"`
+	// Period is a day (2006-01-02), an ISO week (2006-W01) or a month (2006-01) in the requester's timezone
+	Period      string `json:"period,omitempty" bson:"period,omitempty"`
+	Seconds     int    `json:"seconds" bson:"seconds"`
+	// RoundedSeconds is the time billed by the rounding rules of the projects
+	RoundedSeconds int `json:"rounded_seconds" bson:"rounded_seconds"`
+	TimersCount int    `json:"timers_count" bson:"timers_count"`
+	Pomodoros   int    `json:"pomodoros" bson:"pomodoros"`
+}
+
+// TaskSearchResult - the timers of a task that match a search. Score is the relevance of the task name,
+// the most relevant tasks come first
+type TaskSearchResult struct {
+	TaskHash            string            `json:"task_hash" bson:"_id"`
+	TaskName            string            `json:"task_name" bson:"task_name"`
+	ProjectID           string            `json:"project_id" bson:"project_id"`
+	ProjectExternalID   string            `json:"project_ext_id" bson:"project_ext_id"`
+	ProjectExternalName string            `json:"project_ext_name" bson:"project_ext_name"`
+	Issues              []*IssueReference `json:"issues" bson:"issues"`
+	TeamUserIDs         []string          `json:"team_user_ids" bson:"team_user_ids"`
+	Score               float64           `json:"score" bson:"score"`
+	Seconds             int               `json:"seconds" bson:"seconds"`
+	TimersCount         int               `json:"timers_count" bson:"timers_count"`
+	FirstStartedAt      time.Time         `json:"first_started_at" bson:"first_started_at"`
+	LastStartedAt       time.Time         `json:"last_started_at" bson:"last_started_at"`
+}
+
+// TaskSuggestion - a task name suggested while typing a new one. Tasks used often and lately come first,
+// the ones of the user weigh more than the ones of the team
+type TaskSuggestion struct {
+	TaskHash            string    `json:"task_hash" bson:"_id"`
+	TaskName            string    `json:"task_name" bson:"task_name"`
+	ProjectID           string    `json:"project_id" bson:"project_id"`
+	ProjectExternalID   string    `json:"project_ext_id" bson:"project_ext_id"`
+	ProjectExternalName string    `json:"project_ext_name" bson:"project_ext_name"`
+	TimersCount         int       `json:"timers_count" bson:"timers_count"`
+	OwnTimersCount      int       `json:"own_timers_count" bson:"own_timers_count"`
+	LastStartedAt       time.Time `json:"last_started_at" bson:"last_started_at"`
+	Score               float64   `json:"score" bson:"-"`
+}
+
+// TimesheetGrid is a week of user's time as a matrix of project/task rows by days, the time is in seconds
+type TimesheetGrid struct {
+	WeekStart time.Time           `json:"week_start"`
+	Days      []string            `json:"days"`
+	Rows      []*TimesheetGridRow `json:"rows"`
+	DayTotals []int               `json:"day_totals"`
+	Total     int                 `json:"total"`
+}
+
+// TimesheetGridRow holds the seconds of a task for each day of the week
+type TimesheetGridRow struct {
+	ProjectID           string `json:"project_id"`
+	ProjectExternalID   string `json:"project_ext_id"`
+	ProjectExternalName string `json:"project_ext_name"`
+	TaskHash            string `json:"task_hash"`
+	TaskName            string `json:"task_name"`
+	Seconds             []int  `json:"seconds"`
+	Total               int    `json:"total"`
+}
+
+// TimesheetGridCell is a cell of the grid edited by the user, Error tells why the cell is not saved
+type TimesheetGridCell struct {
+	ProjectID string `json:"project_id"`
+	TaskName  string `json:"task_name"`
+	Date      string `json:"date"`
+	Seconds   int    `json:"seconds"`
+	Error     string `json:"error,omitempty"`
 }
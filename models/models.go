@@ -8,10 +8,56 @@ import (
 )
 
 const (
-	ModelVersionTeam     = 1
-	ModelVersionTeamUser = 1
-	ModelVersionTimer    = 1
-	ModelVersionPass     = 1
+	ModelVersionTeam      = 1
+	ModelVersionTeamUser  = 1
+	ModelVersionTimer     = 2
+	ModelVersionPass      = 1
+	ModelVersionTimesheet = 1
+	ModelVersionWebhook   = 1
+	ModelVersionMeeting   = 1
+	ModelVersionPomodoro  = 1
+	ModelVersionTimeOff   = 1
+	ModelVersionHoliday   = 1
+	ModelVersionIdle      = 1
+	ModelVersionToken     = 1
+	ModelVersionPassClaim = 1
+)
+
+const (
+	// BudgetKindHours - project budget is measured in hours
+	BudgetKindHours = "hours"
+	// BudgetKindMoney - project budget is measured in money, hours are converted using the hourly rate
+	BudgetKindMoney = "money"
+
+	// BudgetPeriodTotal - the budget covers the whole life of the project
+	BudgetPeriodTotal = "total"
+	// BudgetPeriodMonthly - the budget is renewed at the beginning of each calendar month
+	BudgetPeriodMonthly = "monthly"
+)
+
+const (
+	// RoundingUp - the time is rounded up to the next increment
+	RoundingUp = "up"
+	// RoundingDown - the time is rounded down to the previous increment
+	RoundingDown = "down"
+	// RoundingNearest - the time is rounded to the nearest increment, halves are rounded up
+	RoundingNearest = "nearest"
+
+	// RoundingPerEntry - every timer is rounded on its own
+	RoundingPerEntry = "entry"
+	// RoundingPerDay - the total a user tracked for a project during a day is rounded
+	RoundingPerDay = "day"
+)
+
+const (
+	// TimerSourceToggl - the timer is imported from a Toggl CSV export
+	TimerSourceToggl = "toggl"
+	// TimerSourceHarvest - the timer is imported from a Harvest CSV export
+	TimerSourceHarvest = "harvest"
+	// TimerSourceClockify - the timer is imported from a Clockify CSV export
+	TimerSourceClockify = "clockify"
+	// TimerSourceMeeting - the timer is an accepted meeting proposal of an uploaded calendar
+	TimerSourceMeeting = "meeting"
 )
 
 // Team represents a Slack team
@@ -27,17 +73,75 @@ type Team struct {
 	Projects         []*Project           `json:"projects" bson:"projects"`
 	CreatedAt        time.Time            `json:"created_at" bson:"created_at"`
 	SlackOAuth       *slack.OAuthResponse `json:"slack_oauth" bson:"slack_oauth"`
+	IssuePatterns    []*IssuePattern      `json:"issue_patterns" bson:"issue_patterns"`
+	// WorkSchedule is the default schedule of team members who have none of their own, nil means the built-in default
+	WorkSchedule     *WorkSchedule        `json:"work_schedule" bson:"work_schedule,omitempty"`
+	// Rounding is how the time of the projects that have no rule of their own is billed, nil means it is not rounded
+	Rounding         *RoundingRule        `json:"rounding" bson:"rounding,omitempty"`
 	ModelVersion     int                  `json:"ver" bson:"ver"`
 }
 
-// Project - is a project you can associate tasks with and tracks their time. It is embedded in Team
+// IssuePattern recognises references to an issue tracker in task names. Pattern is a regular expression,
+// the whole match becomes the issue key. URL is a link template where `{key}` and `{name}` of every
+// named group of the pattern get substituted, e.g. `https://github.com/{repo}/issues/{number}`
+type IssuePattern struct {
+	Pattern string `json:"pattern" bson:"pattern"`
+	URL     string `json:"url" bson:"url"`
+}
+
+// IssueReference - an issue a timer's task refers to
+type IssueReference struct {
+	Key string `json:"key" bson:"key"`
+	URL string `json:"url" bson:"url"`
+}
+
+// WorkSchedule - hours a team user is expected to work on every day of the week.
+// Hours are indexed by time.Weekday, Sunday goes first; WeekStart is the weekday weeks start with
+type WorkSchedule struct {
+	Hours     []float64    `json:"hours" bson:"hours"`
+	WeekStart time.Weekday `json:"week_start" bson:"week_start"`
+}
+
+// RoundingRule - how the tracked time is rounded for billing, e.g. up to 6 or 15 minute increments.
+// Raw seconds of timers are never changed, reports show the rounded time next to them
+type RoundingRule struct {
+	Increment int    `json:"increment" bson:"increment"`
+	Direction string `json:"direction" bson:"direction"`
+	Scope     string `json:"scope" bson:"scope"`
+}
+
+// Project - is a project you can associate tasks with and tracks their time. It is embedded in Team.
+// Projects created by imports are not bound to a Slack channel and have blank ExternalProjectID
 type Project struct {
-	ID                  bson.ObjectId `json:"id" bson:"_id,omitempty"`
-	ExternalProjectID   string        `json:"ext_id" bson:"ext_id"`
-	ExternalProjectName string        `json:"ext_name" bson:"ext_name"`
-	CreatedAt           time.Time     `json:"created_at" bson:"created_at"`
+	ID                  bson.ObjectId  `json:"id" bson:"_id,omitempty"`
+	ExternalProjectID   string         `json:"ext_id" bson:"ext_id"`
+	ExternalProjectName string         `json:"ext_name" bson:"ext_name"`
+	CreatedAt           time.Time      `json:"created_at" bson:"created_at"`
+	Budget              *ProjectBudget `json:"budget" bson:"budget,omitempty"`
+	Rounding            *RoundingRule  `json:"rounding" bson:"rounding,omitempty"`
 }
 
+// ProjectBudget - a limit of hours or money a project is allowed to consume. It is embedded in Project
+type ProjectBudget struct {
+	Kind       string  `json:"kind" bson:"kind"`
+	Period     string  `json:"period" bson:"period"`
+	Amount     float64 `json:"amount" bson:"amount"`
+	HourlyRate float64 `json:"hourly_rate" bson:"hourly_rate"`
+
+	// Thresholds (in percents) the project channel has already been notified about
+	// during the budget period identified by NotifiedPeriod
+	NotifiedThresholds []int  `json:"notified_thresholds" bson:"notified_thresholds"`
+	NotifiedPeriod     string `json:"notified_period" bson:"notified_period"`
+}
+
+const (
+	RoleOwner   = "owner"
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleMember  = "member"
+	RoleViewer  = "viewer"
+)
+
 // TeamUser represents a Slack user that belongs to a team.
 // We not going to call it `User` because we may want to have admin users to administer stuff via UI etc
 type TeamUser struct {
@@ -46,8 +150,23 @@ type TeamUser struct {
 	SlackUserInfo    *slack.User   `json:"slack_user_info" bson:"slack_user_info"`
 	ExternalUserID   string        `json:"ext_id" bson:"ext_id"`
 	ExternalUserName string        `json:"ext_name" bson:"ext_name"`
-	CreatedAt        time.Time     `json:"created_at" bson:"created_at"`
-	ModelVersion     int           `json:"ver" bson:"ver"`
+	// Role is one of owner, admin, manager, member or viewer. Blank role is derived from Slack owner/admin flags
+	Role string `json:"role" bson:"role"`
+	// ManagedProjectIDs limits project scoped permissions of managers and viewers to these projects
+	ManagedProjectIDs []string  `json:"managed_project_ids" bson:"managed_project_ids"`
+	// CalendarToken is the secret part of the user's iCalendar feed URL, blank when the feed is off
+	CalendarToken     string    `json:"-" bson:"calendar_token,omitempty"`
+	// PomodoroSettings overrides the default pomodoro durations, nil means the defaults
+	PomodoroSettings  *PomodoroSettings `json:"pomodoro_settings" bson:"pomodoro_settings,omitempty"`
+	// WorkSchedule overrides the schedule of the team, nil means the team's one
+	WorkSchedule      *WorkSchedule `json:"work_schedule" bson:"work_schedule,omitempty"`
+	// TokenVersion is put into user's JWTs, bumping it revokes all of them
+	TokenVersion      int       `json:"-" bson:"token_version"`
+	// AccessToken is the personal access token the user made the request with, nil for a session.
+	// It is not stored, its scopes limit the permissions of the role for the request
+	AccessToken       *AccessToken `json:"-" bson:"-"`
+	CreatedAt         time.Time `json:"created_at" bson:"created_at"`
+	ModelVersion      int       `json:"ver" bson:"ver"`
 }
 
 // Timer - a time record that has start and finish dates. Belongs to a slack user and a task
@@ -63,17 +182,28 @@ type Timer struct {
 	TaskHash            string        `json:"task_hash" bson:"task_hash"`
 	CreatedAt           time.Time     `json:"created_at" bson:"created_at"`
 	FinishedAt          *time.Time    `json:"finished_at" bson:"finished_at"`
-	Minutes             int           `json:"minutes" bson:"minutes"`
-	ActualMinutes	    int		  `json:"actual_minutes" bson:"actual_minutes"`
+	// Seconds is the duration of the timer including the edits, ActualSeconds is the time it was running for
+	Seconds             int           `json:"seconds" bson:"seconds"`
+	ActualSeconds       int           `json:"actual_seconds" bson:"actual_seconds"`
 	Edits		    []*TimeEdit   `json:"edits" bson:"edits"`
+	Tags                []string      `json:"tags" bson:"tags"`
+	Issues              []*IssueReference `json:"issues" bson:"issues"`
+	// Pomodoros is the number of pomodoros completed while the timer was running
+	Pomodoros           int           `json:"pomodoros" bson:"pomodoros"`
+	// Source tells where a timer came from if it was not tracked in Slack, SourceID identifies the original entry
+	Source              string        `json:"source,omitempty" bson:"source,omitempty"`
+	SourceID            string        `json:"source_id,omitempty" bson:"source_id,omitempty"`
+	// LastHeartbeatAt is the last time the frontend or a desktop helper told the user is active while the timer runs
+	LastHeartbeatAt     *time.Time    `json:"last_heartbeat_at,omitempty" bson:"last_heartbeat_at,omitempty"`
 	DeletedAt           *time.Time    `json:"deleted_at" bson:"deleted_at"`
 	ModelVersion        int           `json:"ver" bson:"ver"`
 }
 
+// TimeEdit - a manual correction of timer's duration, Seconds may be negative
 type TimeEdit struct {
 	TeamUserID          string        `json:"team_user_id" bson:"team_user_id"`
 	CreatedAt           time.Time     `json:"created_at" bson:"created_at"`
-	Minutes             int           `json:"minutes" bson:"minutes"`
+	Seconds             int           `json:"seconds" bson:"seconds"`
 }
 
 // Pass - a one time login token that is used by frontend to get JWT from the backend
@@ -89,6 +219,263 @@ type Pass struct {
 	ModelVersion int           `json:"ver" bson:"ver"`
 }
 
+// PassClaim - a login with a pass, the log of user's logins
+type PassClaim struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	TeamUserID   string        `json:"team_user_id" bson:"team_user_id"`
+	PassID       string        `json:"pass_id" bson:"pass_id"`
+	UserAgent    string        `json:"user_agent" bson:"user_agent"`
+	IP           string        `json:"ip" bson:"ip"`
+	ClaimedAt    time.Time     `json:"claimed_at" bson:"claimed_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// LoginFailure - a login with an unknown, expired or used pass. Recent failures of an IP address
+// block its logins for a while
+type LoginFailure struct {
+	ID        bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	IP        string        `json:"ip" bson:"ip"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+const (
+	TimesheetStatusDraft     = "draft"
+	TimesheetStatusSubmitted = "submitted"
+	TimesheetStatusApproved  = "approved"
+	TimesheetStatusRejected  = "rejected"
+)
+
+// Timesheet - a week of user's timers submitted for an approval. Timers covered by an approved timesheet are locked
+type Timesheet struct {
+	ID         bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID     string        `json:"team_id" bson:"team_id"`
+	TeamUserID string        `json:"team_user_id" bson:"team_user_id"`
+	// WeekStart is the date of the Monday the week starts with in the user's timezone
+	WeekStart time.Time `json:"week_start" bson:"week_start"`
+	// StartsAt and EndsAt are the boundaries of the week converted to UTC by user's timezone offset
+	StartsAt     time.Time  `json:"starts_at" bson:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at" bson:"ends_at"`
+	Status       string     `json:"status" bson:"status"`
+	Seconds      int        `json:"seconds" bson:"seconds"`
+	SubmittedAt  *time.Time `json:"submitted_at" bson:"submitted_at"`
+	ReviewerID   string     `json:"reviewer_id" bson:"reviewer_id"`
+	ReviewedAt   *time.Time `json:"reviewed_at" bson:"reviewed_at"`
+	Comment      string     `json:"comment" bson:"comment"`
+	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
+	ModelVersion int        `json:"ver" bson:"ver"`
+}
+
+// Timer lifecycle events webhooks can subscribe to
+const (
+	WebhookEventTimerStarted = "timer.started"
+	WebhookEventTimerStopped = "timer.stopped"
+	WebhookEventTimerCreated = "timer.created"
+	WebhookEventTimerUpdated = "timer.updated"
+	WebhookEventTimerDeleted = "timer.deleted"
+)
+
+// TimerEvent - a change of a timer pushed to the open frontends of the users allowed to see it
+type TimerEvent struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	TeamID     string    `json:"team_id"`
+	TeamUserID string    `json:"team_user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Timer      *Timer    `json:"timer"`
+}
+
+// Scopes of personal access tokens
+const (
+	AccessTokenScopeReadTimers  = "timers:read"
+	AccessTokenScopeWriteTimers = "timers:write"
+	AccessTokenScopeReports     = "reports"
+)
+
+// AccessToken - a personal access token scripts and plugins call the API with on behalf of the user.
+// Only a hash of the token is stored, Prefix is its beginning to tell the tokens apart
+type AccessToken struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	TeamUserID   string        `json:"team_user_id" bson:"team_user_id"`
+	Name         string        `json:"name" bson:"name"`
+	TokenHash    string        `json:"-" bson:"token_hash"`
+	Prefix       string        `json:"prefix" bson:"prefix"`
+	Scopes       []string      `json:"scopes" bson:"scopes"`
+	LastUsedAt   *time.Time    `json:"last_used_at" bson:"last_used_at"`
+	RevokedAt    *time.Time    `json:"revoked_at" bson:"revoked_at"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// Delivery statuses of webhook events
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook - a team's subscription to timer events. Blank Events means all of them.
+// Payloads are signed with the Secret using HMAC-SHA256
+type Webhook struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	URL          string        `json:"url" bson:"url"`
+	Secret       string        `json:"secret" bson:"secret"`
+	Events       []string      `json:"events" bson:"events"`
+	CreatedBy    string        `json:"created_by" bson:"created_by"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// WebhookDelivery - an event queued for delivery to a webhook along with the log of delivery attempts
+type WebhookDelivery struct {
+	ID            bson.ObjectId     `json:"id" bson:"_id,omitempty"`
+	WebhookID     string            `json:"webhook_id" bson:"webhook_id"`
+	TeamID        string            `json:"team_id" bson:"team_id"`
+	Event         string            `json:"event" bson:"event"`
+	Payload       string            `json:"payload" bson:"payload"`
+	Status        string            `json:"status" bson:"status"`
+	Attempts      []*WebhookAttempt `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" bson:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at" bson:"created_at"`
+	DeliveredAt   *time.Time        `json:"delivered_at" bson:"delivered_at"`
+}
+
+// WebhookAttempt - a single try to deliver an event
+type WebhookAttempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"status_code" bson:"status_code"`
+	Error      string    `json:"error" bson:"error"`
+}
+
+// Statuses of meeting proposals
+const (
+	MeetingProposalPending   = "pending"
+	MeetingProposalAccepted  = "accepted"
+	MeetingProposalDismissed = "dismissed"
+)
+
+// MeetingProposal - a calendar event a team user attended which may become a timer once the user accepts it
+type MeetingProposal struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	TeamUserID   string        `json:"team_user_id" bson:"team_user_id"`
+	EventUID     string        `json:"event_uid" bson:"event_uid"`
+	Summary      string        `json:"summary" bson:"summary"`
+	StartsAt     time.Time     `json:"starts_at" bson:"starts_at"`
+	EndsAt       time.Time     `json:"ends_at" bson:"ends_at"`
+	Seconds      int           `json:"seconds" bson:"seconds"`
+	Status       string        `json:"status" bson:"status"`
+	TimerID      string        `json:"timer_id" bson:"timer_id"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// Kinds and statuses of pomodoro intervals
+const (
+	PomodoroKindWork       = "work"
+	PomodoroKindShortBreak = "short_break"
+	PomodoroKindLongBreak  = "long_break"
+
+	PomodoroStatusRunning     = "running"
+	PomodoroStatusCompleted   = "completed"
+	PomodoroStatusInterrupted = "interrupted"
+)
+
+// PomodoroSettings - durations of user's pomodoros in minutes. A long break comes after every LongBreakEvery pomodoros
+type PomodoroSettings struct {
+	WorkMinutes       int `json:"work_minutes" bson:"work_minutes"`
+	ShortBreakMinutes int `json:"short_break_minutes" bson:"short_break_minutes"`
+	LongBreakMinutes  int `json:"long_break_minutes" bson:"long_break_minutes"`
+	LongBreakEvery    int `json:"long_break_every" bson:"long_break_every"`
+}
+
+// Pomodoro - an interval of a pomodoro session: either work on a timer's task or a break.
+// Breaks have no timer so they never count as tracked time
+type Pomodoro struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	TeamUserID   string        `json:"team_user_id" bson:"team_user_id"`
+	TimerID      string        `json:"timer_id,omitempty" bson:"timer_id,omitempty"`
+	TaskName     string        `json:"task_name" bson:"task_name"`
+	Kind         string        `json:"kind" bson:"kind"`
+	Status       string        `json:"status" bson:"status"`
+	StartsAt     time.Time     `json:"starts_at" bson:"starts_at"`
+	EndsAt       time.Time     `json:"ends_at" bson:"ends_at"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// Kinds of time off
+const (
+	TimeOffVacation = "vacation"
+	TimeOffSick     = "sick"
+	TimeOffOther    = "other"
+)
+
+// TimeOff - days a team user is away. StartDate and EndDate are dates in the user's timezone, both inclusive.
+// HalfDay halves the expected hours of every day of the range instead of taking them off completely
+type TimeOff struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	TeamUserID   string        `json:"team_user_id" bson:"team_user_id"`
+	Kind         string        `json:"kind" bson:"kind"`
+	StartDate    time.Time     `json:"start_date" bson:"start_date"`
+	EndDate      time.Time     `json:"end_date" bson:"end_date"`
+	HalfDay      bool          `json:"half_day" bson:"half_day"`
+	Note         string        `json:"note" bson:"note"`
+	CreatedBy    string        `json:"created_by" bson:"created_by"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// Holiday - a day off of the whole team. Date is the day in team members' timezones.
+// Holidays imported from a calendar keep the UID of their event in SourceID
+type Holiday struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	Date         time.Time     `json:"date" bson:"date"`
+	Name         string        `json:"name" bson:"name"`
+	HalfDay      bool          `json:"half_day" bson:"half_day"`
+	SourceID     string        `json:"source_id,omitempty" bson:"source_id,omitempty"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
+// Pagination - where a page of a list is. NextCursor asks for the page after it and is blank on the last page
+type Pagination struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// Statuses of idle intervals
+const (
+	IdleStatusPending   = "pending"
+	IdleStatusKept      = "kept"
+	IdleStatusDiscarded = "discarded"
+	IdleStatusSplit     = "split"
+)
+
+// IdleInterval - a gap between heartbeats of a running timer. It stays pending until the user decides whether
+// the time was work on the task (kept), no work at all (discarded by a negative edit of the timer)
+// or something else (split off the timer as a separate one, SplitTimerID)
+type IdleInterval struct {
+	ID           bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	TeamID       string        `json:"team_id" bson:"team_id"`
+	TeamUserID   string        `json:"team_user_id" bson:"team_user_id"`
+	TimerID      string        `json:"timer_id" bson:"timer_id"`
+	TaskName     string        `json:"task_name" bson:"task_name"`
+	StartedAt    time.Time     `json:"started_at" bson:"started_at"`
+	EndedAt      time.Time     `json:"ended_at" bson:"ended_at"`
+	Status       string        `json:"status" bson:"status"`
+	SplitTimerID string        `json:"split_timer_id,omitempty" bson:"split_timer_id,omitempty"`
+	ResolvedAt   *time.Time    `json:"resolved_at" bson:"resolved_at"`
+	CreatedAt    time.Time     `json:"created_at" bson:"created_at"`
+	ModelVersion int           `json:"ver" bson:"ver"`
+}
+
 // SlackCustomCommand todo
 type SlackCustomCommand struct {
 	ID          int64
@@ -1,7 +1,10 @@
 package models
 
+import "time"
+
 // StartCommandInventory collect everything that StartCommand creates, modifies or touches
-// This report instance will be sent to a UITheme to format a slack reply to the Start Command
+// This report instance will be sent to a UITheme to format a slack reply to the Start Command.
+// Totals of this and other command reports are in seconds, themes round them when formatting
 type StartCommandReport struct {
 	Team                             *Team
 	Project                          *Project
@@ -15,6 +18,61 @@ type StartCommandReport struct {
 	AlreadyStartedTimerTotalForToday int
 	Resumed                          bool
 	UserTotalForToday                int
+	IdlePrompt                       *IdlePrompt
+}
+
+// IdlePrompt - the idle intervals a user is asked about in reply to any command, Command is the Slack command to answer with
+type IdlePrompt struct {
+	Command   string
+	Intervals []*IdleInterval
+}
+
+// IdleCommandReport - the answer to idle intervals given from Slack. Blank Action means the user only asks for them
+type IdleCommandReport struct {
+	Team       *Team
+	Project    *Project
+	TeamUser   *TeamUser
+	Pass       *Pass
+	Action     string
+	Resolved   []*IdleInterval
+	IdlePrompt *IdlePrompt
+}
+
+// PomodoroCommandReport - what the start of a pomodoro did, the timer part is reported the same way as by Start
+type PomodoroCommandReport struct {
+	*StartCommandReport
+	Pomodoro *Pomodoro
+	// Completed is the number of user's pomodoros completed today before this one
+	Completed int
+}
+
+// ReportCommandReport - user's capacity report requested from Slack
+type ReportCommandReport struct {
+	Team       *Team
+	Project    *Project
+	TeamUser   *TeamUser
+	Pass       *Pass
+	PeriodName string // `week`, `last week` or `month`
+	Capacity   *CapacityReport
+}
+
+// FindCommandReport - user's tasks found by a search from Slack
+type FindCommandReport struct {
+	Team     *Team
+	Project  *Project
+	TeamUser *TeamUser
+	Pass     *Pass
+	Text     string
+	Results  []*TaskSearchResult
+}
+
+// OffCommandReport - the time off booked from Slack
+type OffCommandReport struct {
+	Team     *Team
+	Project  *Project
+	TeamUser *TeamUser
+	Pass     *Pass
+	TimeOff  *TimeOff
 }
 
 type StopCommandReport struct {
@@ -25,6 +83,7 @@ type StopCommandReport struct {
 	StoppedTimer             *Timer
 	StoppedTaskTotalForToday int
 	UserTotalForToday        int
+	IdlePrompt               *IdlePrompt
 }
 
 type StatusCommandReport struct {
@@ -35,6 +94,80 @@ type StatusCommandReport struct {
 	Tasks                            []*TaskAggregation
 	AlreadyStartedTimer              *Timer
 	AlreadyStartedTimerTotalForToday int
-	PeriodName                       string // `today`, `yesterday`, `MM-DD-YYYY`
+	PeriodName                       string    // `today`, `yesterday`, `MM-DD-YYYY`
+	Day                              time.Time // the day of the status in user's timezone
 	UserTotalForPeriod               int
+	IdlePrompt                       *IdlePrompt
+}
+
+// ProjectBudgetReport shows how much of the project budget is consumed for the current budget period
+type ProjectBudgetReport struct {
+	ProjectID       string    `json:"project_id"`
+	Kind            string    `json:"kind"`
+	Period          string    `json:"period"`
+	PeriodStart     time.Time `json:"period_start"`
+	Budget          float64   `json:"budget"`
+	ConsumedSeconds int       `json:"consumed_seconds"`
+	// RoundedSeconds is the consumed time rounded by the project's rules, the budget is consumed by it
+	RoundedSeconds  int       `json:"rounded_seconds"`
+	Consumed        float64   `json:"consumed"`
+	Remaining       float64   `json:"remaining"`
+	Percent         float64   `json:"percent"`
+}
+
+// ImportReport - the outcome of importing time entries from a CSV export, or its preview when DryRun is set
+type ImportReport struct {
+	Source        string   `json:"source"`
+	DryRun        bool     `json:"dry_run"`
+	Entries       int      `json:"entries"`
+	Imported      int      `json:"imported"`
+	UnmappedUsers []string `json:"unmapped_users"`
+	NewProjects   []string `json:"new_projects"`
+	Duplicates    []string `json:"duplicates"`
+	// Locked are the entries of the time approved timesheets cover
+	Locked        []string `json:"locked"`
+	Errors        []string `json:"errors"`
+}
+
+// MeetingProposalsReport - the outcome of proposing timers out of an uploaded calendar, or its preview when DryRun is set
+type MeetingProposalsReport struct {
+	DryRun             bool     `json:"dry_run"`
+	Events             int      `json:"events"`
+	Proposed           int      `json:"proposed"`
+	AlreadyProposed    int      `json:"already_proposed"`
+	UnmatchedAttendees []string `json:"unmatched_attendees"`
+}
+
+// CapacityReport compares the time a user has tracked with the hours of the user's work schedule, all times are in seconds.
+// Balance is tracked minus expected, so overtime is positive and undertime is negative
+type CapacityReport struct {
+	TeamUserID      string          `json:"team_user_id"`
+	StartDate       string          `json:"start_date"`
+	EndDate         string          `json:"end_date"`
+	Schedule        *WorkSchedule   `json:"schedule"`
+	ExpectedSeconds int             `json:"expected_seconds"`
+	TrackedSeconds  int             `json:"tracked_seconds"`
+	BalanceSeconds  int             `json:"balance_seconds"`
+	Days            []*CapacityDay  `json:"days"`
+	Weeks           []*CapacityWeek `json:"weeks"`
+}
+
+// CapacityDay - a day of the capacity report, CumulativeBalance sums balances since the start of the report.
+// Off names the holiday or the kind of time off which reduces the expected time of the day
+type CapacityDay struct {
+	Date              string `json:"date"`
+	Off               string `json:"off,omitempty"`
+	ExpectedSeconds   int    `json:"expected_seconds"`
+	TrackedSeconds    int    `json:"tracked_seconds"`
+	BalanceSeconds    int    `json:"balance_seconds"`
+	CumulativeBalance int    `json:"cumulative_balance"`
+}
+
+// CapacityWeek - a week of the capacity report, the first and the last weeks may be partial
+type CapacityWeek struct {
+	WeekStart         string `json:"week_start"`
+	ExpectedSeconds   int    `json:"expected_seconds"`
+	TrackedSeconds    int    `json:"tracked_seconds"`
+	BalanceSeconds    int    `json:"balance_seconds"`
+	CumulativeBalance int    `json:"cumulative_balance"`
 }
@@ -8,13 +8,17 @@ import (
 	"github.com/nlopes/slack"
 	"github.com/cleverua/tuna-timer-api/models"
 	"github.com/cleverua/tuna-timer-api/utils"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
 // DefaultSlackMessageTheme - the basic UI theme for messages that go back from us to Slack users
 type DefaultSlackMessageTheme struct {
 	themeConfig
-	ctx context.Context
+	ctx   context.Context
+	links *FrontendLinks
 }
 
 var defaultThemeConfig = themeConfig{
@@ -31,10 +35,11 @@ var defaultThemeConfig = themeConfig{
 	ErrorColor:             "#D0021B",
 }
 
-func NewDefaultSlackMessageTheme(ctx context.Context) *DefaultSlackMessageTheme {
+func NewDefaultSlackMessageTheme(ctx context.Context, links *FrontendLinks) *DefaultSlackMessageTheme {
 	return &DefaultSlackMessageTheme{
 		themeConfig: defaultThemeConfig,
 		ctx:         ctx,
+		links:       links,
 	}
 }
 
@@ -80,17 +85,18 @@ func (t *DefaultSlackMessageTheme) FormatStatusCommand(data *models.StatusComman
 			displayProjectLink := task.ProjectExternalID != data.Project.ExternalProjectID
 
 			if data.AlreadyStartedTimer == nil || data.AlreadyStartedTimer.TaskHash != task.TaskHash {
+				text := t.linkIssues(task.Name, task.Issues) + t.pomodoros(task.Pomodoros)
 				if displayProjectLink {
-					buffer.WriteString(t.taskWithProject(task.Name, task.Minutes, task.ProjectExternalID, task.ProjectExternalName))
+					buffer.WriteString(t.taskWithProject(text, task.Seconds, task.ProjectExternalID, task.ProjectExternalName))
 				} else {
-					buffer.WriteString(t.task(task.Name, task.Minutes))
+					buffer.WriteString(t.task(text, task.Seconds))
 				}
 			}
 		}
 
 		if buffer.Len() > 0 {
 			statusAttachment.Text = buffer.String()
-			statusAttachment.Footer = fmt.Sprintf("<http://www.google.com?pid=%s|Open in Application>", data.Pass.Token)
+			statusAttachment.Footer = t.appLink(t.links.Day(data.Day, data.Pass.Token), "Open in Application")
 			tpl.Attachments = append(tpl.Attachments, statusAttachment)
 		}
 	}
@@ -106,6 +112,64 @@ func (t *DefaultSlackMessageTheme) FormatStatusCommand(data *models.StatusComman
 		tpl.Text = fmt.Sprintf("You have no tasks completed %s", data.PeriodName)
 	}
 
+	if data.IdlePrompt != nil {
+		tpl.Attachments = append(tpl.Attachments, t.idlePromptAttachment(data.IdlePrompt, data.TeamUser))
+	}
+
+	result, err := json.Marshal(tpl)
+	if err != nil {
+		// todo return { "text": err.String() }
+	}
+
+	return string(result)
+}
+
+func (t *DefaultSlackMessageTheme) FormatReportCommand(data *models.ReportCommandReport) string {
+	capacity := data.Capacity
+
+	period := "this week"
+	if data.PeriodName == "last week" {
+		period = "last week"
+	} else if data.PeriodName == "month" {
+		period = "this month"
+	}
+
+	tpl := SlackThemeTemplate{
+		Text:        fmt.Sprintf("Your time for %s", period),
+		Attachments: []slack.Attachment{},
+	}
+
+	var buffer bytes.Buffer
+	if data.PeriodName == "month" {
+		for _, week := range capacity.Weeks {
+			weekStart, _ := time.Parse("2006-01-02", week.WeekStart)
+			buffer.WriteString(t.capacityLine("Week of "+weekStart.Format("Jan 2"), week.TrackedSeconds, week.ExpectedSeconds, week.BalanceSeconds))
+		}
+	} else {
+		for _, day := range capacity.Days {
+			date, _ := time.Parse("2006-01-02", day.Date)
+			title := date.Format("Mon, Jan 2")
+			if day.Off != "" {
+				title += fmt.Sprintf(" (%s)", day.Off)
+			}
+			buffer.WriteString(t.capacityLine(title, day.TrackedSeconds, day.ExpectedSeconds, day.BalanceSeconds))
+		}
+	}
+
+	sa := t.defaultAttachment()
+	sa.ThumbURL = t.asset(t.StatusCommandThumbURL)
+	sa.Color = t.StatusCommandColor
+	sa.Text = buffer.String()
+	sa.Footer = t.appLink(t.links.Period(capacity.StartDate, capacity.EndDate, data.Pass.Token), "Open in Application")
+	tpl.Attachments = append(tpl.Attachments, sa)
+
+	summary := slack.Attachment{}
+	summary.Text = fmt.Sprintf("*Tracked %s of %s expected, balance %s*",
+		t.duration(capacity.TrackedSeconds), t.duration(capacity.ExpectedSeconds), t.balance(capacity.BalanceSeconds))
+	summary.Color = t.SummaryAttachmentColor
+	summary.MarkdownIn = t.MarkdownEnabledFor
+	tpl.Attachments = append(tpl.Attachments, summary)
+
 	result, err := json.Marshal(tpl)
 	if err != nil {
 		// todo return { "text": err.String() }
@@ -114,6 +178,156 @@ func (t *DefaultSlackMessageTheme) FormatStatusCommand(data *models.StatusComman
 	return string(result)
 }
 
+func (t *DefaultSlackMessageTheme) FormatOffCommand(data *models.OffCommandReport) string {
+	timeOff := data.TimeOff
+
+	kind := strings.ToUpper(timeOff.Kind[:1]) + timeOff.Kind[1:]
+	if timeOff.HalfDay {
+		kind += " (half day)"
+	}
+
+	period := "on " + timeOff.StartDate.Format("Mon, Jan 2")
+	if !timeOff.EndDate.Equal(timeOff.StartDate) {
+		period = fmt.Sprintf("from %s to %s", timeOff.StartDate.Format("Mon, Jan 2"), timeOff.EndDate.Format("Mon, Jan 2"))
+	}
+
+	sa := t.defaultAttachment()
+	sa.ThumbURL = t.asset(t.StatusCommandThumbURL)
+	sa.Color = t.StatusCommandColor
+	sa.Text = fmt.Sprintf("*%s* %s", kind, period)
+	if timeOff.Note != "" {
+		sa.Text += "\n" + timeOff.Note
+	}
+	sa.Footer = t.appLink(t.links.Day(timeOff.StartDate, data.Pass.Token), "Open in Application")
+
+	tpl := SlackThemeTemplate{
+		Text:        "Your time off is booked",
+		Attachments: []slack.Attachment{sa},
+	}
+
+	result, err := json.Marshal(tpl)
+	if err != nil {
+		// todo return { "text": err.String() }
+	}
+
+	return string(result)
+}
+
+func (t *DefaultSlackMessageTheme) FormatFindCommand(data *models.FindCommandReport) string {
+	tpl := SlackThemeTemplate{
+		Text:        fmt.Sprintf("Your tasks matching _%s_", data.Text),
+		Attachments: []slack.Attachment{},
+	}
+
+	if len(data.Results) == 0 {
+		tpl.Text = fmt.Sprintf("Found no tasks matching _%s_", data.Text)
+	} else {
+		tzOffset := time.Duration(data.TeamUser.SlackUserInfo.TZOffset) * time.Second
+
+		var buffer bytes.Buffer
+		for _, result := range data.Results {
+			period := result.LastStartedAt.UTC().Add(tzOffset).Format("Jan 2, 2006")
+			if first := result.FirstStartedAt.UTC().Add(tzOffset).Format("Jan 2, 2006"); first != period {
+				period = first + " - " + period
+			}
+
+			text := fmt.Sprintf("%s  _%s_", t.linkIssues(result.TaskName, result.Issues),
+				t.appLink(t.links.Task(result.TaskHash, data.Pass.Token), period))
+			buffer.WriteString(t.taskWithProject(text, result.Seconds, result.ProjectExternalID, result.ProjectExternalName))
+		}
+
+		sa := t.defaultAttachment()
+		sa.ThumbURL = t.asset(t.StatusCommandThumbURL)
+		sa.Color = t.StatusCommandColor
+		sa.Text = buffer.String()
+		sa.Footer = t.appLink(t.links.Home(data.Pass.Token), "Open in Application")
+		tpl.Attachments = append(tpl.Attachments, sa)
+	}
+
+	result, err := json.Marshal(tpl)
+	if err != nil {
+		// todo return { "text": err.String() }
+	}
+
+	return string(result)
+}
+
+func (t *DefaultSlackMessageTheme) FormatIdleCommand(data *models.IdleCommandReport) string {
+	tpl := SlackThemeTemplate{
+		Attachments: []slack.Attachment{},
+	}
+
+	if len(data.Resolved) > 0 {
+		seconds := 0
+		for _, interval := range data.Resolved {
+			seconds += int(interval.EndedAt.Sub(interval.StartedAt).Seconds())
+		}
+		idleTime := utils.FormatDuration(time.Duration(seconds) * time.Second)
+
+		switch data.Resolved[0].Status {
+		case models.IdleStatusDiscarded:
+			tpl.Text = fmt.Sprintf("Discarded *%s* of idle time", idleTime)
+		case models.IdleStatusSplit:
+			tpl.Text = fmt.Sprintf("Split *%s* of idle time off into separate timers, you can edit them in the application", idleTime)
+		default:
+			tpl.Text = fmt.Sprintf("Kept *%s* of idle time", idleTime)
+		}
+	}
+
+	if data.IdlePrompt != nil {
+		tpl.Attachments = append(tpl.Attachments, t.idlePromptAttachment(data.IdlePrompt, data.TeamUser))
+	} else if len(data.Resolved) == 0 {
+		tpl.Text = "You have no idle time to review"
+	}
+
+	result, err := json.Marshal(tpl)
+	if err != nil {
+		// todo return { "text": err.String() }
+	}
+
+	return string(result)
+}
+
+// idlePromptAttachment - asks the user what to do with the time the timer was running while the user was away
+func (t *DefaultSlackMessageTheme) idlePromptAttachment(prompt *models.IdlePrompt, teamUser *models.TeamUser) slack.Attachment {
+	tzOffset := time.Duration(teamUser.SlackUserInfo.TZOffset) * time.Second
+
+	var buffer bytes.Buffer
+	for i, interval := range prompt.Intervals {
+		buffer.WriteString(fmt.Sprintf("%d.  *%s - %s*  %s  %s\n",
+			i+1,
+			interval.StartedAt.UTC().Add(tzOffset).Format("Mon 15:04"),
+			interval.EndedAt.UTC().Add(tzOffset).Format("15:04"),
+			utils.FormatDuration(interval.EndedAt.Sub(interval.StartedAt)),
+			interval.TaskName))
+	}
+
+	sa := t.defaultAttachment()
+	sa.AuthorName = "You were away while the timer was running:"
+	sa.Color = t.StatusCommandColor
+	buffer.WriteString(fmt.Sprintf("Answer with `%s idle keep`, `%s idle discard` or `%s idle split`, add a number to answer about one interval only",
+		prompt.Command, prompt.Command, prompt.Command))
+	sa.Text = buffer.String()
+	return sa
+}
+
+func (t *DefaultSlackMessageTheme) capacityLine(title string, tracked, expected, balance int) string {
+	return fmt.Sprintf("•  %s  *%s* of %s  _%s_\n", title, t.duration(tracked), t.duration(expected), t.balance(balance))
+}
+
+func (t *DefaultSlackMessageTheme) duration(seconds int) string {
+	return utils.FormatDuration(time.Duration(seconds) * time.Second)
+}
+
+// balance formats overtime with a plus and undertime with a minus sign, a balance rounded to zero has no sign
+func (t *DefaultSlackMessageTheme) balance(seconds int) string {
+	result := t.duration(seconds)
+	if seconds > 0 && result != t.duration(0) {
+		return "+" + result
+	}
+	return result
+}
+
 func (t *DefaultSlackMessageTheme) FormatStopCommand(data *models.StopCommandReport) string {
 	tpl := SlackThemeTemplate{
 		Attachments: []slack.Attachment{},
@@ -126,6 +340,10 @@ func (t *DefaultSlackMessageTheme) FormatStopCommand(data *models.StopCommandRep
 
 	tpl.Attachments = append(tpl.Attachments, t.summaryAttachment("today", data.UserTotalForToday))
 
+	if data.IdlePrompt != nil {
+		tpl.Attachments = append(tpl.Attachments, t.idlePromptAttachment(data.IdlePrompt, data.TeamUser))
+	}
+
 	result, err := json.Marshal(tpl)
 	if err != nil {
 		// todo return { "text": err.String() }
@@ -136,26 +354,43 @@ func (t *DefaultSlackMessageTheme) FormatStopCommand(data *models.StopCommandRep
 
 func (t *DefaultSlackMessageTheme) FormatStartCommand(data *models.StartCommandReport) string {
 	tpl := SlackThemeTemplate{
-		Attachments: []slack.Attachment{},
+		Attachments: t.startCommandAttachments(data),
 	}
 
-	if data.StoppedTimer != nil {
-		sa := t.attachmentForStoppedTask(data.StoppedTimer, data.StoppedTaskTotalForToday, data.Pass.Token)
-		tpl.Attachments = append(tpl.Attachments, sa)
+	tpl.Attachments = append(tpl.Attachments, t.summaryAttachment("today", data.UserTotalForToday))
+
+	if data.IdlePrompt != nil {
+		tpl.Attachments = append(tpl.Attachments, t.idlePromptAttachment(data.IdlePrompt, data.TeamUser))
 	}
 
-	if data.StartedTimer != nil {
-		sa := t.attachmentForNewTask(data.StartedTimer, data.StartedTaskTotalForToday, data.Pass.Token)
-		tpl.Attachments = append(tpl.Attachments, sa)
+	result, err := json.Marshal(tpl)
+	if err != nil {
+		// todo return { "text": err.String() }
 	}
 
-	if data.AlreadyStartedTimer != nil {
-		sa := t.attachmentForNewTask(data.AlreadyStartedTimer, data.AlreadyStartedTimerTotalForToday, data.Pass.Token)
-		tpl.Attachments = append(tpl.Attachments, sa)
+	return string(result)
+}
+
+func (t *DefaultSlackMessageTheme) FormatPomodoroCommand(data *models.PomodoroCommandReport) string {
+	tpl := SlackThemeTemplate{
+		Attachments: t.startCommandAttachments(data.StartCommandReport),
 	}
 
+	pomodoro := data.Pomodoro
+	tzOffset := time.Duration(data.TeamUser.SlackUserInfo.TZOffset) * time.Second
+
+	sa := t.defaultAttachment()
+	sa.Color = t.StartCommandColor
+	sa.Text = fmt.Sprintf(":tomato: Pomodoro #%d until *%s*, I will message you when it is time for a break",
+		data.Completed+1, pomodoro.EndsAt.UTC().Add(tzOffset).Format("15:04"))
+	tpl.Attachments = append(tpl.Attachments, sa)
+
 	tpl.Attachments = append(tpl.Attachments, t.summaryAttachment("today", data.UserTotalForToday))
 
+	if data.IdlePrompt != nil {
+		tpl.Attachments = append(tpl.Attachments, t.idlePromptAttachment(data.IdlePrompt, data.TeamUser))
+	}
+
 	result, err := json.Marshal(tpl)
 	if err != nil {
 		// todo return { "text": err.String() }
@@ -164,28 +399,48 @@ func (t *DefaultSlackMessageTheme) FormatStartCommand(data *models.StartCommandR
 	return string(result)
 }
 
+// startCommandAttachments - attachments for the timers the start of a task has stopped and started
+func (t *DefaultSlackMessageTheme) startCommandAttachments(data *models.StartCommandReport) []slack.Attachment {
+	attachments := []slack.Attachment{}
+
+	if data.StoppedTimer != nil {
+		sa := t.attachmentForStoppedTask(data.StoppedTimer, data.StoppedTaskTotalForToday, data.Pass.Token)
+		attachments = append(attachments, sa)
+	}
+
+	if data.StartedTimer != nil {
+		sa := t.attachmentForNewTask(data.StartedTimer, data.StartedTaskTotalForToday, data.Pass.Token)
+		attachments = append(attachments, sa)
+	}
+
+	if data.AlreadyStartedTimer != nil {
+		sa := t.attachmentForNewTask(data.AlreadyStartedTimer, data.AlreadyStartedTimerTotalForToday, data.Pass.Token)
+		attachments = append(attachments, sa)
+	}
+
+	return attachments
+}
+
 func (t *DefaultSlackMessageTheme) attachmentForNewTask(timer *models.Timer, taskTotalForToday int, token string) slack.Attachment {
 	sa := t.defaultAttachment()
-	sa.Text = t.task(timer.TaskName, taskTotalForToday)
+	sa.Text = t.task(t.linkIssues(timer.TaskName, timer.Issues), taskTotalForToday)
 	sa.ThumbURL = t.asset(t.StartCommandThumbURL)
 	sa.Color = t.StartCommandColor
 	sa.AuthorName = "Started:"
 
-	sa.Footer = fmt.Sprintf(
-		"Project: %s > Task: %s > <http://www.google.com?pid=%s|Edit in Application>", t.channelLinkForTimer(timer), timer.TaskHash, token)
+	sa.Footer = t.timerFooter(timer, t.appLink(t.links.EditTimer(timer, token), "Edit in Application"), token)
 
 	return sa
 }
 
 func (t *DefaultSlackMessageTheme) attachmentForCurrentTask(timer *models.Timer, totalForToday int, token string) slack.Attachment {
 	sa := t.defaultAttachment()
-	sa.Text = t.task(timer.TaskName, totalForToday)
+	sa.Text = t.task(t.linkIssues(timer.TaskName, timer.Issues), totalForToday)
 	sa.ThumbURL = t.asset(t.StartCommandThumbURL)
 	sa.Color = t.StartCommandColor
 	sa.AuthorName = "Current:"
 
-	sa.Footer = fmt.Sprintf(
-		"Project: %s > Task: %s > <http://www.google.com?pid=%s|Open in Application>", t.channelLinkForTimer(timer), timer.TaskHash, token)
+	sa.Footer = t.timerFooter(timer, t.appLink(t.links.Timer(timer, token), "Open in Application"), token)
 
 	sa.Fields = []slack.AttachmentField{}
 	return sa
@@ -195,22 +450,21 @@ func (t *DefaultSlackMessageTheme) attachmentForStoppedTask(timer *models.Timer,
 	sa := t.defaultAttachment()
 	sa.AuthorName = "Completed:"
 
-	sa.Text = t.task(timer.TaskName, totalForToday)
+	sa.Text = t.task(t.linkIssues(timer.TaskName, timer.Issues), totalForToday)
 	sa.ThumbURL = t.asset(t.StopCommandThumbURL)
 	sa.Color = t.StopCommandColor
 
-	sa.Footer = fmt.Sprintf(
-		"Project: %s > Task: %s > <http://www.google.com?pid=%s|Open in Application>", t.channelLinkForTimer(timer), timer.TaskHash, token)
+	sa.Footer = t.timerFooter(timer, t.appLink(t.links.Timer(timer, token), "Open in Application"), token)
 
 	sa.Fields = []slack.AttachmentField{}
 	return sa
 }
 
-func (t *DefaultSlackMessageTheme) summaryAttachment(period string, minutes int) slack.Attachment {
+func (t *DefaultSlackMessageTheme) summaryAttachment(period string, seconds int) slack.Attachment {
 	result := slack.Attachment{}
 	result.Text = fmt.Sprintf("*Your total for %s is %s*",
 		period,
-		utils.FormatDuration(time.Duration(int64(minutes)*int64(time.Minute))))
+		utils.FormatDuration(time.Duration(seconds)*time.Second))
 
 	result.Color = t.SummaryAttachmentColor
 	result.MarkdownIn = t.MarkdownEnabledFor
@@ -227,6 +481,19 @@ func (t *DefaultSlackMessageTheme) asset(assetPath string) string {
 	return utils.GetSelfBaseURLFromContext(t.ctx) + assetPath
 }
 
+// timerFooter shows the project and the task of the timer, the task links to its timers in the application
+func (t *DefaultSlackMessageTheme) timerFooter(timer *models.Timer, appLink, token string) string {
+	return fmt.Sprintf("Project: %s > Task: %s > %s",
+		t.channelLinkForTimer(timer), t.appLink(t.links.Task(timer.TaskHash, token), timer.TaskHash), appLink)
+}
+
+// appLink formats a link to the application, the URL is escaped the way Slack wants it in message text
+func (t *DefaultSlackMessageTheme) appLink(url, text string) string {
+	return fmt.Sprintf("<%s|%s>", slackEscaper.Replace(url), text)
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
 func (t *DefaultSlackMessageTheme) channelLinkForTimer(timer *models.Timer) string {
 	return t.channelLink(timer.ProjectExternalID, timer.ProjectExternalName)
 }
@@ -235,13 +502,49 @@ func (t *DefaultSlackMessageTheme) channelLink(channelID, channelName string) st
 	return fmt.Sprintf("<#%s|%s>", channelID, channelName)
 }
 
-func (t *DefaultSlackMessageTheme) task(text string, minutes int) string {
-	return fmt.Sprintf("•  *%s*  %s\n", utils.FormatDuration(time.Duration(int64(minutes)*int64(time.Minute))), text)
+func (t *DefaultSlackMessageTheme) task(text string, seconds int) string {
+	return fmt.Sprintf("•  *%s*  %s\n", utils.FormatDuration(time.Duration(seconds)*time.Second), text)
 }
 
-func (t *DefaultSlackMessageTheme) taskWithProject(text string, minutes int, projectID, projectName string) string {
+func (t *DefaultSlackMessageTheme) taskWithProject(text string, seconds int, projectID, projectName string) string {
 	return fmt.Sprintf("•  *%s  *%s  %s\n",
-		utils.FormatDuration(time.Duration(int64(minutes)*int64(time.Minute))),
+		utils.FormatDuration(time.Duration(seconds)*time.Second),
 		t.channelLink(projectID, projectName),
 		text)
 }
+
+// pomodoros shows how many pomodoros a task took, nothing if it was not worked in pomodoros
+func (t *DefaultSlackMessageTheme) pomodoros(count int) string {
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  :tomato: %d", count)
+}
+
+// linkIssues turns the issue keys mentioned in the task name into links to the issue tracker
+func (t *DefaultSlackMessageTheme) linkIssues(taskName string, issues []*models.IssueReference) string {
+	if len(issues) == 0 {
+		return taskName
+	}
+
+	urls := map[string]string{}
+	keys := []string{}
+	for _, issue := range issues {
+		urls[issue.Key] = issue.URL
+		keys = append(keys, regexp.QuoteMeta(issue.Key))
+	}
+
+	// longer keys go first so `PROJ-12` is not linked as `PROJ-1` followed by `2`
+	sort.Sort(byLengthDesc(keys))
+
+	re := regexp.MustCompile(strings.Join(keys, "|"))
+	return re.ReplaceAllStringFunc(taskName, func(key string) string {
+		return fmt.Sprintf("<%s|%s>", urls[key], key)
+	})
+}
+
+type byLengthDesc []string
+
+func (s byLengthDesc) Len() int           { return len(s) }
+func (s byLengthDesc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byLengthDesc) Less(i, j int) bool { return len(s[i]) > len(s[j]) }
@@ -0,0 +1,187 @@
+package themes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/nlopes/slack"
+	"github.com/olebedev/config"
+	"gopkg.in/mgo.v2/bson"
+	"gopkg.in/tylerb/is.v1"
+)
+
+// go test ./themes -update rewrites the golden files with the messages the theme formats now
+var update = flag.Bool("update", false, "update the golden files")
+
+var passParameter = regexp.MustCompile(`pid=([0-9a-f-]+)`)
+
+func TestDefaultThemeGolden(t *testing.T) {
+	s := is.New(t)
+
+	theme := testTheme()
+	teamUser := &models.TeamUser{SlackUserInfo: &slack.User{TZOffset: 7200}}
+	pass := &models.Pass{Token: "0b9ba6b4-5d8e-4a35-9d3c-3f0e8b5e1c7a"}
+	finishedAt := utils.PT("2016 Dec 05 15:30:00")
+
+	stopped := &models.Timer{
+		ID:                  bson.ObjectIdHex("5845a1e3c9e77c0001e7a001"),
+		ProjectExternalID:   "C01",
+		ProjectExternalName: "general",
+		TaskName:            "Fix PROJ-12 login",
+		TaskHash:            "a1b2c3",
+		Issues:              []*models.IssueReference{{Key: "PROJ-12", URL: "https://jira.example.com/browse/PROJ-12"}},
+		CreatedAt:           utils.PT("2016 Dec 05 14:00:00"),
+		FinishedAt:          &finishedAt,
+		Seconds:             5400,
+	}
+	started := &models.Timer{
+		ID:                  bson.ObjectIdHex("5845a1e3c9e77c0001e7a002"),
+		ProjectExternalID:   "C01",
+		ProjectExternalName: "general",
+		TaskName:            "Review pull requests",
+		TaskHash:            "d4e5f6",
+		CreatedAt:           utils.PT("2016 Dec 05 15:30:00"),
+	}
+
+	cases := map[string]string{
+		"start": theme.FormatStartCommand(&models.StartCommandReport{
+			TeamUser:                 teamUser,
+			Pass:                     pass,
+			StoppedTimer:             stopped,
+			StoppedTaskTotalForToday: 5400,
+			StartedTimer:             started,
+			UserTotalForToday:        5400,
+		}),
+		"stop": theme.FormatStopCommand(&models.StopCommandReport{
+			TeamUser:                 teamUser,
+			Pass:                     pass,
+			StoppedTimer:             stopped,
+			StoppedTaskTotalForToday: 5400,
+			UserTotalForToday:        5400,
+		}),
+		"status": theme.FormatStatusCommand(&models.StatusCommandReport{
+			Project:  &models.Project{ExternalProjectID: "C01"},
+			TeamUser: teamUser,
+			Pass:     pass,
+			Tasks: []*models.TaskAggregation{
+				{TaskHash: "a1b2c3", ProjectExternalID: "C01", ProjectExternalName: "general", Name: "Fix PROJ-12 login", Seconds: 5400},
+				{TaskHash: "0f0f0f", ProjectExternalID: "C02", ProjectExternalName: "random", Name: "Standup", Seconds: 900},
+			},
+			AlreadyStartedTimer:              started,
+			AlreadyStartedTimerTotalForToday: 600,
+			PeriodName:                       "today",
+			Day:                              utils.PT("2016 Dec 05 00:00:00"),
+			UserTotalForPeriod:               6900,
+		}),
+		"report": theme.FormatReportCommand(&models.ReportCommandReport{
+			TeamUser:   teamUser,
+			Pass:       pass,
+			PeriodName: "week",
+			Capacity: &models.CapacityReport{
+				StartDate:       "2016-12-05",
+				EndDate:         "2016-12-06",
+				ExpectedSeconds: 16 * 60 * 60,
+				TrackedSeconds:  15*60*60 + 15*60 - 40,
+				BalanceSeconds:  -45*60 - 40,
+				Days: []*models.CapacityDay{
+					{Date: "2016-12-05", ExpectedSeconds: 8 * 60 * 60, TrackedSeconds: 8*60*60 + 15*60 - 10, BalanceSeconds: 15*60 - 10},
+					{Date: "2016-12-06", ExpectedSeconds: 8 * 60 * 60, TrackedSeconds: 7*60*60 - 30, BalanceSeconds: -60*60 - 30},
+				},
+			},
+		}),
+		"off": theme.FormatOffCommand(&models.OffCommandReport{
+			TeamUser: teamUser,
+			Pass:     pass,
+			TimeOff: &models.TimeOff{
+				Kind:      models.TimeOffVacation,
+				StartDate: utils.PT("2016 Dec 19 00:00:00"),
+				EndDate:   utils.PT("2016 Dec 23 00:00:00"),
+			},
+		}),
+		"find": theme.FormatFindCommand(&models.FindCommandReport{
+			TeamUser: teamUser,
+			Pass:     pass,
+			Text:     "login",
+			Results: []*models.TaskSearchResult{
+				{
+					TaskHash:            "a1b2c3",
+					TaskName:            "Fix PROJ-12 login",
+					ProjectExternalID:   "C01",
+					ProjectExternalName: "general",
+					Issues:              stopped.Issues,
+					Seconds:             5400,
+					FirstStartedAt:      utils.PT("2016 Dec 01 09:00:00"),
+					LastStartedAt:       utils.PT("2016 Dec 05 14:00:00"),
+				},
+			},
+		}),
+	}
+
+	for name, message := range cases {
+		// every link of a message carries the same single use pass, the frontend ignores it having a session
+		for _, pid := range passParameter.FindAllStringSubmatch(message, -1) {
+			s.Equal(pid[1], pass.Token)
+		}
+
+		var formatted bytes.Buffer
+		s.Nil(json.Indent(&formatted, []byte(message), "", "  "))
+		formatted.WriteString("\n")
+
+		golden := filepath.Join("testdata", name+".golden")
+		if *update {
+			s.Nil(ioutil.WriteFile(golden, formatted.Bytes(), 0644))
+			continue
+		}
+
+		expected, err := ioutil.ReadFile(golden)
+		s.Nil(err)
+		if !bytes.Equal(expected, formatted.Bytes()) {
+			t.Errorf("%s message differs from %s:\n%s", name, golden, formatted.String())
+		}
+	}
+}
+
+func TestFrontendLinks(t *testing.T) {
+	s := is.New(t)
+
+	cfg, _ := config.ParseYaml(`
+origin:
+  url: "https://app.example.com/"
+frontend:
+  routes:
+    timer_edit: "/#/timers/{timer_id}?edit=1"
+`)
+	links := NewFrontendLinks(cfg)
+	s.Equal(links.BaseURL, "https://app.example.com")
+
+	timer := &models.Timer{ID: bson.ObjectIdHex("5845a1e3c9e77c0001e7a001")}
+	s.Equal(links.EditTimer(timer, "pass"), "https://app.example.com/#/timers/5845a1e3c9e77c0001e7a001?edit=1&pid=pass")
+	s.Equal(links.Timer(timer, "pass"), "https://app.example.com/timers/5845a1e3c9e77c0001e7a001?pid=pass")
+	s.Equal(links.Day(time.Date(2016, 12, 5, 0, 0, 0, 0, time.UTC), "pass"), "https://app.example.com/days/2016-12-05?pid=pass")
+
+	cfg, _ = config.ParseYaml(`
+origin:
+  url: "https://app.example.com"
+frontend:
+  url: "https://tuna.example.com/app"
+`)
+	s.Equal(NewFrontendLinks(cfg).Home("a b"), "https://tuna.example.com/app/?pid=a+b")
+}
+
+func testTheme() *DefaultSlackMessageTheme {
+	cfg, _ := config.ParseYaml(`
+frontend:
+  url: "https://tuna.example.com"
+`)
+	ctx := utils.PutSelfBaseURLInContext(context.Background(), "https://api.tuna.example.com")
+	return NewDefaultSlackMessageTheme(ctx, NewFrontendLinks(cfg))
+}
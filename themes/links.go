@@ -0,0 +1,101 @@
+package themes
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/olebedev/config"
+)
+
+// Routes of the frontend the messages link to. `frontend.routes` of the config overrides their templates,
+// the placeholders in braces are replaced with the values of the timer, task or day a message shows
+const (
+	RouteHome      = "home"
+	RouteTimer     = "timer"
+	RouteTimerEdit = "timer_edit"
+	RouteTask      = "task"
+	RouteDay       = "day"
+	RoutePeriod    = "period"
+)
+
+var defaultFrontendRoutes = map[string]string{
+	RouteHome:      "/",
+	RouteTimer:     "/timers/{timer_id}",
+	RouteTimerEdit: "/timers/{timer_id}/edit",
+	RouteTask:      "/tasks/{task_hash}",
+	RouteDay:       "/days/{date}",
+	RoutePeriod:    "/timers?start={start}&end={end}",
+}
+
+// FrontendLinks builds the deep links into the frontend. Every link carries the pass as the `pid` query parameter,
+// the frontend exchanges it for a session and then opens the route. All the links of a message carry the same pass,
+// which logs in once, so the frontend must ignore `pid` when it already has a session
+type FrontendLinks struct {
+	BaseURL string
+	Routes  map[string]string
+}
+
+// NewFrontendLinks reads the base URL from `frontend.url`, the origin the API allows is used if it is not set
+func NewFrontendLinks(cfg *config.Config) *FrontendLinks {
+	links := &FrontendLinks{
+		BaseURL: strings.TrimRight(cfg.UString("frontend.url", cfg.UString("origin.url")), "/"),
+		Routes:  map[string]string{},
+	}
+
+	for name, route := range defaultFrontendRoutes {
+		links.Routes[name] = route
+	}
+	for name, route := range cfg.UMap("frontend.routes") {
+		if value, ok := route.(string); ok && value != "" {
+			links.Routes[name] = value
+		}
+	}
+	return links
+}
+
+// Home links to the start page of the frontend
+func (l *FrontendLinks) Home(token string) string {
+	return l.link(RouteHome, token)
+}
+
+// Timer links to the timer
+func (l *FrontendLinks) Timer(timer *models.Timer, token string) string {
+	return l.link(RouteTimer, token, "timer_id", timer.ID.Hex())
+}
+
+// EditTimer links to the edit form of the timer
+func (l *FrontendLinks) EditTimer(timer *models.Timer, token string) string {
+	return l.link(RouteTimerEdit, token, "timer_id", timer.ID.Hex())
+}
+
+// Task links to the timers of the task
+func (l *FrontendLinks) Task(taskHash, token string) string {
+	return l.link(RouteTask, token, "task_hash", taskHash)
+}
+
+// Day links to the timers of the day, the date is in user's timezone
+func (l *FrontendLinks) Day(date time.Time, token string) string {
+	return l.link(RouteDay, token, "date", date.Format("2006-01-02"))
+}
+
+// Period links to the timers between the dates formatted as 2006-01-02
+func (l *FrontendLinks) Period(start, end, token string) string {
+	return l.link(RoutePeriod, token, "start", start, "end", end)
+}
+
+// link fills the template of the route with the pairs of placeholder names and values and adds the pass
+func (l *FrontendLinks) link(route, token string, params ...string) string {
+	replacements := []string{}
+	for i := 0; i+1 < len(params); i += 2 {
+		replacements = append(replacements, "{"+params[i]+"}", url.QueryEscape(params[i+1]))
+	}
+	path := strings.NewReplacer(replacements...).Replace(l.Routes[route])
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return l.BaseURL + path + separator + "pid=" + url.QueryEscape(token)
+}
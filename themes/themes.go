@@ -10,6 +10,11 @@ type SlackMessageTheme interface {
 	FormatStartCommand(data *models.StartCommandReport) string
 	FormatStopCommand(data *models.StopCommandReport) string
 	FormatStatusCommand(data *models.StatusCommandReport) string
+	FormatPomodoroCommand(data *models.PomodoroCommandReport) string
+	FormatReportCommand(data *models.ReportCommandReport) string
+	FormatOffCommand(data *models.OffCommandReport) string
+	FormatIdleCommand(data *models.IdleCommandReport) string
+	FormatFindCommand(data *models.FindCommandReport) string
 	FormatError(errorMessage string) string
 }
 
@@ -5,13 +5,21 @@ import (
 	"time"
 )
 
-// FormatDuration formats duration to be like HH:MMh regardless of days, months etc
+// FormatDuration formats duration to be like HH:MMh regardless of days, months etc.
+// Durations are stored in seconds, they are rounded to the nearest minute here, negative ones away from zero
 func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	d = (d + 30*time.Second) / time.Minute * time.Minute
 	if d.Minutes() == 0 {
 		return "0:00"
 	}
 
 	minutes := int(d.Minutes()) % 60
 	hours := int(d.Hours())
-	return fmt.Sprintf("%.1d:%.2d", hours, minutes)
+	return fmt.Sprintf("%s%.1d:%.2d", sign, hours, minutes)
 }
@@ -20,4 +20,22 @@ func TestDurationFormatter(t *testing.T) {
 
 	d = time.Duration(0)
 	s.Equal(FormatDuration(d), "0:00")
+
+	d = time.Duration(29 * time.Second)
+	s.Equal(FormatDuration(d), "0:00")
+
+	d = time.Duration(89 * time.Second)
+	s.Equal(FormatDuration(d), "0:01")
+
+	d = time.Duration(90 * time.Second)
+	s.Equal(FormatDuration(d), "0:02")
+
+	d = time.Duration(-50 * time.Second)
+	s.Equal(FormatDuration(d), "-0:01")
+
+	d = time.Duration(-29 * time.Second)
+	s.Equal(FormatDuration(d), "0:00")
+
+	d = time.Duration(-(2*time.Hour + 5*time.Minute))
+	s.Equal(FormatDuration(d), "-2:05")
 }
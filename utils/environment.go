@@ -20,13 +20,28 @@ const (
 const (
 	PassExpiresInMinutes          = 5
 	ClaimedPassesToPurgeAfterDays = 7
+	// an IP address making MaxLoginFailures failed logins within LoginFailuresWindowMinutes is refused to log in
+	// until the oldest of them is that old
+	MaxLoginFailures           = 10
+	LoginFailuresWindowMinutes = 15
 )
 
 const (
-	MongoCollectionTeams     = "teams"
-	MongoCollectionTimers    = "timers"
-	MongoCollectionTeamUsers = "team_users"
-	MongoCollectionPasses    = "passes"
+	MongoCollectionTeams      = "teams"
+	MongoCollectionTimers     = "timers"
+	MongoCollectionTeamUsers  = "team_users"
+	MongoCollectionPasses     = "passes"
+	MongoCollectionTimesheets = "timesheets"
+	MongoCollectionWebhooks   = "webhooks"
+	MongoCollectionDeliveries = "webhook_deliveries"
+	MongoCollectionMeetings   = "meeting_proposals"
+	MongoCollectionPomodoros  = "pomodoros"
+	MongoCollectionTimeOff    = "time_off"
+	MongoCollectionHolidays   = "holidays"
+	MongoCollectionIdle       = "idle_intervals"
+	MongoCollectionTokens     = "access_tokens"
+	MongoCollectionClaims     = "pass_claims"
+	MongoCollectionFailures   = "login_failures"
 )
 
 const (
@@ -76,6 +91,15 @@ func (env *Environment) MigrateDatabase(session *mgo.Session) error {
 	timers.EnsureIndex(mgo.Index{Key: []string{"finished_at"}})
 	timers.EnsureIndex(mgo.Index{Key: []string{"deleted_at"}})
 	timers.EnsureIndex(mgo.Index{Key: []string{"tz_offset"}})
+	timers.EnsureIndex(mgo.Index{Key: []string{"tags"}})
+	timers.EnsureIndex(mgo.Index{Key: []string{"team_id", "source_id"}, Sparse: true})
+	// the text index of search, a collection may have one only so more fields (like notes) have to join it
+	timers.EnsureIndex(mgo.Index{Key: []string{"$text:task_name"}, Name: "timers_text"})
+	// task suggestions scan the recent timers of the team
+	timers.EnsureIndex(mgo.Index{Key: []string{"team_id", "created_at"}})
+	// pages of user's timers, keyed by the sort field and the ID
+	timers.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "created_at", "_id"}})
+	timers.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "seconds", "_id"}})
 
 	users := session.DB("").C(MongoCollectionTeamUsers)
 	users.Create(&mgo.CollectionInfo{})
@@ -83,6 +107,11 @@ func (env *Environment) MigrateDatabase(session *mgo.Session) error {
 		Unique: true,
 		Key:    []string{"ext_id"},
 	})
+	users.EnsureIndex(mgo.Index{
+		Unique: true,
+		Sparse: true,
+		Key:    []string{"calendar_token"},
+	})
 
 	passes := session.DB("").C(MongoCollectionPasses)
 	passes.Create(&mgo.CollectionInfo{})
@@ -93,6 +122,73 @@ func (env *Environment) MigrateDatabase(session *mgo.Session) error {
 	passes.EnsureIndex(mgo.Index{Key: []string{"team_user_id"}})
 	passes.EnsureIndex(mgo.Index{Key: []string{"expires_at"}})
 
+	claims := session.DB("").C(MongoCollectionClaims)
+	claims.Create(&mgo.CollectionInfo{})
+	claims.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "-claimed_at"}})
+
+	// failures are only counted within the window, Mongo removes the older ones
+	failures := session.DB("").C(MongoCollectionFailures)
+	failures.Create(&mgo.CollectionInfo{})
+	failures.EnsureIndex(mgo.Index{Key: []string{"ip", "created_at"}})
+	failures.EnsureIndex(mgo.Index{
+		Key:         []string{"created_at"},
+		ExpireAfter: LoginFailuresWindowMinutes * time.Minute,
+	})
+
+	timesheets := session.DB("").C(MongoCollectionTimesheets)
+	timesheets.Create(&mgo.CollectionInfo{})
+	timesheets.EnsureIndex(mgo.Index{
+		Unique: true,
+		Key:    []string{"team_user_id", "week_start"},
+	})
+	timesheets.EnsureIndex(mgo.Index{Key: []string{"team_id", "status"}})
+	timesheets.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "status", "starts_at"}})
+
+	webhooks := session.DB("").C(MongoCollectionWebhooks)
+	webhooks.Create(&mgo.CollectionInfo{})
+	webhooks.EnsureIndex(mgo.Index{Key: []string{"team_id"}})
+
+	deliveries := session.DB("").C(MongoCollectionDeliveries)
+	deliveries.Create(&mgo.CollectionInfo{})
+	deliveries.EnsureIndex(mgo.Index{Key: []string{"status", "next_attempt_at"}})
+	deliveries.EnsureIndex(mgo.Index{Key: []string{"webhook_id", "created_at"}})
+
+	meetings := session.DB("").C(MongoCollectionMeetings)
+	meetings.Create(&mgo.CollectionInfo{})
+	meetings.EnsureIndex(mgo.Index{
+		Unique: true,
+		Key:    []string{"team_user_id", "event_uid", "starts_at"},
+	})
+	meetings.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "status", "starts_at"}})
+
+	pomodoros := session.DB("").C(MongoCollectionPomodoros)
+	pomodoros.Create(&mgo.CollectionInfo{})
+	pomodoros.EnsureIndex(mgo.Index{Key: []string{"status", "ends_at"}})
+	pomodoros.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "status", "starts_at"}})
+
+	timeOff := session.DB("").C(MongoCollectionTimeOff)
+	timeOff.Create(&mgo.CollectionInfo{})
+	timeOff.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "start_date", "end_date"}})
+
+	holidays := session.DB("").C(MongoCollectionHolidays)
+	holidays.Create(&mgo.CollectionInfo{})
+	holidays.EnsureIndex(mgo.Index{
+		Unique: true,
+		Key:    []string{"team_id", "date"},
+	})
+
+	idle := session.DB("").C(MongoCollectionIdle)
+	idle.Create(&mgo.CollectionInfo{})
+	idle.EnsureIndex(mgo.Index{Key: []string{"team_user_id", "status", "started_at"}})
+
+	tokens := session.DB("").C(MongoCollectionTokens)
+	tokens.Create(&mgo.CollectionInfo{})
+	tokens.EnsureIndex(mgo.Index{
+		Unique: true,
+		Key:    []string{"token_hash"},
+	})
+	tokens.EnsureIndex(mgo.Index{Key: []string{"team_user_id"}})
+
 	log.Println("Database migrated!")
 	return nil
 }
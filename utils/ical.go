@@ -0,0 +1,356 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	icalDateLayout = "20060102T150405"
+	icalDayLayout  = "20060102"
+	icalLineLimit  = 75
+)
+
+// ICalEvent is a VEVENT of an iCalendar feed, Start and End are moments in UTC.
+// Floating events of parsed calendars have wall clock Start and End, their timezone is up to the reader.
+// All-day events are floating too, they start at the midnight of their first day and end at the midnight after the last one
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Attendees   []string
+	Start       time.Time
+	End         time.Time
+	Tentative   bool
+	Floating    bool
+	AllDay      bool
+}
+
+// ICalWriter writes iCalendar (RFC 5545) feeds. Times are written in a fixed-offset timezone
+// so calendar apps show the events the way the owner of the feed sees them
+type ICalWriter struct {
+	Name     string
+	TZOffset int // in seconds, as Slack reports it
+}
+
+// Write writes the calendar with the events
+func (c *ICalWriter) Write(w io.Writer, events []*ICalEvent, now time.Time) error {
+	var buffer bytes.Buffer
+	tzID := c.tzID()
+
+	writeICalLine(&buffer, "BEGIN:VCALENDAR")
+	writeICalLine(&buffer, "VERSION:2.0")
+	writeICalLine(&buffer, "PRODID:-//Tuna Timer//Tracked time//EN")
+	writeICalLine(&buffer, "CALSCALE:GREGORIAN")
+	writeICalLine(&buffer, "METHOD:PUBLISH")
+	writeICalLine(&buffer, "X-WR-CALNAME:"+escapeICalText(c.Name))
+	writeICalLine(&buffer, "X-WR-TIMEZONE:"+tzID)
+
+	writeICalLine(&buffer, "BEGIN:VTIMEZONE")
+	writeICalLine(&buffer, "TZID:"+tzID)
+	writeICalLine(&buffer, "BEGIN:STANDARD")
+	writeICalLine(&buffer, "DTSTART:19700101T000000")
+	writeICalLine(&buffer, "TZOFFSETFROM:"+c.offset())
+	writeICalLine(&buffer, "TZOFFSETTO:"+c.offset())
+	writeICalLine(&buffer, "TZNAME:"+tzID)
+	writeICalLine(&buffer, "END:STANDARD")
+	writeICalLine(&buffer, "END:VTIMEZONE")
+
+	for _, event := range events {
+		writeICalLine(&buffer, "BEGIN:VEVENT")
+		writeICalLine(&buffer, "UID:"+event.UID)
+		writeICalLine(&buffer, "DTSTAMP:"+now.UTC().Format(icalDateLayout)+"Z")
+		writeICalLine(&buffer, fmt.Sprintf("DTSTART;TZID=%s:%s", tzID, c.local(event.Start)))
+		writeICalLine(&buffer, fmt.Sprintf("DTEND;TZID=%s:%s", tzID, c.local(event.End)))
+		writeICalLine(&buffer, "SUMMARY:"+escapeICalText(event.Summary))
+		if event.Description != "" {
+			writeICalLine(&buffer, "DESCRIPTION:"+escapeICalText(event.Description))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeICalText(category)
+			}
+			writeICalLine(&buffer, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		if event.Tentative {
+			writeICalLine(&buffer, "STATUS:TENTATIVE")
+		} else {
+			writeICalLine(&buffer, "STATUS:CONFIRMED")
+		}
+		writeICalLine(&buffer, "TRANSP:OPAQUE")
+		writeICalLine(&buffer, "END:VEVENT")
+	}
+
+	writeICalLine(&buffer, "END:VCALENDAR")
+
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+func (c *ICalWriter) local(moment time.Time) string {
+	return moment.UTC().Add(time.Duration(c.TZOffset) * time.Second).Format(icalDateLayout)
+}
+
+// offset formats the timezone offset as +HHMM
+func (c *ICalWriter) offset() string {
+	sign := "+"
+	offset := c.TZOffset
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// tzID names the timezone like UTC+0200. The name goes unquoted into TZID parameters,
+// where a colon would end the parameter, so it has none
+func (c *ICalWriter) tzID() string {
+	return "UTC" + c.offset()
+}
+
+// escapeICalText escapes TEXT values as RFC 5545 section 3.3.11 requires
+func escapeICalText(text string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	).Replace(text)
+}
+
+// writeICalLine folds content lines longer than 75 octets without breaking multi-byte characters
+func writeICalLine(buffer *bytes.Buffer, line string) {
+	limit := icalLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isUTF8Start(line[cut]) {
+			cut--
+		}
+		buffer.WriteString(line[:cut])
+		buffer.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of continuation lines counts too
+		limit = icalLineLimit - 1
+	}
+	buffer.WriteString(line)
+	buffer.WriteString("\r\n")
+}
+
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// ParseICalendar reads the events of an iCalendar file. Events without start are skipped,
+// recurring events only give their first occurrence. Unknown TZIDs are treated as floating times
+func ParseICalendar(r io.Reader) ([]*ICalEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []*ICalEvent{}
+	var event *ICalEvent
+	var duration time.Duration
+
+	for _, line := range lines {
+		name, params, value := splitICalLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			event = &ICalEvent{Attendees: []string{}}
+			duration = 0
+		case event == nil:
+			continue
+		case name == "END" && value == "VEVENT":
+			if !event.Start.IsZero() {
+				if event.End.IsZero() {
+					// an all-day event without an end lasts for the day
+					if event.AllDay && duration == 0 {
+						duration = 24 * time.Hour
+					}
+					event.End = event.Start.Add(duration)
+				}
+				events = append(events, event)
+			}
+			event = nil
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = unescapeICalText(value)
+		case name == "DESCRIPTION":
+			event.Description = unescapeICalText(value)
+		case name == "ATTENDEE" || name == "ORGANIZER":
+			if email := icalEmail(value); email != "" {
+				event.Attendees = append(event.Attendees, email)
+			}
+		case name == "DTSTART":
+			if isICalDay(params, value) {
+				event.AllDay, event.Floating = true, true
+				if event.Start, err = time.Parse(icalDayLayout, value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if event.Start, event.Floating, err = parseICalTime(value, params["TZID"]); err != nil {
+				return nil, err
+			}
+		case name == "DTEND":
+			if isICalDay(params, value) {
+				if event.End, err = time.Parse(icalDayLayout, value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if event.End, _, err = parseICalTime(value, params["TZID"]); err != nil {
+				return nil, err
+			}
+		case name == "DURATION":
+			if duration, err = parseICalDuration(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return events, nil
+}
+
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICalLine splits `NAME;PARAM=VALUE:value` content line
+func splitICalLine(line string) (string, map[string]string, string) {
+	params := map[string]string{}
+
+	// parameter values may be quoted and contain colons
+	colon, quoted := -1, false
+	for i, char := range line {
+		if char == '"' {
+			quoted = !quoted
+		} else if char == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), params, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	for _, param := range parts[1:] {
+		if pair := strings.SplitN(param, "=", 2); len(pair) == 2 {
+			params[strings.ToUpper(pair[0])] = strings.Trim(pair[1], "\"")
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// isICalDay tells whether the value is a DATE rather than a DATE-TIME
+func isICalDay(params map[string]string, value string) bool {
+	return params["VALUE"] == "DATE" || len(value) == len(icalDayLayout)
+}
+
+func parseICalTime(value, tzID string) (time.Time, bool, error) {
+	if strings.HasSuffix(value, "Z") {
+		moment, err := time.Parse(icalDateLayout, strings.TrimSuffix(value, "Z"))
+		return moment, false, err
+	}
+
+	wallClock, err := time.Parse(icalDateLayout, value)
+	if err != nil {
+		return wallClock, false, err
+	}
+
+	if tzID != "" {
+		location, err := time.LoadLocation(tzID)
+		if err != nil {
+			location, err = fixedICalZone(tzID)
+		}
+		if err == nil {
+			moment := time.Date(wallClock.Year(), wallClock.Month(), wallClock.Day(),
+				wallClock.Hour(), wallClock.Minute(), wallClock.Second(), 0, location)
+			return moment.UTC(), false, nil
+		}
+	}
+	return wallClock, true, nil
+}
+
+// fixedICalZone reads the fixed-offset timezones of the feeds ICalWriter writes, like UTC+0200
+func fixedICalZone(tzID string) (*time.Location, error) {
+	offset, err := time.Parse("-0700", strings.TrimPrefix(tzID, "UTC"))
+	if err != nil || !strings.HasPrefix(tzID, "UTC") {
+		return nil, fmt.Errorf("unknown timezone `%s`", tzID)
+	}
+	_, seconds := offset.Zone()
+	return time.FixedZone(tzID, seconds), nil
+}
+
+// parseICalDuration supports the time durations of events like PT1H30M and P1D
+func parseICalDuration(value string) (time.Duration, error) {
+	if !strings.HasPrefix(value, "P") && !strings.HasPrefix(value, "+P") {
+		return 0, fmt.Errorf("wrong duration `%s`", value)
+	}
+
+	var result time.Duration
+	number := 0
+	for _, char := range strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P") {
+		switch {
+		case char >= '0' && char <= '9':
+			number = number*10 + int(char-'0')
+			continue
+		case char == 'W':
+			result += time.Duration(number) * 7 * 24 * time.Hour
+		case char == 'D':
+			result += time.Duration(number) * 24 * time.Hour
+		case char == 'H':
+			result += time.Duration(number) * time.Hour
+		case char == 'M':
+			result += time.Duration(number) * time.Minute
+		case char == 'S':
+			result += time.Duration(number) * time.Second
+		case char == 'T':
+		default:
+			return 0, fmt.Errorf("wrong duration `%s`", value)
+		}
+		number = 0
+	}
+	return result, nil
+}
+
+func icalEmail(value string) string {
+	if strings.HasPrefix(strings.ToLower(value), "mailto:") {
+		return strings.ToLower(strings.TrimSpace(value[len("mailto:"):]))
+	}
+	return ""
+}
+
+func unescapeICalText(text string) string {
+	return strings.NewReplacer(
+		"\\\\", "\\",
+		"\\;", ";",
+		"\\,", ",",
+		"\\n", "\n",
+		"\\N", "\n",
+	).Replace(text)
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/tylerb/is.v1"
+)
+
+func TestICalWriter(t *testing.T) {
+	s := is.New(t)
+
+	writer := &ICalWriter{Name: "Tracked time", TZOffset: -16200}
+	events := []*ICalEvent{
+		{
+			UID:         "timer-1@tuna-timer",
+			Summary:     "general: fix login, again; really",
+			Description: "line one\nline two",
+			Categories:  []string{"general"},
+			Start:       PT("2016 Dec 05 14:00:00"),
+			End:         PT("2016 Dec 05 15:30:00"),
+		},
+		{
+			UID:       "timer-2@tuna-timer",
+			Summary:   "general: running",
+			Start:     PT("2016 Dec 05 16:00:00"),
+			End:       PT("2016 Dec 05 16:20:00"),
+			Tentative: true,
+		},
+	}
+
+	var buffer bytes.Buffer
+	s.Nil(writer.Write(&buffer, events, PT("2016 Dec 05 16:20:00")))
+	feed := buffer.String()
+
+	s.True(strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\n"))
+	s.True(strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	s.True(strings.Contains(feed, "TZID:UTC-0430\r\n"))
+	s.True(strings.Contains(feed, "TZOFFSETTO:-0430\r\n"))
+	s.True(strings.Contains(feed, "DTSTART;TZID=UTC-0430:20161205T093000\r\n"))
+	s.True(strings.Contains(feed, "DTEND;TZID=UTC-0430:20161205T110000\r\n"))
+	s.True(strings.Contains(feed, "SUMMARY:general: fix login\\, again\\; really\r\n"))
+	s.True(strings.Contains(feed, "DESCRIPTION:line one\\nline two\r\n"))
+	s.True(strings.Contains(feed, "STATUS:TENTATIVE\r\n"))
+	s.Equal(strings.Count(feed, "BEGIN:VEVENT"), 2)
+}
+
+func TestICalWriterRoundTrip(t *testing.T) {
+	s := is.New(t)
+
+	for _, offset := range []int{-16200, 0, 7200} {
+		writer := &ICalWriter{Name: "Tracked time", TZOffset: offset}
+		written := &ICalEvent{
+			UID:     "timer-1@tuna-timer",
+			Summary: "general: fix login, again; really",
+			Start:   PT("2016 Dec 05 14:00:00"),
+			End:     PT("2016 Dec 05 15:30:00"),
+		}
+
+		var buffer bytes.Buffer
+		s.Nil(writer.Write(&buffer, []*ICalEvent{written}, PT("2016 Dec 05 16:20:00")))
+
+		events, err := ParseICalendar(&buffer)
+		s.Nil(err)
+		s.Len(events, 1)
+		s.Equal(events[0].UID, written.UID)
+		s.Equal(events[0].Summary, written.Summary)
+		s.False(events[0].Floating)
+		s.True(events[0].Start.Equal(written.Start))
+		s.True(events[0].End.Equal(written.End))
+	}
+}
+
+func TestICalLineFolding(t *testing.T) {
+	s := is.New(t)
+
+	var buffer bytes.Buffer
+	writeICalLine(&buffer, "SUMMARY:"+strings.Repeat("ж", 80))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\r\n"), "\r\n") {
+		s.True(len(line) <= 75)
+	}
+	s.Equal(strings.Replace(buffer.String(), "\r\n ", "", -1), "SUMMARY:"+strings.Repeat("ж", 80)+"\r\n")
+}
+
+func TestParseICalendar(t *testing.T) {
+	s := is.New(t)
+
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup@example.com\r\n" +
+		"SUMMARY:Daily standup\\, team\r\n" +
+		"DTSTART:20161205T080000Z\r\n" +
+		"DTEND:20161205T081500Z\r\n" +
+		"ORGANIZER;CN=\"Smith: Alice\":mailto:Alice@example.com\r\n" +
+		"ATTENDEE;CN=Bob;PARTSTAT=ACCEPTED:mailto:bob@exam\r\n" +
+		" ple.com\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:planning@example.com\r\n" +
+		"SUMMARY:Planning\r\n" +
+		"DTSTART;TZID=Europe/Kiev:20160705T100000\r\n" +
+		"DURATION:PT1H30M\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:retro@example.com\r\n" +
+		"SUMMARY:Retro\r\n" +
+		"DTSTART:20161206T160000\r\n" +
+		"DTEND:20161206T170000\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:holiday@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20161225\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:vacation@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20161226\r\n" +
+		"DTEND;VALUE=DATE:20161229\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseICalendar(strings.NewReader(calendar))
+	s.Nil(err)
+	s.Len(events, 5)
+
+	s.Equal(events[0].UID, "standup@example.com")
+	s.Equal(events[0].Summary, "Daily standup, team")
+	s.Equal(events[0].Start, PT("2016 Dec 05 08:00:00"))
+	s.Equal(events[0].End, PT("2016 Dec 05 08:15:00"))
+	s.Equal(events[0].Attendees, []string{"alice@example.com", "bob@example.com"})
+	s.False(events[0].Floating)
+
+	s.Equal(events[1].Start, PT("2016 Jul 05 07:00:00"))
+	s.Equal(events[1].End, PT("2016 Jul 05 08:30:00"))
+
+	s.True(events[2].Floating)
+	s.False(events[2].AllDay)
+	s.Equal(events[2].Start, PT("2016 Dec 06 16:00:00"))
+
+	s.True(events[3].AllDay)
+	s.True(events[3].Floating)
+	s.Equal(events[3].Start, PT("2016 Dec 25 00:00:00"))
+	s.Equal(events[3].End, PT("2016 Dec 26 00:00:00"))
+
+	s.Equal(events[4].Start, PT("2016 Dec 26 00:00:00"))
+	s.Equal(events[4].End, PT("2016 Dec 29 00:00:00"))
+}
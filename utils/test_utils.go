@@ -14,6 +14,17 @@ func TruncateTables(session *mgo.Session) {
 		MongoCollectionTimers,
 		MongoCollectionTeamUsers,
 		MongoCollectionPasses,
+		MongoCollectionTimesheets,
+		MongoCollectionWebhooks,
+		MongoCollectionDeliveries,
+		MongoCollectionMeetings,
+		MongoCollectionPomodoros,
+		MongoCollectionTimeOff,
+		MongoCollectionHolidays,
+		MongoCollectionIdle,
+		MongoCollectionTokens,
+		MongoCollectionClaims,
+		MongoCollectionFailures,
 	}
 
 	for _, tableName := range tablesToTruncate {
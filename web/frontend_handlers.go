@@ -1,6 +1,7 @@
 package web
 
 import (
+	"net"
 	"net/http"
 	"github.com/cleverua/tuna-timer-api/data"
 	"encoding/json"
@@ -11,13 +12,24 @@ import (
 	"time"
 	"gopkg.in/mgo.v2/bson"
 	"github.com/gorilla/mux"
+	"strings"
+	"fmt"
+	"strconv"
+	"log"
+	"github.com/dgrijalva/jwt-go"
 )
 
 const (
 	statusOK = "200"
 	statusBadRequest = "400"
+	statusForbidden = "403"
+	statusTooManyRequests = "429"
 	statusInternalServerError = "500"
 	userLoginMessage = "please login from slack application"
+	userForbiddenMessage = "you are not allowed to do this"
+	maxImportFileSize = 10 << 20
+	// eventsKeepAlive - how often an idle event stream sends a comment so that proxies keep it open
+	eventsKeepAlive = 25 * time.Second
 )
 
 // Handlers is a collection of net/http handlers to serve the API
@@ -25,10 +37,14 @@ type FrontendHandlers struct {
 	env                   *utils.Environment
 	mongoSession          *mgo.Session
 	status                map[string]string
+	events                data.EventBus
+	jwt                   *JWTSettings
+	trustedProxies        trustedProxies
+	eventsKeepAlive       time.Duration
 }
 
-// NewHandlers constructs a FrontendHandler collection
-func NewFrontendHandlers(env *utils.Environment, mongoSession *mgo.Session) *FrontendHandlers {
+// NewHandlers constructs a FrontendHandler collection, the tokens are issued with the settings the secure routes verify them by
+func NewFrontendHandlers(env *utils.Environment, mongoSession *mgo.Session, jwtSettings *JWTSettings) *FrontendHandlers {
 	return &FrontendHandlers{
 		env:          env,
 		mongoSession: mongoSession,
@@ -36,6 +52,10 @@ func NewFrontendHandlers(env *utils.Environment, mongoSession *mgo.Session) *Fro
 			"env":     env.Name,
 			"version": env.AppVersion,
 		},
+		events: data.DefaultEventBus,
+		jwt:    jwtSettings,
+		trustedProxies: newTrustedProxies(env.Config.UList("trusted_proxies")),
+		eventsKeepAlive: eventsKeepAlive,
 	}
 }
 
@@ -57,6 +77,67 @@ func writeError(rs *ResponseStatus, code, dm, um string ) {
 	rs.Status = code
 }
 
+// clientIP returns the address of the client. X-Forwarded-For is only believed for the requests coming from
+// the trusted proxies and then the right-most address not of a trusted proxy is the client, as the addresses
+// to the left of it can be anything the client sent
+func clientIP(r *http.Request, proxies trustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !proxies.contains(host) {
+		return host
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop != "" && !proxies.contains(hop) {
+			return hop
+		}
+	}
+	return host
+}
+
+// trustedProxies - the addresses and networks of the proxies in front of the API, `trusted_proxies` of the config
+type trustedProxies []*net.IPNet
+
+func newTrustedProxies(values []interface{}) trustedProxies {
+	proxies := trustedProxies{}
+	for _, value := range values {
+		entry, _ := value.(string)
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			// not trusting a proxy only makes its clients share its address
+			log.Printf("Ignoring trusted proxy `%v`: %s", value, err)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
+
+func (p trustedProxies) contains(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // encodeResponse encodes response body to JSON
 func encodeResponse(w http.ResponseWriter, resp interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -76,40 +157,153 @@ func (h *FrontendHandlers) Authenticate(w http.ResponseWriter, r *http.Request)
 
 	pid := requestData["pid"] // TODO: sanitize pid
 
+	// the pass is claimed here, so it logs in once
 	passService := data.NewPassService(session)
-	pass, err := passService.FindPassByToken(pid)
+	pass, err := passService.ClaimPass(pid, r.UserAgent(), clientIP(r, h.trustedProxies), time.Now())
 
-	if err == nil && pass == nil {
+	if err == data.ErrTooManyLoginAttempts {
+		writeError(resp.ResponseStatus, statusTooManyRequests, err.Error(), err.Error())
+	} else if err == nil && pass == nil {
 		writeError(resp.ResponseStatus, statusBadRequest, "", userLoginMessage)
 	} else if err != nil {
 		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
 	} else {
-		jwtToken, err := NewUserToken(pass.TeamUserID, session)
+		tokens, err := NewUserTokens(h.jwt, pass.TeamUserID, r.UserAgent(), session, time.Now())
 		if err != nil {
 			writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+			return
 		}
-		resp.ResponseData.Token = jwtToken
+		resp.ResponseData = *tokens
+	}
+}
+
+// RefreshSession exchanges `{"refresh_token": "..."}` for a new pair of tokens
+func (h *FrontendHandlers) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	resp := NewJWTResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := map[string]string{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	tokens, err := RefreshUserTokens(h.jwt, requestData["refresh_token"], r.UserAgent(), session, time.Now())
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), userLoginMessage)
+		return
+	}
+	resp.ResponseData = *tokens
+}
+
+// SessionClaims returns the latest logins of the user, when and where from the passes were used
+func (h *FrontendHandlers) SessionClaims(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewPassClaimsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	passService := data.NewPassService(session)
+	claims, err := passService.Claims(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = claims
+}
+
+// Logout revokes all the tokens of the user, on every device
+func (h *FrontendHandlers) Logout(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewResponseBody(h.status)
+	resp.ResponseStatus.UserMessage = "successfully logged out"
+	defer encodeResponse(w, resp)
+
+	userService := data.NewUserService(session)
+	if err := userService.RevokeTokens(user); err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+	}
+}
+
+// RevokeUserSessions logs a team member out everywhere, e.g. when they leave the team
+func (h *FrontendHandlers) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTeamUserResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	userService := data.NewUserService(session)
+	teamUser, err := userService.RevokeUserTokens(user, mux.Vars(r)["id"])
+	if err == data.ErrForbidden {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	} else if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
 	}
+	resp.ResponseData = teamUser
 }
 
+// TimersData returns a page of user's timers. Any of `startDate` and `endDate` may be omitted to leave the range open,
+// `projects`, `task_hash`, `tag`, `q` and `state` (running or finished) filter the timers,
+// `sort` is one of created_at, -created_at, seconds or -seconds and `cursor` asks for the next page
 func(h *FrontendHandlers) TimersData(w http.ResponseWriter, r *http.Request) {
 	session := h.mongoSession.Clone()
 	defer session.Close()
 	user := context.Get(r, "user").(*models.TeamUser)
-	resp := NewTimersResponse(h.status)
+	resp := NewTimersPageResponse(h.status)
 	defer encodeResponse(w, resp)
 
-	//Get Query params (start and end date)
-	startDate := r.FormValue("startDate")
-	endDate := r.FormValue("endDate")
+	query := r.URL.Query()
+	filter := &data.TimersFilter{
+		ProjectIDs: splitQueryList(query.Get("projects")),
+		TaskHash:   query.Get("task_hash"),
+		Tag:        query.Get("tag"),
+		Text:       query.Get("q"),
+		State:      query.Get("state"),
+		Sort:       query.Get("sort"),
+		Cursor:     query.Get("cursor"),
+	}
+
+	var err error
+	tzOffset := user.SlackUserInfo.TZOffset
+	if startDate := query.Get("startDate"); startDate != "" {
+		if filter.StartDate, err = data.ParseDay(startDate, tzOffset); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
+	if endDate := query.Get("endDate"); endDate != "" {
+		if filter.EndDate, err = data.ParseDay(endDate, tzOffset); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+		filter.EndDate = filter.EndDate.Add(24*time.Hour - time.Second)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
 
 	timersService := data.NewTimerService(session)
-	tasks, err := timersService.GetUserTimersByRange(startDate, endDate, user)
+	timers, pagination, err := timersService.UserTimers(user, filter)
 	if err != nil {
-		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
 	}
 
-	resp.ResponseData = tasks
+	resp.ResponseData = timers
+	resp.Pagination = pagination
 }
 
 func(h *FrontendHandlers) ProjectsData(w http.ResponseWriter, r *http.Request) {
@@ -252,3 +446,1358 @@ func (h *FrontendHandlers) MonthStatistics(w http.ResponseWriter, r *http.Reques
 	}
 	resp.ResponseData = monthStatistic
 }
+
+// Statistics aggregates user's time of the `period` (week, month, quarter or year) the `date` belongs to
+func (h *FrontendHandlers) Statistics(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewUserStatisticsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+
+	timerService := data.NewTimerService(session)
+	statistics, err := timerService.UserStatistics(user, query.Get("period"), query.Get("date"))
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = statistics
+}
+
+// Heatmap returns user's time by days of the year up to the `date`, today by default
+func (h *FrontendHandlers) Heatmap(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewHeatmapResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().UTC().Add(time.Duration(user.SlackUserInfo.TZOffset) * time.Second).Format("2006-1-2")
+	}
+
+	timerService := data.NewTimerService(session)
+	heatmap, err := timerService.UserHeatmap(user, date)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = heatmap
+}
+
+func (h *FrontendHandlers) ProjectBudget(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewProjectBudgetResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	projectID := mux.Vars(r)["id"]
+	if err := data.AuthorizeForProject(user, data.PermissionViewTeamReports, projectID); err != nil {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	budgetService := data.NewBudgetService(session)
+	report, err := budgetService.ProjectBudgetReport(team, projectID, time.Now())
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = report
+}
+
+func (h *FrontendHandlers) UpdateProjectBudget(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewProjectResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	// A request without budget data removes the budget
+	budget := &models.ProjectBudget{}
+	if ok := jsonDecode(&budget, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	budgetService := data.NewBudgetService(session)
+	project, err := budgetService.UpdateProjectBudget(user, team, mux.Vars(r)["id"], budget)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = project
+}
+
+func (h *FrontendHandlers) Timesheet(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimesheetResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	timesheetService := data.NewTimesheetService(session)
+	timesheet, err := timesheetService.GetTimesheet(user, r.URL.Query().Get("week"))
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timesheet
+}
+
+func (h *FrontendHandlers) SubmitTimesheet(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimesheetResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := map[string]string{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	timesheetService := data.NewTimesheetService(session)
+	timesheet, err := timesheetService.Submit(user, requestData["week"])
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timesheet
+}
+
+func (h *FrontendHandlers) PendingTimesheets(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimesheetsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	timesheetService := data.NewTimesheetService(session)
+	timesheets, err := timesheetService.PendingTimesheets(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timesheets
+}
+
+func (h *FrontendHandlers) ApproveTimesheet(w http.ResponseWriter, r *http.Request) {
+	h.reviewTimesheet(w, r, true)
+}
+
+func (h *FrontendHandlers) RejectTimesheet(w http.ResponseWriter, r *http.Request) {
+	h.reviewTimesheet(w, r, false)
+}
+
+func (h *FrontendHandlers) reviewTimesheet(w http.ResponseWriter, r *http.Request, approve bool) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimesheetResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := map[string]string{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	timesheetService := data.NewTimesheetService(session)
+	timesheetID := mux.Vars(r)["id"]
+
+	var timesheet *models.Timesheet
+	var err error
+	if approve {
+		timesheet, err = timesheetService.Approve(user, timesheetID, requestData["comment"])
+	} else {
+		timesheet, err = timesheetService.Reject(user, timesheetID, requestData["comment"])
+	}
+
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timesheet
+}
+
+func (h *FrontendHandlers) TeamUsers(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTeamUsersResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	userService := data.NewUserService(session)
+	users, err := userService.TeamUsers(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	}
+	resp.ResponseData = users
+}
+
+func (h *FrontendHandlers) AssignRole(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTeamUserResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := &models.TeamUser{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	userService := data.NewUserService(session)
+	teamUser, err := userService.AssignRole(user, mux.Vars(r)["id"], requestData.Role, requestData.ManagedProjectIDs)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = teamUser
+}
+
+func (h *FrontendHandlers) TeamReport(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTeamReportResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+	startDate, endDate, err := data.ParseDateRange(query.Get("start_date"), query.Get("end_date"), user.SlackUserInfo.TZOffset)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+
+	filter := &data.TeamReportFilter{
+		UserIDs:    splitQueryList(query.Get("users")),
+		ProjectIDs: splitQueryList(query.Get("projects")),
+		Tags:       splitQueryList(query.Get("tags")),
+		GroupBy:    splitQueryList(query.Get("group_by")),
+		StartDate:  startDate,
+		EndDate:    endDate,
+		TZOffset:   user.SlackUserInfo.TZOffset,
+	}
+
+	timerService := data.NewTimerService(session)
+	report, err := timerService.TeamReport(user, filter)
+	if err == data.ErrForbidden {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	} else if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = report
+}
+
+func (h *FrontendHandlers) TimesheetGrid(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimesheetGridResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	timerService := data.NewTimerService(session)
+	grid, err := timerService.TimesheetGrid(user, r.URL.Query().Get("week"))
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = grid
+}
+
+func (h *FrontendHandlers) SaveTimesheetGrid(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimesheetGridResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		Week  string                      `json:"week"`
+		Cells []*models.TimesheetGridCell `json:"cells"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	timerService := data.NewTimerService(session)
+	unapplied, saveErr := timerService.SaveTimesheetGrid(user, team, requestData.Week, requestData.Cells)
+	resp.Unapplied = unapplied
+
+	grid, err := timerService.TimesheetGrid(user, requestData.Week)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = grid
+
+	if saveErr != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, saveErr.Error(), "")
+	}
+}
+
+// ImportTimers imports a CSV export of another time tracker uploaded as the `file` multipart field
+func (h *FrontendHandlers) ImportTimers(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewImportReportResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	defer file.Close()
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	importService := data.NewImportService(session)
+	report, err := importService.Import(team, r.FormValue("source"), file, r.FormValue("dry_run") == "true")
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = report
+}
+
+func (h *FrontendHandlers) Webhooks(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWebhooksResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	webhookService := data.NewWebhookService(session)
+	webhooks, err := webhookService.Webhooks(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = webhooks
+}
+
+func (h *FrontendHandlers) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWebhookResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	webhookService := data.NewWebhookService(session)
+	webhook, err := webhookService.CreateWebhook(user, requestData.URL, requestData.Events)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = webhook
+}
+
+func (h *FrontendHandlers) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewResponseBody(h.status)
+	resp.ResponseStatus.UserMessage = "successfully deleted"
+	defer encodeResponse(w, resp)
+
+	webhookService := data.NewWebhookService(session)
+	if err := webhookService.DeleteWebhook(user, mux.Vars(r)["id"]); err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+	}
+}
+
+func (h *FrontendHandlers) AccessTokens(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewAccessTokensResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	accessTokenService := data.NewAccessTokenService(session)
+	tokens, err := accessTokenService.Tokens(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = tokens
+}
+
+// CreateAccessToken creates a personal access token from `{"name": "...", "scopes": ["timers:read"]}`.
+// The response is the only place the token itself is ever shown
+func (h *FrontendHandlers) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewCreatedAccessTokenResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	accessTokenService := data.NewAccessTokenService(session)
+	token, secret, err := accessTokenService.CreateToken(user, requestData.Name, requestData.Scopes)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = &CreatedAccessToken{AccessToken: token, Token: secret}
+}
+
+func (h *FrontendHandlers) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewAccessTokenResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	accessTokenService := data.NewAccessTokenService(session)
+	token, err := accessTokenService.RevokeToken(user, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = token
+}
+
+func (h *FrontendHandlers) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWebhookDeliveriesResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	webhookService := data.NewWebhookService(session)
+	deliveries, err := webhookService.Deliveries(user, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = deliveries
+}
+
+func (h *FrontendHandlers) IssuePatterns(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewIssuePatternsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = team.IssuePatterns
+}
+
+func (h *FrontendHandlers) UpdateIssuePatterns(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewIssuePatternsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	patterns := []*models.IssuePattern{}
+	if ok := jsonDecode(&patterns, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	team, err = teamService.UpdateIssuePatterns(user, team, patterns)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = team.IssuePatterns
+}
+
+// CalendarFeed returns the URL of user's iCalendar feed turning the feed on if it is off
+func (h *FrontendHandlers) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	h.calendarFeed(w, r, (*data.CalendarService).FeedToken)
+}
+
+// RegenerateCalendarFeed issues a new feed URL, the old one stops working
+func (h *FrontendHandlers) RegenerateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	h.calendarFeed(w, r, (*data.CalendarService).RegenerateFeedToken)
+}
+
+func (h *FrontendHandlers) calendarFeed(w http.ResponseWriter, r *http.Request, token func(*data.CalendarService, *models.TeamUser) (string, error)) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewCalendarFeedResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	feedToken, err := token(data.NewCalendarService(session), user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = &CalendarFeed{
+		URL: fmt.Sprintf("%s/api/v1/calendar/%s.ics", utils.GetSelfURLFromRequest(r), feedToken),
+	}
+}
+
+func (h *FrontendHandlers) RevokeCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewResponseBody(h.status)
+	resp.ResponseStatus.UserMessage = "successfully revoked"
+	defer encodeResponse(w, resp)
+
+	calendarService := data.NewCalendarService(session)
+	if err := calendarService.RevokeFeedToken(user); err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+	}
+}
+
+// splitQueryList splits a comma separated query parameter value skipping blank items
+func splitQueryList(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// UploadMeetings proposes timers for the events of an ICS file uploaded as the `file` multipart field,
+// `dry_run` only reports what would be proposed
+func (h *FrontendHandlers) UploadMeetings(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewMeetingProposalsReportResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	defer file.Close()
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	meetingService := data.NewMeetingService(session)
+	report, err := meetingService.ProposeFromCalendar(user, team, file, time.Now(), r.FormValue("dry_run") == "true")
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = report
+}
+
+func (h *FrontendHandlers) MeetingProposals(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewMeetingProposalsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	meetingService := data.NewMeetingService(session)
+	proposals, err := meetingService.Proposals(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = proposals
+}
+
+// AcceptMeetings turns the proposals into finished timers of the project
+func (h *FrontendHandlers) AcceptMeetings(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimersResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		IDs       []string `json:"ids"`
+		ProjectID string   `json:"project_id"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	meetingService := data.NewMeetingService(session)
+	timers, err := meetingService.Accept(user, team, requestData.IDs, requestData.ProjectID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timers
+}
+
+func (h *FrontendHandlers) DismissMeetings(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewResponseBody(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		IDs []string `json:"ids"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	meetingService := data.NewMeetingService(session)
+	if err := meetingService.Dismiss(user, requestData.IDs); err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseStatus.UserMessage = "successfully dismissed"
+}
+
+func (h *FrontendHandlers) PomodoroSettings(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewPomodoroSettingsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	resp.ResponseData = data.PomodoroSettingsOf(user)
+}
+
+// UpdatePomodoroSettings changes user's pomodoro durations, `null` brings the defaults back
+func (h *FrontendHandlers) UpdatePomodoroSettings(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewPomodoroSettingsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	var settings *models.PomodoroSettings
+	if ok := jsonDecode(&settings, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	pomodoroService := data.NewPomodoroService(session)
+	result, err := pomodoroService.UpdateSettings(user, settings)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = result
+}
+
+// CapacityReport compares tracked time with the work schedule for `start_date`..`end_date`.
+// Reviewers may pass `user_id` to see the report of another team member
+func (h *FrontendHandlers) CapacityReport(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewCapacityReportResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+	reportUser := user
+	if userID := query.Get("user_id"); userID != "" && userID != user.ID.Hex() {
+		var err error
+		if reportUser, err = data.NewUserService(session).FindByID(userID); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	capacityService := data.NewCapacityService(session)
+	report, err := capacityService.Report(user, reportUser, team, query.Get("start_date"), query.Get("end_date"))
+	if err == data.ErrForbidden {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	} else if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = report
+}
+
+// WorkSchedule returns the schedule the user works by
+func (h *FrontendHandlers) WorkSchedule(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWorkScheduleResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = data.WorkScheduleOf(user, team)
+}
+
+// UpdateWorkSchedule sets user's own schedule, `null` makes the user work by the team's one
+func (h *FrontendHandlers) UpdateWorkSchedule(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWorkScheduleResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	var schedule *models.WorkSchedule
+	if ok := jsonDecode(&schedule, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	capacityService := data.NewCapacityService(session)
+	result, err := capacityService.UpdateUserSchedule(user, team, schedule)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = result
+}
+
+// TeamWorkSchedule returns the default schedule of the team members
+func (h *FrontendHandlers) TeamWorkSchedule(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWorkScheduleResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = data.WorkScheduleOf(&models.TeamUser{}, team)
+}
+
+func (h *FrontendHandlers) UpdateTeamWorkSchedule(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewWorkScheduleResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	var schedule *models.WorkSchedule
+	if ok := jsonDecode(&schedule, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	team, err = teamService.UpdateWorkSchedule(user, team, schedule)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = data.WorkScheduleOf(&models.TeamUser{}, team)
+}
+
+// TeamRounding returns the rule the time of the team's projects is billed by, `null` if it is not rounded
+func (h *FrontendHandlers) TeamRounding(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewRoundingRuleResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = team.Rounding
+}
+
+func (h *FrontendHandlers) UpdateTeamRounding(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewRoundingRuleResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	var rule *models.RoundingRule
+	if ok := jsonDecode(&rule, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	team, err = teamService.UpdateRounding(user, team, rule)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = team.Rounding
+}
+
+// UpdateProjectRounding sets project's own rounding rule, `null` makes the project follow the team's one
+func (h *FrontendHandlers) UpdateProjectRounding(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewProjectResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	var rule *models.RoundingRule
+	if ok := jsonDecode(&rule, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	teamService := data.NewTeamService(session)
+	team, err := teamService.FindByID(user.TeamID)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+
+	project, err := teamService.UpdateProjectRounding(user, team, mux.Vars(r)["id"], rule)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = project
+}
+
+// TimeOff returns user's time off overlapping the range of days
+func (h *FrontendHandlers) TimeOff(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimeOffsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+	timeOffService := data.NewTimeOffService(session)
+	timeOffs, err := timeOffService.TimeOff(user, query.Get("start_date"), query.Get("end_date"))
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timeOffs
+}
+
+// CreateTimeOff books time off for the user or, given `user_id`, for a member of user's team
+func (h *FrontendHandlers) CreateTimeOff(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTimeOffResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		UserID    string `json:"user_id"`
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Kind      string `json:"kind"`
+		HalfDay   bool   `json:"half_day"`
+		Note      string `json:"note"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	owner := user
+	if requestData.UserID != "" && requestData.UserID != user.ID.Hex() {
+		var err error
+		if owner, err = data.NewUserService(session).FindByID(requestData.UserID); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
+
+	timeOffService := data.NewTimeOffService(session)
+	timeOff, err := timeOffService.CreateTimeOff(user, owner, &data.TimeOffRequest{
+		StartDate: requestData.StartDate,
+		EndDate:   requestData.EndDate,
+		Kind:      requestData.Kind,
+		HalfDay:   requestData.HalfDay,
+		Note:      requestData.Note,
+	})
+	if err == data.ErrForbidden {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	} else if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = timeOff
+}
+
+func (h *FrontendHandlers) DeleteTimeOff(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewResponseBody(h.status)
+	defer encodeResponse(w, resp)
+
+	timeOffService := data.NewTimeOffService(session)
+	if err := timeOffService.DeleteTimeOff(user, mux.Vars(r)["id"]); err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseStatus.UserMessage = "successfully deleted"
+}
+
+// Holidays returns the holidays of user's team within the range of days
+func (h *FrontendHandlers) Holidays(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewHolidaysResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+	timeOffService := data.NewTimeOffService(session)
+	holidays, err := timeOffService.Holidays(user, query.Get("start_date"), query.Get("end_date"))
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = holidays
+}
+
+func (h *FrontendHandlers) CreateHoliday(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewHolidayResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		Date    string `json:"date"`
+		Name    string `json:"name"`
+		HalfDay bool   `json:"half_day"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	timeOffService := data.NewTimeOffService(session)
+	holiday, err := timeOffService.CreateHoliday(user, requestData.Date, requestData.Name, requestData.HalfDay)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = holiday
+}
+
+// ImportHolidays adds the all-day events of an uploaded ICS calendar to the team's holidays
+func (h *FrontendHandlers) ImportHolidays(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewHolidaysResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	defer file.Close()
+
+	timeOffService := data.NewTimeOffService(session)
+	holidays, err := timeOffService.ImportHolidays(user, file)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = holidays
+}
+
+func (h *FrontendHandlers) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewResponseBody(h.status)
+	defer encodeResponse(w, resp)
+
+	timeOffService := data.NewTimeOffService(session)
+	if err := timeOffService.DeleteHoliday(user, mux.Vars(r)["id"]); err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseStatus.UserMessage = "successfully deleted"
+}
+
+// Heartbeat tells the user is active, the frontend or a desktop helper calls it while a timer runs.
+// Responds with the idle intervals the user should be asked about
+func (h *FrontendHandlers) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewIdleIntervalsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	idleService := data.NewIdleService(session)
+	intervals, err := idleService.Heartbeat(user, time.Now())
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = intervals
+}
+
+func (h *FrontendHandlers) IdleIntervals(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewIdleIntervalsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	idleService := data.NewIdleService(session)
+	intervals, err := idleService.Pending(user)
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = intervals
+}
+
+// ResolveIdleInterval keeps, discards or splits off the idle interval according to the `action`
+func (h *FrontendHandlers) ResolveIdleInterval(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewIdleIntervalResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	requestData := struct {
+		Action string `json:"action"`
+	}{}
+	if ok := jsonDecode(&requestData, r, resp.ResponseStatus); !ok {
+		return
+	}
+
+	idleService := data.NewIdleService(session)
+	interval, err := idleService.Resolve(user, mux.Vars(r)["id"], requestData.Action, time.Now())
+	if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = interval
+}
+
+// TaskSuggestions suggests the recent and frequent tasks of the team with a word of the name starting with `q`,
+// `project` narrows them down to a project
+func (h *FrontendHandlers) TaskSuggestions(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewTaskSuggestionsResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
+
+	timerService := data.NewTimerService(session)
+	suggestions, err := timerService.SuggestTasks(user, query.Get("project"), query.Get("q"), limit, time.Now())
+	if err != nil {
+		writeError(resp.ResponseStatus, statusInternalServerError, err.Error(), "")
+		return
+	}
+	resp.ResponseData = suggestions
+}
+
+// Events streams the changes of user's timers, and of the team's ones to those who see team reports,
+// as Server-Sent Events until the client disconnects. Every event is a JSON models.TimerEvent
+// named by its type, e.g. `timer.started`
+func (h *FrontendHandlers) Events(w http.ResponseWriter, r *http.Request) {
+	user := context.Get(r, "user").(*models.TeamUser)
+	claims, _ := context.Get(r, "claims").(jwt.MapClaims)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	subscription := h.events.Subscribe()
+	defer h.events.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(h.eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	// the stream ends when the token it was opened with expires
+	var expired <-chan time.Time
+	if expiresAt, ok := claimsExpiry(claims); ok {
+		expiry := time.NewTimer(expiresAt.Sub(time.Now()))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired:
+			return
+		case <-keepAlive.C:
+			// logging out, revoked sessions and role changes take effect on the open streams too
+			var err error
+			if user, err = h.streamUser(user, claims); err != nil {
+				return
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			if !data.CanSeeTimerEvent(user, event) {
+				continue
+			}
+
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Event, payload)
+		}
+		flusher.Flush()
+	}
+}
+
+// streamUser reloads the user of an event stream and makes sure the token the stream was opened with
+// is still valid and the user may still see their data
+func (h *FrontendHandlers) streamUser(user *models.TeamUser, claims jwt.MapClaims) (*models.TeamUser, error) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+
+	current, err := data.NewUserService(session).FindByID(user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if err = ensureTokenVersion(current, claims); err != nil {
+		return nil, err
+	}
+	if err = data.Authorize(current, data.PermissionViewOwnData); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// Search looks for `q` in task names of the user or of the team members listed in `users`,
+// `projects`, `start_date` and `end_date` narrow the search down
+func (h *FrontendHandlers) Search(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+	user := context.Get(r, "user").(*models.TeamUser)
+
+	resp := NewSearchResponse(h.status)
+	defer encodeResponse(w, resp)
+
+	query := r.URL.Query()
+	filter := &data.SearchFilter{
+		Text:       query.Get("q"),
+		UserIDs:    splitQueryList(query.Get("users")),
+		ProjectIDs: splitQueryList(query.Get("projects")),
+	}
+
+	var err error
+	tzOffset := user.SlackUserInfo.TZOffset
+	if startDate := query.Get("start_date"); startDate != "" {
+		if filter.StartDate, err = data.ParseDay(startDate, tzOffset); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
+	if endDate := query.Get("end_date"); endDate != "" {
+		if filter.EndDate, err = data.ParseDay(endDate, tzOffset); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+		filter.EndDate = filter.EndDate.Add(24*time.Hour - time.Second)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+			return
+		}
+	}
+
+	timerService := data.NewTimerService(session)
+	results, err := timerService.SearchTasks(user, filter)
+	if err == data.ErrForbidden {
+		writeError(resp.ResponseStatus, statusForbidden, err.Error(), userForbiddenMessage)
+		return
+	} else if err != nil {
+		writeError(resp.ResponseStatus, statusBadRequest, err.Error(), "")
+		return
+	}
+	resp.ResponseData = results
+}
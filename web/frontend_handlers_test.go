@@ -19,6 +19,9 @@ import (
 	"github.com/justinas/alice"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
+	"bufio"
+	"io/ioutil"
+	"strings"
 )
 
 func TestFrontendHandlers(t *testing.T) {
@@ -33,8 +36,10 @@ func (s *FrontendHandlersTestSuite) TestAuthenticate(t *testing.T) {
 	req, err := http.NewRequest("POST", "/api/v1/frontend/sessions", body)
 	s.Nil(err)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-browser")
+	req.RemoteAddr = "10.0.0.1:53211"
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.Authenticate)
 	handler.ServeHTTP(recorder, req)
@@ -43,10 +48,114 @@ func (s *FrontendHandlersTestSuite) TestAuthenticate(t *testing.T) {
 	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
 	s.Nil(err)
 
-	verificationToken, err := NewUserToken(s.user.ID.Hex(), s.session)
-	s.Nil(err)
 	s.Equal(resp.ResponseStatus.Status, "200")
-	s.Equal(resp.ResponseData.Token, verificationToken)
+	claims, err := s.secureCTX.JWT.parse(resp.ResponseData.Token, jwtTypeAccess)
+	s.Nil(err)
+	s.Equal(claims["user_id"], s.user.ID.Hex())
+	_, err = s.secureCTX.JWT.parse(resp.ResponseData.RefreshToken, jwtTypeRefresh)
+	s.Nil(err)
+	s.True(resp.ResponseData.ExpiresAt.After(time.Now()))
+
+	// the pass is used now
+	body = new(bytes.Buffer)
+	json.NewEncoder(body).Encode(reqData)
+	req, _ = http.NewRequest("POST", "/api/v1/frontend/sessions", body)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	reused := JWTResponse{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &reused))
+	s.Equal(reused.ResponseStatus.Status, "400")
+	s.Equal(reused.ResponseData.Token, "")
+
+	// and the login is in the user's log
+	req, _ = http.NewRequest("GET", "/api/v1/frontend/session/claims", nil)
+	req.Header.Set("Authorization", "Bearer " + resp.ResponseData.Token)
+	recorder = httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.SessionClaims).ServeHTTP(recorder, req)
+	claimsResp := PassClaimsResponse{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &claimsResp))
+	s.Equal(claimsResp.ResponseStatus.Status, "200")
+	s.Len(claimsResp.ResponseData, 1)
+	s.Equal(claimsResp.ResponseData[0].UserAgent, "test-browser")
+	s.Equal(claimsResp.ResponseData[0].IP, "10.0.0.1")
+}
+
+func TestClientIP(t *testing.T) {
+	s := is.New(t)
+
+	proxies := newTrustedProxies([]interface{}{"10.0.0.0/8", "192.168.1.10", "not an address"})
+	s.Len(proxies, 2)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:53211"
+	s.Equal(clientIP(req, proxies), "10.0.0.1")
+
+	// the right-most address not of a trusted proxy is the client, whatever the client put before it
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.2")
+	s.Equal(clientIP(req, proxies), "203.0.113.7")
+
+	// X-Forwarded-For of the clients connecting directly is ignored
+	req.RemoteAddr = "203.0.113.9:53211"
+	s.Equal(clientIP(req, proxies), "203.0.113.9")
+	s.Equal(clientIP(req, nil), "203.0.113.9")
+
+	// and so are the hops when all of them are trusted proxies
+	req.RemoteAddr = "192.168.1.10:53211"
+	req.Header.Set("X-Forwarded-For", "10.0.0.2")
+	s.Equal(clientIP(req, proxies), "192.168.1.10")
+}
+
+func (s *FrontendHandlersTestSuite) TestRefreshSessionAndLogout(t *testing.T) {
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	tokens, err := NewUserTokens(s.secureCTX.JWT, s.user.ID.Hex(), "", s.session, time.Now().Add(-time.Minute))
+	s.Nil(err)
+
+	refresh := func(refreshToken string) JWTResponse {
+		body := new(bytes.Buffer)
+		json.NewEncoder(body).Encode(map[string]string{"refresh_token": refreshToken})
+		req, _ := http.NewRequest("POST", "/api/v1/frontend/session/refresh", body)
+		recorder := httptest.NewRecorder()
+		http.HandlerFunc(h.RefreshSession).ServeHTTP(recorder, req)
+
+		resp := JWTResponse{}
+		s.Nil(json.Unmarshal(recorder.Body.Bytes(), &resp))
+		return resp
+	}
+
+	refreshed := refresh(tokens.RefreshToken)
+	s.Equal(refreshed.ResponseStatus.Status, "200")
+	s.NotEqual(refreshed.ResponseData.Token, tokens.Token)
+
+	// an access token is not a refresh token and vice versa
+	s.Equal(refresh(tokens.Token).ResponseStatus.Status, "400")
+
+	req, _ := http.NewRequest("GET", "/api/v1/frontend/timers", nil)
+	req.Header.Set("Authorization", "Bearer " + tokens.RefreshToken)
+	recorder := httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.TimersData).ServeHTTP(recorder, req)
+	rejected := ResponseBody{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &rejected))
+	s.Equal(rejected.ResponseStatus.Status, "400")
+
+	// logging out revokes every token issued before
+	req, _ = http.NewRequest("POST", "/api/v1/frontend/session/logout", nil)
+	req.Header.Set("Authorization", "Bearer " + refreshed.ResponseData.Token)
+	recorder = httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.Logout).ServeHTTP(recorder, req)
+	loggedOut := ResponseBody{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &loggedOut))
+	s.Equal(loggedOut.ResponseStatus.Status, "200")
+
+	req, _ = http.NewRequest("GET", "/api/v1/frontend/timers", nil)
+	req.Header.Set("Authorization", "Bearer " + refreshed.ResponseData.Token)
+	recorder = httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.TimersData).ServeHTTP(recorder, req)
+	rejected = ResponseBody{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &rejected))
+	s.Equal(rejected.ResponseStatus.Status, "400")
+	s.Equal(rejected.ResponseStatus.DeveloperMessage, "token is revoked")
+
+	s.Equal(refresh(refreshed.ResponseData.RefreshToken).ResponseStatus.Status, "400")
 }
 
 func (s *FrontendHandlersTestSuite) TestAuthenticateWithWrongPid(t *testing.T) {
@@ -58,7 +167,7 @@ func (s *FrontendHandlersTestSuite) TestAuthenticateWithWrongPid(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	s.Nil(err)
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	handler := http.HandlerFunc(h.Authenticate)
 	handler.ServeHTTP(recorder, req)
@@ -81,11 +190,11 @@ func (s *FrontendHandlersTestSuite) TestTimersData(t *testing.T)  {
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	s.Nil(err)
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.TimersData).ServeHTTP(recorder, req)
 
-	resp := TimersResponse{}
+	resp := TimersPageResponse{}
 	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
 
 	s.Nil(err)
@@ -94,6 +203,8 @@ func (s *FrontendHandlersTestSuite) TestTimersData(t *testing.T)  {
 	s.Equal(resp.AppInfo["version"], s.env.AppVersion)
 	s.Equal(len(resp.ResponseData), 1)
 	s.Equal(resp.ResponseData[0].ID, s.timer.ID)
+	s.Equal(resp.Pagination.Total, 1)
+	s.Equal(resp.Pagination.NextCursor, "")
 }
 
 func (s *FrontendHandlersTestSuite) TestTimersDataWithoutDateRange(t *testing.T)  {
@@ -101,17 +212,35 @@ func (s *FrontendHandlersTestSuite) TestTimersDataWithoutDateRange(t *testing.T)
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	s.Nil(err)
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.TimersData).ServeHTTP(recorder, req)
 
-	resp := TimersResponse{}
+	resp := TimersPageResponse{}
 	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
 
+	// the range is open, all the timers are returned
 	s.Nil(err)
-	s.Equal(resp.ResponseStatus.Status, "500")
-	s.NotNil(resp.ResponseStatus.DeveloperMessage)
-	s.Equal(resp.ResponseStatus.UserMessage, "")
+	s.Equal(resp.ResponseStatus.Status, "200")
+	s.Len(resp.ResponseData, 1)
+	s.Equal(resp.Pagination.Total, 1)
+}
+
+func (s *FrontendHandlersTestSuite) TestTimersDataWithWrongFilter(t *testing.T)  {
+	req, err := http.NewRequest("GET", "/api/v1/frontend/timers?sort=name", nil)
+	req.Header.Set("Authorization", "Bearer " + s.userJwt)
+	s.Nil(err)
+
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	recorder := httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.TimersData).ServeHTTP(recorder, req)
+
+	resp := TimersPageResponse{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+
+	s.Nil(err)
+	s.Equal(resp.ResponseStatus.Status, "400")
+	s.Equal(resp.ResponseStatus.DeveloperMessage, "unknown sort `name`")
 	s.Len(resp.ResponseData, 0)
 }
 
@@ -131,7 +260,7 @@ func (s *FrontendHandlersTestSuite) TestTimersDataWithNoExistingUser(t *testing.
 	req.Header.Set("Authorization", "Bearer " + token)
 	s.Nil(err)
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.TimersData).ServeHTTP(recorder, req)
 
@@ -150,7 +279,7 @@ func (s *FrontendHandlersTestSuite) TestProjectsData(t *testing.T)  {
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	s.Nil(err)
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.ProjectsData).ServeHTTP(recorder, req)
 
@@ -182,7 +311,7 @@ func (s *FrontendHandlersTestSuite) TestProjectsDataWithNoExistedUser(t *testing
 	req.Header.Set("Authorization", "Bearer " + token)
 	s.Nil(err)
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.ProjectsData).ServeHTTP(recorder, req)
 	resp := ProjectsResponse{}
@@ -204,7 +333,7 @@ func (s *FrontendHandlersTestSuite) TestCreateTimer(t *testing.T)  {
 		ProjectID:  bson.NewObjectId().Hex(),
 		ProjectExternalID:  "external-project-id",
 		ProjectExternalName: "external-project-name",
-		Minutes: 30,
+		Seconds: 30 * 60,
 	}
 	body := new(bytes.Buffer)
 	json.NewEncoder(body).Encode(newTimer)
@@ -214,7 +343,7 @@ func (s *FrontendHandlersTestSuite) TestCreateTimer(t *testing.T)  {
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	req.Header.Set("Content-Type", "application/json")
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.CreateTimer).ServeHTTP(recorder, req)
 
@@ -231,7 +360,7 @@ func (s *FrontendHandlersTestSuite) TestCreateTimer(t *testing.T)  {
 
 	// Check for first timer completed
 	s.Equal(resp.ResponseData[0].ID, s.timer.ID)
-	s.Equal(resp.ResponseData[0].Minutes, 20)
+	s.Equal(resp.ResponseData[0].Seconds, 20*60)
 	s.NotNil(resp.ResponseData[0])
 
 	// Check new timers data
@@ -240,8 +369,8 @@ func (s *FrontendHandlersTestSuite) TestCreateTimer(t *testing.T)  {
 	s.Equal(resp.ResponseData[1].ProjectID, newTimer.ProjectID)
 	s.Equal(resp.ResponseData[1].ProjectExternalID, newTimer.ProjectExternalID)
 	s.Equal(resp.ResponseData[1].ProjectExternalName, newTimer.ProjectExternalName)
-	s.Equal(resp.ResponseData[1].Minutes, 0)
-	s.Equal(resp.ResponseData[1].ActualMinutes, 0)
+	s.Equal(resp.ResponseData[1].Seconds, 0)
+	s.Equal(resp.ResponseData[1].ActualSeconds, 0)
 	s.Equal(resp.ResponseData[1].TeamID, s.user.TeamID)
 }
 
@@ -252,9 +381,9 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimer(t *testing.T)  {
 		ProjectID:  bson.NewObjectId().Hex(),
 		ProjectExternalID:  "external-project-id",
 		ProjectExternalName: "external-project-name",
-		Minutes: 25,
+		Seconds: 25 * 60,
 		Edits: []*models.TimeEdit{
-			{TeamUserID: s.user.ID.Hex(), CreatedAt: time.Now(), Minutes: 10},
+			{TeamUserID: s.user.ID.Hex(), CreatedAt: time.Now(), Seconds: 10 * 60},
 		},
 	}
 	body := new(bytes.Buffer)
@@ -265,7 +394,7 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimer(t *testing.T)  {
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	req.Header.Set("Content-Type", "application/json")
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.UpdateTimer).ServeHTTP(recorder, req)
 
@@ -284,8 +413,8 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimer(t *testing.T)  {
 	s.Equal(resp.ResponseData.ProjectExternalName, timersData.ProjectExternalName)
 	s.Equal(resp.ResponseData.TeamID, s.user.TeamID)
 	// Check calculation of timers edits
-	s.NotEqual(resp.ResponseData.Minutes, 25)
-	s.Equal(resp.ResponseData.Minutes, 30)
+	s.NotEqual(resp.ResponseData.Seconds, 25*60)
+	s.Equal(resp.ResponseData.Seconds, 30*60)
 }
 
 func (s *FrontendHandlersTestSuite) TestUpdateTimerWithNoExistingTimer(t *testing.T)  {
@@ -304,7 +433,7 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimerWithNoExistingTimer(t *testin
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	req.Header.Set("Content-Type", "application/json")
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.UpdateTimer).ServeHTTP(recorder, req)
 
@@ -327,7 +456,7 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimerStopAction(t *testing.T)  {
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	req.Header.Set("Content-Type", "application/json")
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.UpdateTimer).ServeHTTP(recorder, req)
 
@@ -339,7 +468,7 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimerStopAction(t *testing.T)  {
 	s.Equal(resp.AppInfo["env"], utils.TestEnv)
 	s.Equal(resp.AppInfo["version"], s.env.AppVersion)
 	s.Equal(resp.ResponseData.ID, s.timer.ID)
-	s.Equal(resp.ResponseData.Minutes, 20)
+	s.Equal(resp.ResponseData.Seconds, 20*60)
 	s.NotNil(resp.ResponseData.FinishedAt)
 }
 
@@ -356,7 +485,7 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimerStopAlreadyStoppedTimer(t *te
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	req.Header.Set("Content-Type", "application/json")
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.UpdateTimer).ServeHTTP(recorder, req)
 
@@ -371,7 +500,7 @@ func (s *FrontendHandlersTestSuite) TestUpdateTimerStopAlreadyStoppedTimer(t *te
 
 func (s *FrontendHandlersTestSuite) TestDeleteTimer(t *testing.T) {
 	router := mux.NewRouter()
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	router.Handle("/api/v1/frontend/timers/{id}", s.middlewareChain.ThenFunc(h.DeleteTimer)).Methods("DELETE")
 	ts := httptest.NewServer(router)
 	defer ts.Close()
@@ -408,7 +537,7 @@ func (s *FrontendHandlersTestSuite) TestDeleteTimer(t *testing.T) {
 
 func (s *FrontendHandlersTestSuite) TestDeleteTimerWithForeignUser(t *testing.T) {
 	router := mux.NewRouter()
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	router.Handle("/api/v1/frontend/timers/{id}", s.middlewareChain.ThenFunc(h.DeleteTimer)).Methods("DELETE")
 	ts := httptest.NewServer(router)
 	defer ts.Close()
@@ -426,7 +555,7 @@ func (s *FrontendHandlersTestSuite) TestDeleteTimerWithForeignUser(t *testing.T)
 	_, err := userRepository.Save(user)
 	s.Nil(err)
 
-	userJwt, err := NewUserToken(user.ID.Hex(), s.session)
+	userJwt, err := NewUserToken(s.secureCTX.JWT, user.ID.Hex(), s.session)
 	s.Nil(err)
 
 	req, _ := http.NewRequest("DELETE", ts.URL + "/api/v1/frontend/timers/" + s.timer.ID.Hex(), nil)
@@ -455,7 +584,7 @@ func (s *FrontendHandlersTestSuite) TestMonthStatistic(t *testing.T)  {
 		ProjectExternalName:	"project_name",
 		TeamUserID:		s.user.ID.Hex(),
 		CreatedAt:		startDate.Add(time.Hour * 1),
-		Minutes:		30,
+		Seconds:		30 * 60,
 		FinishedAt:		&finished,
 	})
 
@@ -464,7 +593,7 @@ func (s *FrontendHandlersTestSuite) TestMonthStatistic(t *testing.T)  {
 	req.Header.Set("Authorization", "Bearer " + s.userJwt)
 	req.Header.Set("Content-Type", "application/json")
 
-	h := NewFrontendHandlers(s.env, s.session)
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
 	recorder := httptest.NewRecorder()
 	s.middlewareChain.ThenFunc(h.MonthStatistics).ServeHTTP(recorder, req)
 
@@ -477,11 +606,302 @@ func (s *FrontendHandlersTestSuite) TestMonthStatistic(t *testing.T)  {
 	s.Equal(resp.AppInfo["version"], s.env.AppVersion)
 	s.NotNil(resp.ResponseData[0])
 	s.Equal(resp.ResponseData[0].Day, 1)
-	s.Equal(resp.ResponseData[0].Minutes, 30)
+	s.Equal(resp.ResponseData[0].Seconds, 30*60)
 	s.Equal(resp.ResponseData[0].ProjectsNames[0], "project_name")
 	s.Len(resp.ResponseData, 1)
 }
 
+func (s *FrontendHandlersTestSuite) TestStatisticsAndHeatmap(t *testing.T) {
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+
+	req, _ := http.NewRequest("GET", "/api/v1/frontend/statistics?period=decade&date=2016-12-1", nil)
+	req.Header.Set("Authorization", "Bearer " + s.userJwt)
+	recorder := httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.Statistics).ServeHTTP(recorder, req)
+
+	statistics := UserStatisticsResponse{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &statistics))
+	s.Equal(statistics.ResponseStatus.Status, "400")
+
+	req, _ = http.NewRequest("GET", "/api/v1/frontend/heatmap?date=2016-12-31", nil)
+	req.Header.Set("Authorization", "Bearer " + s.userJwt)
+	recorder = httptest.NewRecorder()
+	s.middlewareChain.ThenFunc(h.Heatmap).ServeHTTP(recorder, req)
+
+	heatmap := HeatmapResponse{}
+	s.Nil(json.Unmarshal(recorder.Body.Bytes(), &heatmap))
+	s.Equal(heatmap.ResponseStatus.Status, "200")
+	s.Len(heatmap.ResponseData, 52*7 + 6)
+	s.Equal(heatmap.ResponseData[0].Date, "2015-12-28")
+	s.Equal(heatmap.ResponseData[len(heatmap.ResponseData)-1].Date, "2016-12-31")
+}
+
+func (s *FrontendHandlersTestSuite) TestProjectBudget(t *testing.T) {
+	router := mux.NewRouter()
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	router.Handle("/api/v1/frontend/projects/{id}/budget", s.middlewareChain.ThenFunc(h.ProjectBudget)).Methods("GET")
+	router.Handle("/api/v1/frontend/projects/{id}/budget", s.middlewareChain.ThenFunc(h.UpdateProjectBudget)).Methods("PUT")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	url := ts.URL + "/api/v1/frontend/projects/" + s.team.Projects[0].ID.Hex() + "/budget"
+
+	body := new(bytes.Buffer)
+	json.NewEncoder(body).Encode(models.ProjectBudget{Kind: models.BudgetKindHours, Amount: 10})
+	req, _ := http.NewRequest("PUT", url, body)
+	req.Header.Set("Authorization", "Bearer " + s.userJwt)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Nil(err)
+
+	projectResp := ProjectResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&projectResp)
+	s.Nil(err)
+	s.Equal(projectResp.ResponseStatus.Status, "200")
+	s.Equal(projectResp.ResponseData.Budget.Amount, 10.0)
+
+	req, _ = http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer " + s.userJwt)
+
+	resp, err = http.DefaultClient.Do(req)
+	s.Nil(err)
+
+	budgetResp := ProjectBudgetResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&budgetResp)
+	s.Nil(err)
+	s.Equal(budgetResp.ResponseStatus.Status, "200")
+	s.Equal(budgetResp.ResponseData.Budget, 10.0)
+	s.Equal(budgetResp.ResponseData.Kind, models.BudgetKindHours)
+}
+
+func (s *FrontendHandlersTestSuite) TestProjectBudgetNotSet(t *testing.T) {
+	router := mux.NewRouter()
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	router.Handle("/api/v1/frontend/projects/{id}/budget", s.middlewareChain.ThenFunc(h.ProjectBudget)).Methods("GET")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL + "/api/v1/frontend/projects/" + s.team.Projects[0].ID.Hex() + "/budget", nil)
+	req.Header.Set("Authorization", "Bearer " + s.userJwt)
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Nil(err)
+
+	budgetResp := ProjectBudgetResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&budgetResp)
+	s.Nil(err)
+	s.Equal(budgetResp.ResponseStatus.Status, "400")
+	s.Equal(budgetResp.ResponseStatus.DeveloperMessage, "project has no budget")
+	s.Nil(budgetResp.ResponseData)
+}
+
+func (s *FrontendHandlersTestSuite) TestEvents(t *testing.T) {
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	bus := data.NewMemoryEventBus()
+	h.events = bus
+
+	chain := alice.New(s.secureCTX.CorsMiddleware, s.secureCTX.StreamJWTMiddleware, s.secureCTX.CurrentUserMiddleware)
+	ts := httptest.NewServer(chain.ThenFunc(h.Events))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "?access_token=" + s.userJwt)
+	s.Nil(err)
+	defer resp.Body.Close()
+	s.Equal(resp.Header.Get("Content-Type"), "text/event-stream")
+
+	reader := bufio.NewReader(resp.Body)
+	line, _ := reader.ReadString('\n')
+	s.Equal(line, ": connected\n")
+
+	// the timer of another team is not streamed
+	bus.Fire(models.WebhookEventTimerStarted, &models.Timer{ID: bson.NewObjectId(), TeamID: "other", TeamUserID: "other"})
+	bus.Fire(models.WebhookEventTimerStopped, s.timer)
+
+	lines := []string{}
+	for len(lines) < 3 {
+		line, err = reader.ReadString('\n')
+		s.Nil(err)
+		if line != "\n" {
+			lines = append(lines, line)
+		}
+	}
+	s.True(strings.HasPrefix(lines[0], "id: "))
+	s.Equal(lines[1], "event: timer.stopped\n")
+
+	event := &models.TimerEvent{}
+	s.Nil(json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), event))
+	s.Equal(event.Event, models.WebhookEventTimerStopped)
+	s.Equal(event.Timer.ID, s.timer.ID)
+}
+
+func (s *FrontendHandlersTestSuite) TestEventsEndWhenTokenIsRevoked(t *testing.T) {
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	h.events = data.NewMemoryEventBus()
+	h.eventsKeepAlive = 50 * time.Millisecond
+
+	chain := alice.New(s.secureCTX.CorsMiddleware, s.secureCTX.StreamJWTMiddleware, s.secureCTX.CurrentUserMiddleware)
+	ts := httptest.NewServer(chain.ThenFunc(h.Events))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "?access_token=" + s.userJwt)
+	s.Nil(err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	line, _ := reader.ReadString('\n')
+	s.Equal(line, ": connected\n")
+
+	// logging out everywhere closes the stream on the next keep-alive
+	s.Nil(data.NewUserService(s.session).RevokeTokens(s.user))
+
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(reader)
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		s.Nil(err)
+	case <-time.After(2 * time.Second):
+		t.Error("the stream is still open after the token was revoked")
+	}
+}
+
+func (s *FrontendHandlersTestSuite) TestAccessTokens(t *testing.T) {
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	chain := alice.New(s.secureCTX.AuthenticationMiddleware, s.secureCTX.RequirePermission(data.PermissionViewOwnData))
+	router := mux.NewRouter()
+	router.Handle("/access_tokens", chain.Append(s.secureCTX.RequireSession).ThenFunc(h.CreateAccessToken)).Methods("POST")
+	router.Handle("/timers", chain.ThenFunc(h.TimersData)).Methods("GET")
+	router.Handle("/team/report", alice.New(s.secureCTX.AuthenticationMiddleware, s.secureCTX.RequirePermission(data.PermissionViewTeamReports)).ThenFunc(h.TeamReport)).Methods("GET")
+	router.Handle("/search", chain.ThenFunc(h.Search)).Methods("GET")
+	router.Handle("/capacity", chain.ThenFunc(h.CapacityReport)).Methods("GET")
+	router.Handle("/calendar_feed", chain.Append(s.secureCTX.RequireSession).ThenFunc(h.RegenerateCalendarFeed)).Methods("POST")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	request := func(method, path, token string, body string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL + path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer " + token)
+		resp, err := http.DefaultClient.Do(req)
+		s.Nil(err)
+		return resp
+	}
+
+	created := CreatedAccessTokenResponse{}
+	resp := request("POST", "/access_tokens", s.userJwt, `{"name": "cli", "scopes": ["timers:read"]}`)
+	s.Nil(json.NewDecoder(resp.Body).Decode(&created))
+	s.Equal(created.ResponseStatus.Status, "200")
+	s.True(strings.HasPrefix(created.ResponseData.Token, data.AccessTokenPrefix))
+	s.Equal(created.ResponseData.Name, "cli")
+	token := created.ResponseData.Token
+
+	// the token works instead of the JWT within its scopes
+	timers := TimersPageResponse{}
+	resp = request("GET", "/timers", token, "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&timers))
+	s.Equal(timers.ResponseStatus.Status, "200")
+
+	report := ResponseBody{}
+	resp = request("GET", "/team/report", token, "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&report))
+	s.Equal(report.ResponseStatus.Status, "403")
+
+	// nor through the routes the own data is read at, which check the permissions to see the others in the services
+	other := &models.TeamUser{
+		TeamID:           s.team.ID.Hex(),
+		ExternalUserID:   "ext-other-user-id",
+		ExternalUserName: "other-user-name",
+		SlackUserInfo:    &slack.User{},
+	}
+	_, err := data.NewUserRepository(s.session).Save(other)
+	s.Nil(err)
+
+	searchPath := "/search?q=task&users=" + s.user.ID.Hex() + "," + other.ID.Hex()
+	search := ResponseBody{}
+	resp = request("GET", searchPath, s.userJwt, "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&search))
+	s.Equal(search.ResponseStatus.Status, "200")
+
+	search = ResponseBody{}
+	resp = request("GET", searchPath, token, "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&search))
+	s.Equal(search.ResponseStatus.Status, "403")
+
+	capacity := ResponseBody{}
+	resp = request("GET", "/capacity?start_date=2016-12-05&end_date=2016-12-11&user_id=" + other.ID.Hex(), token, "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&capacity))
+	s.Equal(capacity.ResponseStatus.Status, "403")
+
+	// and cannot create more tokens
+	forbidden := ResponseBody{}
+	resp = request("POST", "/access_tokens", token, `{"name": "another", "scopes": ["reports"]}`)
+	s.Nil(json.NewDecoder(resp.Body).Decode(&forbidden))
+	s.Equal(forbidden.ResponseStatus.Status, "403")
+
+	// nor reveal the secret URL of the calendar feed
+	forbidden = ResponseBody{}
+	resp = request("POST", "/calendar_feed", token, "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&forbidden))
+	s.Equal(forbidden.ResponseStatus.Status, "403")
+
+	unknown := ResponseBody{}
+	resp = request("GET", "/timers", data.AccessTokenPrefix + "unknown", "")
+	s.Nil(json.NewDecoder(resp.Body).Decode(&unknown))
+	s.Equal(unknown.ResponseStatus.Status, "400")
+	s.Equal(unknown.ResponseStatus.UserMessage, userLoginMessage)
+}
+
+func (s *FrontendHandlersTestSuite) TestRevokeUserSessions(t *testing.T) {
+	h := NewFrontendHandlers(s.env, s.session, s.secureCTX.JWT)
+	router := mux.NewRouter()
+	router.Handle("/team/users/{id}/revoke_sessions", alice.New(s.secureCTX.AuthenticationMiddleware,
+		s.secureCTX.RequirePermission(data.PermissionManageTeam)).ThenFunc(h.RevokeUserSessions)).Methods("POST")
+	router.Handle("/timers", alice.New(s.secureCTX.AuthenticationMiddleware,
+		s.secureCTX.RequirePermission(data.PermissionViewOwnData)).ThenFunc(h.TimersData)).Methods("GET")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	request := func(method, path, token string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL + path, nil)
+		req.Header.Set("Authorization", "Bearer " + token)
+		resp, err := http.DefaultClient.Do(req)
+		s.Nil(err)
+		return resp
+	}
+
+	member := &models.TeamUser{
+		TeamID:           s.team.ID.Hex(),
+		ExternalUserID:   "ext-member-id",
+		ExternalUserName: "member-name",
+		SlackUserInfo:    &slack.User{},
+	}
+	_, err := data.NewUserRepository(s.session).Save(member)
+	s.Nil(err)
+
+	_, secret, err := data.NewAccessTokenService(s.session).CreateToken(member, "script", []string{models.AccessTokenScopeReadTimers})
+	s.Nil(err)
+
+	timers := TimersPageResponse{}
+	resp := request("GET", "/timers", secret)
+	s.Nil(json.NewDecoder(resp.Body).Decode(&timers))
+	s.Equal(timers.ResponseStatus.Status, "200")
+
+	revoked := TeamUserResponse{}
+	resp = request("POST", "/team/users/" + member.ID.Hex() + "/revoke_sessions", s.userJwt)
+	s.Nil(json.NewDecoder(resp.Body).Decode(&revoked))
+	s.Equal(revoked.ResponseStatus.Status, "200")
+
+	// the access tokens of the member are revoked along with the sessions
+	timers = TimersPageResponse{}
+	resp = request("GET", "/timers", secret)
+	s.Nil(json.NewDecoder(resp.Body).Decode(&timers))
+	s.Equal(timers.ResponseStatus.Status, "400")
+	s.Equal(timers.ResponseStatus.UserMessage, userLoginMessage)
+}
+
 // =================== TEST setup =================== //
 type FrontendHandlersTestSuite struct {
 	*is.Is
@@ -508,14 +928,20 @@ func (s *FrontendHandlersTestSuite) SetUpSuite() {
 	e.MigrateDatabase(session)
 	s.env = e
 
+	jwtSettings, err := NewJWTSettings(s.env.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	s.secureCTX = &SecureContext{
 		Origin:  s.env.Config.UString("origin.url"),
 		Session: s.session,
 		Env: 	 s.env,
+		JWT: 	 jwtSettings,
 	}
 	s.middlewareChain = alice.New(
 		s.secureCTX.CorsMiddleware,
-		JWTMiddleware,
+		s.secureCTX.JWTMiddleware,
 		s.secureCTX.CurrentUserMiddleware)
 }
 
@@ -572,13 +998,13 @@ func (s *FrontendHandlersTestSuite) SetUp() {
 			ProjectID:  	"project",
 			TeamUserID: 	s.user.ID.Hex(),
 			CreatedAt:  	time.Now().Add(-20 * time.Minute),
-			Minutes:    	20,
-			ActualMinutes:  20,
+			Seconds:    	20 * 60,
+			ActualSeconds:  20 * 60,
 	})
 	s.Nil(err)
 
 	//Generate user JWT
-	s.userJwt, err = NewUserToken(s.user.ID.Hex(), s.session)
+	s.userJwt, err = NewUserToken(s.secureCTX.JWT, s.user.ID.Hex(), s.session)
 	s.Nil(err)
 }
 
@@ -1,9 +1,11 @@
 package web
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"gopkg.in/mgo.v2"
@@ -18,6 +20,7 @@ import (
 	"github.com/cleverua/tuna-timer-api/utils"
 	"gopkg.in/mgo.v2/bson"
 	"log"
+	"github.com/gorilla/mux"
 )
 
 // Handlers is a collection of net/http handlers to serve the API
@@ -27,6 +30,7 @@ type Handlers struct {
 	status                map[string]string
 	commandLookupFunction func(ctx context.Context, slackCommand models.SlackCustomCommand) (commands.SlackCustomCommandHandler, error)
 	slackOAuth            SlackOAuth
+	frontendLinks         *themes.FrontendLinks
 }
 
 // NewHandlers constructs a Handlers collection
@@ -40,6 +44,7 @@ func NewHandlers(env *utils.Environment, mongoSession *mgo.Session) *Handlers {
 		},
 		commandLookupFunction: commands.LookupHandler,
 		slackOAuth:            NewSlackOAuth(),
+		frontendLinks:         themes.NewFrontendLinks(env.Config),
 	}
 }
 
@@ -69,7 +74,7 @@ func (h *Handlers) Timer(w http.ResponseWriter, r *http.Request) {
 	selfBaseURL := utils.GetSelfURLFromRequest(r)
 	ctx = utils.PutSelfBaseURLInContext(ctx, selfBaseURL)
 
-	theme := themes.NewDefaultSlackMessageTheme(ctx)
+	theme := themes.NewDefaultSlackMessageTheme(ctx, h.frontendLinks)
 	ctx = utils.PutThemeInContext(ctx, theme)
 
 	w.Header().Set("Content-Type", "application/json")
@@ -146,6 +151,35 @@ func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// CalendarFeed serves the personal iCalendar feed, the secret token in the URL is the only authentication
+func (h *Handlers) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	session := h.mongoSession.Clone()
+	defer session.Close()
+
+	days := data.CalendarFeedDefaultDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "days should be a number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var feed bytes.Buffer
+	calendarService := data.NewCalendarService(session)
+	err := calendarService.Feed(&feed, mux.Vars(r)["token"], days, time.Now())
+	if err == mgo.ErrNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Write(feed.Bytes())
+}
+
 func (h *Handlers) SendSampleMessageFromBot(w http.ResponseWriter, r *http.Request) {
 
 	teamRepo := data.NewTeamRepository(h.mongoSession)
@@ -1,39 +1,230 @@
 package web
 
 import (
-	"gopkg.in/mgo.v2"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/cleverua/tuna-timer-api/data"
+	"github.com/cleverua/tuna-timer-api/models"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/olebedev/config"
+	"gopkg.in/mgo.v2"
+)
+
+// Types of the tokens, the `typ` claim. Only access tokens are accepted by the secure routes,
+// refresh tokens are only exchanged for a new pair of tokens
+const (
+	jwtTypeAccess  = "access"
+	jwtTypeRefresh = "refresh"
 )
 
-type JwtToken struct{
-	Token string `json:"jwt"`
+const (
+	defaultJWTAccessTTL  = time.Hour
+	defaultJWTRefreshTTL = 30 * 24 * time.Hour
+)
+
+type JwtToken struct {
+	Token        string    `json:"jwt"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// JWTSettings - the keys the tokens are signed and verified with and their lifetimes, the `jwt` section of the config.
+// Keys are looked up by the `kid` header of a token: new tokens are signed with the key SigningKeyID names
+// and the other keys still verify the tokens signed before a rotation.
+// With BindUserAgent the tokens are only accepted from the user agent they were issued to
+type JWTSettings struct {
+	SigningKeyID  string
+	Keys          map[string][]byte
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+	BindUserAgent bool
+}
+
+// NewJWTSettings reads the settings from the config, which has to have the signing key among the keys
+func NewJWTSettings(cfg *config.Config) (*JWTSettings, error) {
+	settings := &JWTSettings{
+		SigningKeyID: cfg.UString("jwt.signing_key_id"),
+		Keys:         map[string][]byte{},
+		AccessTTL:    defaultJWTAccessTTL,
+		RefreshTTL:   defaultJWTRefreshTTL,
+		BindUserAgent: cfg.UBool("jwt.bind_user_agent"),
+	}
+
+	for id, key := range cfg.UMap("jwt.keys") {
+		if value, ok := key.(string); ok && value != "" {
+			settings.Keys[id] = []byte(value)
+		}
+	}
+	if _, ok := settings.Keys[settings.SigningKeyID]; !ok {
+		return nil, fmt.Errorf("jwt signing key `%s` is not set in jwt.keys", settings.SigningKeyID)
+	}
+
+	var err error
+	if value := cfg.UString("jwt.access_token_ttl"); value != "" {
+		if settings.AccessTTL, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("wrong jwt.access_token_ttl: %s", err)
+		}
+	}
+	if value := cfg.UString("jwt.refresh_token_ttl"); value != "" {
+		if settings.RefreshTTL, err = time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("wrong jwt.refresh_token_ttl: %s", err)
+		}
+	}
+	return settings, nil
+}
+
+// verificationKey is the jwt.Keyfunc which finds the key of the token by its `kid`
+func (s *JWTSettings) verificationKey(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodHS256 {
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+
+	id, _ := token.Header["kid"].(string)
+	key, ok := s.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key `%s`", id)
+	}
+	return key, nil
+}
+
+func (s *JWTSettings) sign(claims jwt.MapClaims, userAgent string) (string, error) {
+	if s.BindUserAgent {
+		claims["uah"] = userAgentHash(userAgent)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = s.SigningKeyID
+	return token.SignedString(s.Keys[s.SigningKeyID])
+}
+
+// parse verifies the token, its expiry and its type and returns its claims
+func (s *JWTSettings) parse(value, tokenType string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(value, s.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != tokenType {
+		return nil, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+// NewUserToken returns an access token of the user
+func NewUserToken(settings *JWTSettings, userId string, session *mgo.Session) (string, error) {
+	tokens, err := NewUserTokens(settings, userId, "", session, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return tokens.Token, nil
 }
 
-func NewUserToken(userId string, session *mgo.Session) (string, error) {
+// NewUserTokens issues a pair of an access and a refresh token of the user. Both carry the user's token version,
+// so bumping the version revokes all of them. The tokens are bound to the user agent if the settings say so
+func NewUserTokens(settings *JWTSettings, userId, userAgent string, session *mgo.Session, now time.Time) (*JwtToken, error) {
 	userService := data.NewUserService(session)
 	teamService := data.NewTeamService(session)
 
 	user, err := userService.FindByID(userId)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	userTeam, err := teamService.FindByID(user.TeamID)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":	 user.ID,
-		"name":		 user.ExternalUserName,
+	expiresAt := now.Add(settings.AccessTTL)
+	accessToken, err := settings.sign(jwt.MapClaims{
+		"typ":           jwtTypeAccess,
+		"iat":           now.Unix(),
+		"exp":           expiresAt.Unix(),
+		"tv":            user.TokenVersion,
+		"user_id":       user.ID,
+		"name":          user.ExternalUserName,
 		"is_team_admin": user.SlackUserInfo.IsAdmin,
-		"image48":	 user.SlackUserInfo.Profile.Image48,
-		"team_id":	 userTeam.ID,
-		"ext_team_id":	 userTeam.ExternalTeamID,
+		"role":          data.RoleOf(user),
+		"image48":       user.SlackUserInfo.Profile.Image48,
+		"team_id":       userTeam.ID,
+		"ext_team_id":   userTeam.ExternalTeamID,
 		"ext_team_name": userTeam.ExternalTeamName,
-	})
+	}, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := settings.sign(jwt.MapClaims{
+		"typ":     jwtTypeRefresh,
+		"iat":     now.Unix(),
+		"exp":     now.Add(settings.RefreshTTL).Unix(),
+		"tv":      user.TokenVersion,
+		"user_id": user.ID,
+	}, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JwtToken{Token: accessToken, RefreshToken: refreshToken, ExpiresAt: expiresAt}, nil
+}
+
+// RefreshUserTokens exchanges a refresh token for a new pair of tokens unless the user's tokens are revoked since
+// or the token is bound to another user agent
+func RefreshUserTokens(settings *JWTSettings, refreshToken, userAgent string, session *mgo.Session, now time.Time) (*JwtToken, error) {
+	claims, err := settings.parse(refreshToken, jwtTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureUserAgent(claims, userAgent); err != nil {
+		return nil, err
+	}
+
+	userID, _ := claims["user_id"].(string)
+	user, err := data.NewUserService(session).FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTokenVersion(user, claims); err != nil {
+		return nil, err
+	}
+
+	return NewUserTokens(settings, userID, userAgent, session, now)
+}
+
+// ensureTokenVersion fails for the tokens issued before the user's tokens were revoked,
+// the `tv` claim is the version of user's tokens the token was issued for
+func ensureTokenVersion(user *models.TeamUser, claims jwt.MapClaims) error {
+	version, _ := claims["tv"].(float64)
+	if int(version) != user.TokenVersion {
+		return errors.New("token is revoked")
+	}
+	return nil
+}
+
+// claimsExpiry returns the time the `exp` claim sets, if there is one
+func claimsExpiry(claims jwt.MapClaims) (time.Time, bool) {
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(exp), 0), true
+}
+
+// ensureUserAgent fails for the tokens bound to another user agent, the `uah` claim is the hash
+// of the user agent the token was issued to. Tokens without the claim are accepted from anywhere
+func ensureUserAgent(claims jwt.MapClaims, userAgent string) error {
+	if hash, ok := claims["uah"].(string); ok && hash != userAgentHash(userAgent) {
+		return errors.New("token is issued to another user agent")
+	}
+	return nil
+}
 
-	signedToken, err := jwtToken.SignedString([]byte("TODO: Extract me in config/env"))
-	return signedToken, err
+func userAgentHash(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
 }
@@ -12,35 +12,161 @@ import (
 	"gopkg.in/mgo.v2/bson"
 	"github.com/cleverua/tuna-timer-api/data"
 	"github.com/nlopes/slack"
+	"github.com/olebedev/config"
+	"time"
 )
 
 func TestJwtToken(t *testing.T) {
 	gosuite.Run(t, &JwtTokenTestSuite{Is: is.New(t)})
 }
 
-func (s *JwtTokenTestSuite) TestNewUserToken(t *testing.T) {
-	jwtToken, err := NewUserToken(s.pass.TeamUserID, s.session)
+func TestNewJWTSettings(t *testing.T) {
+	s := is.New(t)
+
+	cfg, _ := config.ParseYaml(`
+jwt:
+  signing_key_id: "2"
+  keys:
+    "1": "old key"
+    "2": "new key"
+  access_token_ttl: 15m
+`)
+	settings, err := NewJWTSettings(cfg)
 	s.Nil(err)
+	s.Equal(settings.SigningKeyID, "2")
+	s.Len(settings.Keys, 2)
+	s.Equal(settings.AccessTTL, 15*time.Minute)
+	s.Equal(settings.RefreshTTL, defaultJWTRefreshTTL)
+
+	cfg, _ = config.ParseYaml(`
+jwt:
+  signing_key_id: "3"
+  keys:
+    "1": "old key"
+    "3": ""
+`)
+	_, err = NewJWTSettings(cfg)
+	s.Err(err)
+	s.Equal(err.Error(), "jwt signing key `3` is not set in jwt.keys")
+
+	cfg, _ = config.ParseYaml(`
+jwt:
+  signing_key_id: "1"
+  keys:
+    "1": "key"
+  refresh_token_ttl: month
+`)
+	_, err = NewJWTSettings(cfg)
+	s.Err(err)
+
+	cfg, _ = config.ParseYaml(`
+jwt:
+  signing_key_id: "1"
+  keys:
+    "1": "key"
+  bind_user_agent: true
+`)
+	settings, err = NewJWTSettings(cfg)
+	s.Nil(err)
+	s.True(settings.BindUserAgent)
+}
+
+func (s *JwtTokenTestSuite) TestNewUserTokens(t *testing.T) {
+	now := time.Now()
+	tokens, err := NewUserTokens(s.jwt, s.pass.TeamUserID, "", s.session, now)
+	s.Nil(err)
+	s.Equal(tokens.ExpiresAt, now.Add(s.jwt.AccessTTL))
+
+	claims, err := s.jwt.parse(tokens.Token, jwtTypeAccess)
+	s.Nil(err)
+	s.Equal(claims["user_id"], s.user.ID.Hex())
+	s.Equal(claims["role"], models.RoleAdmin)
+	s.Equal(claims["name"], s.user.ExternalUserName)
+	s.Equal(claims["team_id"], s.team.ID.Hex())
+	s.Equal(claims["ext_team_id"], s.team.ExternalTeamID)
+	s.Equal(claims["exp"], float64(now.Add(s.jwt.AccessTTL).Unix()))
+	s.Equal(claims["iat"], float64(now.Unix()))
+	s.Equal(claims["tv"], float64(0))
+
+	// the tokens are of their own type only
+	_, err = s.jwt.parse(tokens.Token, jwtTypeRefresh)
+	s.Err(err)
+	_, err = s.jwt.parse(tokens.RefreshToken, jwtTypeRefresh)
+	s.Nil(err)
+}
+
+func (s *JwtTokenTestSuite) TestTokenVerificationKeys(t *testing.T) {
+	token, err := NewUserToken(s.jwt, s.pass.TeamUserID, s.session)
+	s.Nil(err)
+
+	// tokens signed with a previous key are verified until the key is removed
+	rotated := &JWTSettings{SigningKeyID: "new", Keys: map[string][]byte{"new": []byte("new key")}, AccessTTL: time.Hour}
+	for id, key := range s.jwt.Keys {
+		rotated.Keys[id] = key
+	}
+	_, err = rotated.parse(token, jwtTypeAccess)
+	s.Nil(err)
+
+	delete(rotated.Keys, s.jwt.SigningKeyID)
+	_, err = rotated.parse(token, jwtTypeAccess)
+	s.Err(err)
+
+	// as well as the expired tokens and the tokens of the old literal key without a kid
+	expired, _ := NewUserTokens(s.jwt, s.pass.TeamUserID, "", s.session, time.Now().Add(-2*s.jwt.AccessTTL))
+	_, err = s.jwt.parse(expired.Token, jwtTypeAccess)
+	s.Err(err)
 
-	newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":	 s.user.ID,
-		"is_team_admin": s.user.SlackUserInfo.IsAdmin,
-		"name":		 s.user.ExternalUserName,
-		"image48":	 s.user.SlackUserInfo.Profile.Image48,
-		"team_id":	 s.team.ID,
-		"ext_team_id":	 s.team.ExternalTeamID,
-		"ext_team_name": s.team.ExternalTeamName,
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": s.user.ID, "typ": jwtTypeAccess}).
+		SignedString([]byte("TODO: Extract me in config/env"))
+	_, err = s.jwt.parse(legacy, jwtTypeAccess)
+	s.Err(err)
+}
 
-	})
-	verificationToken, err := newToken.SignedString([]byte("TODO: Extract me in config/env"))
+func (s *JwtTokenTestSuite) TestRefreshUserTokens(t *testing.T) {
+	tokens, _ := NewUserTokens(s.jwt, s.pass.TeamUserID, "", s.session, time.Now().Add(-time.Minute))
 
+	refreshed, err := RefreshUserTokens(s.jwt, tokens.RefreshToken, "", s.session, time.Now())
 	s.Nil(err)
-	s.Equal(jwtToken, verificationToken)
+	s.NotEqual(refreshed.Token, tokens.Token)
+
+	_, err = RefreshUserTokens(s.jwt, tokens.Token, "", s.session, time.Now())
+	s.Err(err)
+
+	// logging out revokes the refresh tokens too
+	s.Nil(data.NewUserService(s.session).RevokeTokens(s.user))
+	_, err = RefreshUserTokens(s.jwt, refreshed.RefreshToken, "", s.session, time.Now())
+	s.Err(err)
+	s.Equal(err.Error(), "token is revoked")
+}
+
+func (s *JwtTokenTestSuite) TestUserTokensBoundToUserAgent(t *testing.T) {
+	bound := *s.jwt
+	bound.BindUserAgent = true
+
+	tokens, err := NewUserTokens(&bound, s.pass.TeamUserID, "browser", s.session, time.Now().Add(-time.Minute))
+	s.Nil(err)
+
+	claims, err := bound.parse(tokens.Token, jwtTypeAccess)
+	s.Nil(err)
+	s.Nil(ensureUserAgent(claims, "browser"))
+	s.Err(ensureUserAgent(claims, "another browser"))
+
+	_, err = RefreshUserTokens(&bound, tokens.RefreshToken, "another browser", s.session, time.Now())
+	s.Err(err)
+	s.Equal(err.Error(), "token is issued to another user agent")
+
+	_, err = RefreshUserTokens(&bound, tokens.RefreshToken, "browser", s.session, time.Now())
+	s.Nil(err)
+
+	// unbound tokens are accepted from any user agent
+	tokens, _ = NewUserTokens(s.jwt, s.pass.TeamUserID, "browser", s.session, time.Now())
+	claims, _ = s.jwt.parse(tokens.Token, jwtTypeAccess)
+	s.Nil(ensureUserAgent(claims, "another browser"))
 }
 
 func (s *JwtTokenTestSuite) TestNewUserTokenFail(t *testing.T) {
 	id := bson.NewObjectId().Hex()
-	jwtToken, err := NewUserToken(id, s.session)
+	jwtToken, err := NewUserToken(s.jwt, id, s.session)
 
 	s.Err(err)
 	s.Equal(err.Error(), mgo.ErrNotFound.Error())
@@ -54,7 +180,7 @@ type JwtTokenTestSuite struct {
 	user    *models.TeamUser
 	pass    *models.Pass
 	team	*models.Team
-
+	jwt	*JWTSettings
 }
 func (s *JwtTokenTestSuite) SetUpSuite() {
 	e := utils.NewEnvironment(utils.TestEnv, "1.0.0")
@@ -67,6 +193,11 @@ func (s *JwtTokenTestSuite) SetUpSuite() {
 	s.session = session.Clone()
 	e.MigrateDatabase(session)
 	s.env = e
+
+	s.jwt, err = NewJWTSettings(e.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 func (s *JwtTokenTestSuite) TearDownSuite() {
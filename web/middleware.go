@@ -12,6 +12,11 @@ import (
 	"github.com/cleverua/tuna-timer-api/data"
 	"github.com/gorilla/context"
 	"github.com/cleverua/tuna-timer-api/utils"
+	"github.com/cleverua/tuna-timer-api/models"
+	"github.com/justinas/alice"
+	"strings"
+	"time"
+	"errors"
 )
 
 func LoggingMiddleware(h http.Handler) http.Handler {
@@ -22,19 +27,29 @@ func RecoveryMiddleware(h http.Handler) http.Handler {
 	return handlers.RecoveryHandler()(h)
 }
 
-func JWTMiddleware(h http.Handler) http.Handler {
+type SecureContext struct {
+	Origin	string
+	Session *mgo.Session
+	Env 	*utils.Environment
+	JWT	*JWTSettings
+}
+
+// JWTMiddleware verifies the signature and the expiry of the JWT and puts its claims into the context
+func (c *SecureContext) JWTMiddleware(h http.Handler) http.Handler {
 	return jwtmiddleware.New(jwtmiddleware.Options{
-		ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-			return []byte("TODO: Extract me in config/env"), nil
-		},
+		ValidationKeyGetter: c.JWT.verificationKey,
 		SigningMethod: jwt.SigningMethodHS256,
 	}).Handler(h)
 }
 
-type SecureContext struct {
-	Origin	string
-	Session *mgo.Session
-	Env 	*utils.Environment
+// StreamJWTMiddleware is JWTMiddleware which also takes the token from the `access_token` query parameter
+// for the streams browsers open with EventSource, which cannot send headers
+func (c *SecureContext) StreamJWTMiddleware(h http.Handler) http.Handler {
+	return jwtmiddleware.New(jwtmiddleware.Options{
+		ValidationKeyGetter: c.JWT.verificationKey,
+		SigningMethod: jwt.SigningMethodHS256,
+		Extractor:     jwtmiddleware.FromFirst(jwtmiddleware.FromAuthHeader, jwtmiddleware.FromParameter("access_token")),
+	}).Handler(h)
 }
 
 func (c *SecureContext) CorsMiddleware(h http.Handler) http.Handler {
@@ -55,29 +70,115 @@ func (c *SecureContext) CorsMiddleware(h http.Handler) http.Handler {
 	})
 }
 
+// AuthenticationMiddleware lets the request in with either a JWT or a personal access token as the bearer token
+// and puts the user into the context. Requests made with an access token get the token into the context too
+func (c *SecureContext) AuthenticationMiddleware(h http.Handler) http.Handler {
+	withJWT := c.JWTMiddleware(c.CurrentUserMiddleware(h))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, _ := jwtmiddleware.FromAuthHeader(r)
+		if !strings.HasPrefix(secret, data.AccessTokenPrefix) {
+			withJWT.ServeHTTP(w, r)
+			return
+		}
+
+		accessTokenService := data.NewAccessTokenService(c.Session)
+		token, user, err := accessTokenService.Authenticate(secret, time.Now())
+		if err != nil {
+			c.unauthenticated(w, err.Error())
+			return
+		}
+
+		context.Set(r, "user", user)
+		context.Set(r, "access_token", token)
+		h.ServeHTTP(w, r)
+	})
+}
+
 func (c *SecureContext) CurrentUserMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userData := context.Get(r, "user").(*jwt.Token).Claims.(jwt.MapClaims)
 
+		userID, _ := userData["user_id"].(string)
 		userService := data.NewUserService(c.Session)
-		user, err := userService.FindByID(userData["user_id"].(string))
-
-		if err != nil {
-			response := NewResponseBody(map[string]string{
-				"env": c.Env.Name,
-				"version": c.Env.AppVersion,
-			})
+		user, err := userService.FindByID(userID)
 
-			response.ResponseStatus.Status = statusBadRequest
-			response.ResponseStatus.UserMessage = userLoginMessage
-			response.ResponseStatus.DeveloperMessage = err.Error()
+		// refresh tokens and the tokens revoked by logging out are not accepted
+		if err == nil && userData["typ"] != jwtTypeAccess {
+			err = errors.New("not an access token")
+		} else if err == nil {
+			err = ensureTokenVersion(user, userData)
+		}
+		if err == nil {
+			err = ensureUserAgent(userData, r.UserAgent())
+		}
 
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(response)
+		if err != nil {
+			c.unauthenticated(w, err.Error())
 			return
 		}
 
+		// the claims stay around for the handlers which outlive the request, like event streams
+		context.Set(r, "claims", userData)
 		context.Set(r, "user", user)
 		h.ServeHTTP(w, r)
 	})
 }
+
+// RequirePermission lets the request through only if the current user's role grants the permission
+// and, for requests made with an access token, a scope of the token covers it.
+// It has to be chained after CurrentUserMiddleware or AuthenticationMiddleware
+func (c *SecureContext) RequirePermission(permission string) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := context.Get(r, "user").(*models.TeamUser)
+
+			// the scopes of an access token are on the user, so the services authorize by them as well
+			if err := data.Authorize(user, permission); err != nil {
+				message := err.Error()
+				if token, ok := context.Get(r, "access_token").(*models.AccessToken); ok && !data.AccessTokenAllows(token, permission) {
+					message = "access token has no scope for " + permission
+				}
+				c.forbidden(w, message)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession turns away the requests made with an access token, so that a token can neither create
+// nor revoke tokens
+func (c *SecureContext) RequireSession(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if context.Get(r, "access_token") != nil {
+			c.forbidden(w, "access tokens are not accepted here")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (c *SecureContext) forbidden(w http.ResponseWriter, developerMessage string) {
+	c.respondWithError(w, statusForbidden, userForbiddenMessage, developerMessage)
+}
+
+// unauthenticated asks to log in again, the token of the request is unknown, expired or revoked
+func (c *SecureContext) unauthenticated(w http.ResponseWriter, developerMessage string) {
+	c.respondWithError(w, statusBadRequest, userLoginMessage, developerMessage)
+}
+
+func (c *SecureContext) respondWithError(w http.ResponseWriter, status, userMessage, developerMessage string) {
+	response := NewResponseBody(map[string]string{
+		"env": c.Env.Name,
+		"version": c.Env.AppVersion,
+	})
+
+	response.ResponseStatus.Status = status
+	response.ResponseStatus.UserMessage = userMessage
+	response.ResponseStatus.DeveloperMessage = developerMessage
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -61,6 +61,19 @@ func NewTimersResponse(info map[string]string) *TimersResponse {
 	}
 }
 
+// Response with a page of user's timers
+type TimersPageResponse struct {
+	*ResponseBody
+	ResponseData []*models.Timer   `json:"data"`
+	Pagination   *models.Pagination `json:"pagination"`
+}
+
+func NewTimersPageResponse(info map[string]string) *TimersPageResponse {
+	return &TimersPageResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
 // Response with array of projects data
 type ProjectsResponse struct {
 	*ResponseBody
@@ -84,3 +97,406 @@ func NewUserStatisticsResponse(info map[string]string) *UserStatisticsResponse {
 		ResponseBody: NewResponseBody(info),
 	}
 }
+
+type PassClaimsResponse struct {
+	*ResponseBody
+	ResponseData []*models.PassClaim `json:"data"`
+}
+
+func NewPassClaimsResponse(info map[string]string) *PassClaimsResponse {
+	return &PassClaimsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+type AccessTokensResponse struct {
+	*ResponseBody
+	ResponseData []*models.AccessToken `json:"data"`
+}
+
+func NewAccessTokensResponse(info map[string]string) *AccessTokensResponse {
+	return &AccessTokensResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+type AccessTokenResponse struct {
+	*ResponseBody
+	ResponseData *models.AccessToken `json:"data"`
+}
+
+func NewAccessTokenResponse(info map[string]string) *AccessTokenResponse {
+	return &AccessTokenResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// CreatedAccessToken is a new access token along with the token itself
+type CreatedAccessToken struct {
+	*models.AccessToken
+	Token string `json:"token"`
+}
+
+type CreatedAccessTokenResponse struct {
+	*ResponseBody
+	ResponseData *CreatedAccessToken `json:"data"`
+}
+
+func NewCreatedAccessTokenResponse(info map[string]string) *CreatedAccessTokenResponse {
+	return &CreatedAccessTokenResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with user's activity by days of a year
+type HeatmapResponse struct {
+	*ResponseBody
+	ResponseData []*models.HeatmapDay `json:"data"`
+}
+
+func NewHeatmapResponse(info map[string]string) *HeatmapResponse {
+	return &HeatmapResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with project budget consumption
+type ProjectBudgetResponse struct {
+	*ResponseBody
+	ResponseData *models.ProjectBudgetReport `json:"data"`
+}
+
+func NewProjectBudgetResponse(info map[string]string) *ProjectBudgetResponse {
+	return &ProjectBudgetResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a single project data
+type ProjectResponse struct {
+	*ResponseBody
+	ResponseData *models.Project `json:"data"`
+}
+
+func NewProjectResponse(info map[string]string) *ProjectResponse {
+	return &ProjectResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a weekly timesheet
+type TimesheetResponse struct {
+	*ResponseBody
+	ResponseData *models.Timesheet `json:"data"`
+}
+
+func NewTimesheetResponse(info map[string]string) *TimesheetResponse {
+	return &TimesheetResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a week of user's time as a project by day matrix, Unapplied are the edited cells which are not saved
+type TimesheetGridResponse struct {
+	*ResponseBody
+	ResponseData *models.TimesheetGrid       `json:"data"`
+	Unapplied    []*models.TimesheetGridCell `json:"unapplied,omitempty"`
+}
+
+func NewTimesheetGridResponse(info map[string]string) *TimesheetGridResponse {
+	return &TimesheetGridResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of weekly timesheets
+type TimesheetsResponse struct {
+	*ResponseBody
+	ResponseData []*models.Timesheet `json:"data"`
+}
+
+func NewTimesheetsResponse(info map[string]string) *TimesheetsResponse {
+	return &TimesheetsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a team member data
+type TeamUserResponse struct {
+	*ResponseBody
+	ResponseData *models.TeamUser `json:"data"`
+}
+
+func NewTeamUserResponse(info map[string]string) *TeamUserResponse {
+	return &TeamUserResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of team members data
+type TeamUsersResponse struct {
+	*ResponseBody
+	ResponseData []*models.TeamUser `json:"data"`
+}
+
+func NewTeamUsersResponse(info map[string]string) *TeamUsersResponse {
+	return &TeamUsersResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with team report rows
+type TeamReportResponse struct {
+	*ResponseBody
+	ResponseData []*models.TeamReportAggregation `json:"data"`
+}
+
+func NewTeamReportResponse(info map[string]string) *TeamReportResponse {
+	return &TeamReportResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with the report of an import of time entries
+type ImportReportResponse struct {
+	*ResponseBody
+	ResponseData *models.ImportReport `json:"data"`
+}
+
+func NewImportReportResponse(info map[string]string) *ImportReportResponse {
+	return &ImportReportResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a webhook
+type WebhookResponse struct {
+	*ResponseBody
+	ResponseData *models.Webhook `json:"data"`
+}
+
+func NewWebhookResponse(info map[string]string) *WebhookResponse {
+	return &WebhookResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of webhooks
+type WebhooksResponse struct {
+	*ResponseBody
+	ResponseData []*models.Webhook `json:"data"`
+}
+
+func NewWebhooksResponse(info map[string]string) *WebhooksResponse {
+	return &WebhooksResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of webhook deliveries
+type WebhookDeliveriesResponse struct {
+	*ResponseBody
+	ResponseData []*models.WebhookDelivery `json:"data"`
+}
+
+func NewWebhookDeliveriesResponse(info map[string]string) *WebhookDeliveriesResponse {
+	return &WebhookDeliveriesResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of team's issue patterns
+type IssuePatternsResponse struct {
+	*ResponseBody
+	ResponseData []*models.IssuePattern `json:"data"`
+}
+
+func NewIssuePatternsResponse(info map[string]string) *IssuePatternsResponse {
+	return &IssuePatternsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// CalendarFeed - the URL of user's personal iCalendar feed
+type CalendarFeed struct {
+	URL string `json:"url"`
+}
+
+// Response with the URL of user's iCalendar feed
+type CalendarFeedResponse struct {
+	*ResponseBody
+	ResponseData *CalendarFeed `json:"data"`
+}
+
+func NewCalendarFeedResponse(info map[string]string) *CalendarFeedResponse {
+	return &CalendarFeedResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with the report of an uploaded calendar
+type MeetingProposalsReportResponse struct {
+	*ResponseBody
+	ResponseData *models.MeetingProposalsReport `json:"data"`
+}
+
+func NewMeetingProposalsReportResponse(info map[string]string) *MeetingProposalsReportResponse {
+	return &MeetingProposalsReportResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of user's meeting proposals
+type MeetingProposalsResponse struct {
+	*ResponseBody
+	ResponseData []*models.MeetingProposal `json:"data"`
+}
+
+func NewMeetingProposalsResponse(info map[string]string) *MeetingProposalsResponse {
+	return &MeetingProposalsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with user's pomodoro durations
+type PomodoroSettingsResponse struct {
+	*ResponseBody
+	ResponseData *models.PomodoroSettings `json:"data"`
+}
+
+func NewPomodoroSettingsResponse(info map[string]string) *PomodoroSettingsResponse {
+	return &PomodoroSettingsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a work schedule
+type WorkScheduleResponse struct {
+	*ResponseBody
+	ResponseData *models.WorkSchedule `json:"data"`
+}
+
+func NewWorkScheduleResponse(info map[string]string) *WorkScheduleResponse {
+	return &WorkScheduleResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a rounding rule
+type RoundingRuleResponse struct {
+	*ResponseBody
+	ResponseData *models.RoundingRule `json:"data"`
+}
+
+func NewRoundingRuleResponse(info map[string]string) *RoundingRuleResponse {
+	return &RoundingRuleResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with user's expected vs tracked time
+type CapacityReportResponse struct {
+	*ResponseBody
+	ResponseData *models.CapacityReport `json:"data"`
+}
+
+func NewCapacityReportResponse(info map[string]string) *CapacityReportResponse {
+	return &CapacityReportResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a time off
+type TimeOffResponse struct {
+	*ResponseBody
+	ResponseData *models.TimeOff `json:"data"`
+}
+
+func NewTimeOffResponse(info map[string]string) *TimeOffResponse {
+	return &TimeOffResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of user's time off
+type TimeOffsResponse struct {
+	*ResponseBody
+	ResponseData []*models.TimeOff `json:"data"`
+}
+
+func NewTimeOffsResponse(info map[string]string) *TimeOffsResponse {
+	return &TimeOffsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with a team's holiday
+type HolidayResponse struct {
+	*ResponseBody
+	ResponseData *models.Holiday `json:"data"`
+}
+
+func NewHolidayResponse(info map[string]string) *HolidayResponse {
+	return &HolidayResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of team's holidays
+type HolidaysResponse struct {
+	*ResponseBody
+	ResponseData []*models.Holiday `json:"data"`
+}
+
+func NewHolidaysResponse(info map[string]string) *HolidaysResponse {
+	return &HolidaysResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with an idle interval of a user's timer
+type IdleIntervalResponse struct {
+	*ResponseBody
+	ResponseData *models.IdleInterval `json:"data"`
+}
+
+func NewIdleIntervalResponse(info map[string]string) *IdleIntervalResponse {
+	return &IdleIntervalResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with array of user's pending idle intervals
+type IdleIntervalsResponse struct {
+	*ResponseBody
+	ResponseData []*models.IdleInterval `json:"data"`
+}
+
+func NewIdleIntervalsResponse(info map[string]string) *IdleIntervalsResponse {
+	return &IdleIntervalsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+// Response with tasks matching a search
+type TaskSuggestionsResponse struct {
+	*ResponseBody
+	ResponseData []*models.TaskSuggestion `json:"data"`
+}
+
+func NewTaskSuggestionsResponse(info map[string]string) *TaskSuggestionsResponse {
+	return &TaskSuggestionsResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}
+
+type SearchResponse struct {
+	*ResponseBody
+	ResponseData []*models.TaskSearchResult `json:"data"`
+}
+
+func NewSearchResponse(info map[string]string) *SearchResponse {
+	return &SearchResponse{
+		ResponseBody: NewResponseBody(info),
+	}
+}